@@ -7,25 +7,43 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	pb "github.com/s3storage/api/proto"
 	"github.com/s3storage/internal/api"
+	"github.com/s3storage/internal/api/s3"
+	"github.com/s3storage/internal/applog"
+	"github.com/s3storage/internal/chunkcache"
+	"github.com/s3storage/internal/backoff"
+	"github.com/s3storage/internal/bwmeter"
 	"github.com/s3storage/internal/circuitbreaker"
+	"github.com/s3storage/internal/cluster"
+	"github.com/s3storage/internal/coordinator"
+	"github.com/s3storage/internal/ec"
 	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/metacache"
 	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/spool"
 	"github.com/s3storage/internal/storage"
+	"github.com/s3storage/internal/telemetry"
+	"github.com/s3storage/internal/usagecache"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
-	defaultHTTPPort       = "8080"
-	storageServerTimeout  = 30 * time.Second
-	heartbeatCheckTimeout = 30 * time.Second
+	defaultHTTPPort        = "8080"
+	serviceName            = "api-gateway"
+	storageServerTimeout   = 30 * time.Second
+	heartbeatCheckTimeout  = 30 * time.Second
+	telemetryGaugeInterval = 15 * time.Second
 )
 
 func main() {
@@ -37,12 +55,90 @@ func main() {
 		log.Fatal("DATABASE_URL environment variable is required")
 	}
 
+	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	ecEnabled := getEnv("EC_ENABLED", "") == "true"
+	ecDataShards := getEnvInt("EC_DATA_SHARDS", ec.DefaultDataShards)
+	ecParityShards := getEnvInt("EC_PARITY_SHARDS", ec.DefaultParityShards)
+
+	// PLACEMENT_STRATEGY selects the in-memory chunk placement backend:
+	// "consistent" (default) is virtual-node consistent hashing with
+	// bounded-load placement (see hasher.HashRing); "rendezvous" is HRW
+	// hashing (see hasher.RendezvousRing), which needs no virtual node
+	// tuning and supports true top-N replica selection via GetServers.
+	placementStrategy := getEnv("PLACEMENT_STRATEGY", "consistent")
+
+	// chunk-load-threads/chunk-load-ahead-style knobs for UploadFile's
+	// parallel chunk upload pipeline; 0 means "pick a sensible default at
+	// upload time" (see api.DefaultUploadConcurrency).
+	uploadChunkThreads := getEnvInt("UPLOAD_CHUNK_THREADS", 0)
+	uploadChunkLoadAhead := getEnvInt("UPLOAD_CHUNK_LOAD_AHEAD", 0)
+
+	// Metadata cache in front of GetFileByID/GetChunksByFileID. Unset (the
+	// default) makes it a no-op passthrough - see metacache.New.
+	metaCacheRedisURL := getEnv("METADATA_CACHE_REDIS_URL", "")
+	metaCacheTTL := time.Duration(getEnvInt("METADATA_CACHE_TTL_SECONDS", int(metacache.DefaultTTL/time.Second))) * time.Second
+
+	// Write-ahead spool for chunks that can't reach their hash-ring-assigned
+	// server synchronously during upload (see internal/spool). Unset (the
+	// default) disables spooling: uploadOneChunk fails the upload instead,
+	// as before.
+	spoolDir := getEnv("SPOOL_DIR", "")
+	spoolMaxBytes := int64(getEnvInt("SPOOL_MAX_BYTES", 0))
+
+	// Leader election across an HA deployment's gateway instances, gating
+	// the cleanup job and hash ring refresh loop to whichever one holds
+	// the lease (see internal/coordinator). Unset (the default) makes
+	// every gateway run everything, as before - fine for single-node dev
+	// setups but not for an HA deployment.
+	leaderElectionEnabled := getEnv("LEADER_ELECTION_ENABLED", "") == "true"
+	leaderElectionHolderID := getEnv("LEADER_ELECTION_HOLDER_ID", "")
+
+	// Per-storage-server bandwidth accounting and adaptive concurrency
+	// limiting for gRPC chunk transfers (see internal/bwmeter). Disabled by
+	// default: uploads/downloads stay bounded only by UploadConcurrency and
+	// the caller's own parallelism, as before.
+	bwMeterEnabled := getEnv("BANDWIDTH_METER_ENABLED", "") == "true"
+	bwMeterConcurrencyFloor := getEnvFloat("BANDWIDTH_CONCURRENCY_FLOOR", 1)
+	bwMeterConcurrencyCeiling := getEnvFloat("BANDWIDTH_CONCURRENCY_CEILING", 64)
+
+	// Gossip cluster membership (see internal/cluster): joining lets the
+	// gateway react to storage servers coming and going in sub-second time
+	// instead of waiting on the next hash ring refresh poll. Empty seeds
+	// disables it, leaving RefreshHashRing's DB polling as the only path,
+	// as before.
+	clusterSeeds := splitNonEmpty(getEnv("CLUSTER_SEEDS", ""))
+	clusterPort := getEnvInt("CLUSTER_PORT", 7946)
+	healthProbeEnabled := getEnv("HEALTH_PROBE", "true") != "false"
+
+	// Structured JSON logger every background loop and per-request handler
+	// below logs through - see internal/applog and internal/logctx.
+	logger := applog.New(getEnv("LOG_LEVEL", "info"))
+
 	log.Printf("Starting API Gateway")
 	log.Printf("HTTP Port: %s", httpPort)
 
 	// Initialize database connection
 	ctx := context.Background()
-	dbPool, err := pgxpool.New(ctx, databaseURL)
+
+	shutdownTracer, err := telemetry.InitTracer(ctx, serviceName, otlpEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracer(shutdownCtx); err != nil {
+			log.Printf("Error shutting down tracer: %v", err)
+		}
+	}()
+
+	dbConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to parse database URL: %v", err)
+	}
+	dbConfig.ConnConfig.Tracer = storage.NewTraceLog(logger)
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -55,17 +151,53 @@ func main() {
 	log.Println("Database connection established")
 
 	// Initialize storage
-	store := storage.NewPostgresStorage(dbPool)
+	store := storage.NewPostgresStorageWithLogger(dbPool, logger)
+
+	metaCache, err := metacache.New(store, metaCacheRedisURL, metaCacheTTL)
+	if err != nil {
+		log.Fatalf("Failed to initialize metadata cache: %v", err)
+	}
+
+	retryConfig := retry.DefaultRetryConfig()
+	retryConfig.OnRetry = func(attempt int, retryErr error) {
+		telemetry.RetryAttempts.WithLabelValues("storage_chunk_io").Inc()
+		logger.Info("retrying storage chunk I/O", "attempt", attempt, "error", retryErr.Error())
+	}
+
+	var placementRing hasher.PlacementRing
+	switch placementStrategy {
+	case "rendezvous":
+		placementRing = hasher.NewRendezvousRing()
+	case "consistent", "":
+		placementRing = hasher.NewHashRing()
+	default:
+		log.Fatalf("Invalid PLACEMENT_STRATEGY %q: must be \"consistent\" or \"rendezvous\"", placementStrategy)
+	}
 
 	// Initialize API Gateway
 	gateway := &api.APIGateway{
 		Router:          gin.Default(),
 		DB:              dbPool,
 		Storage:         store,
+		MetaCache:       metaCache,
 		StorageClients:  make(map[uuid.UUID]*grpc.ClientConn),
 		CircuitBreakers: make(map[uuid.UUID]*circuitbreaker.CircuitBreaker),
-		HashRing:        hasher.NewHashRing(),
-		RetryConfig:     retry.DefaultRetryConfig(),
+		StorageBackoffs: make(map[uuid.UUID]*backoff.Backoff),
+		HashRing:        placementRing,
+		RetryConfig:     retryConfig,
+		ChunkCache:      chunkcache.NewCache(chunkcache.DefaultMaxBytes, chunkcache.DefaultMaxEntries),
+		Logger:          logger,
+		ECConfig: api.ECConfig{
+			Enabled:      ecEnabled,
+			DataShards:   ecDataShards,
+			ParityShards: ecParityShards,
+		},
+		UploadConcurrency: uploadChunkThreads,
+		UploadQueueDepth:  uploadChunkLoadAhead,
+	}
+
+	if bwMeterEnabled {
+		gateway.BWMeter = bwmeter.New(bwMeterConcurrencyFloor, bwMeterConcurrencyCeiling)
 	}
 
 	// Initialize hash ring with active storage servers
@@ -73,6 +205,54 @@ func main() {
 		log.Fatalf("Failed to initialize hash ring: %v", err)
 	}
 
+	// Join the gossip cluster so the hash ring reacts to storage servers
+	// joining/leaving within gossip's sub-second convergence time, rather
+	// than waiting on the next StartHashRingRefreshLoop poll. Disabled (nil
+	// gatewayCluster) when CLUSTER_SEEDS is empty, leaving DB polling as
+	// the only path, as before.
+	var gatewayCluster *cluster.Cluster
+	if len(clusterSeeds) > 0 {
+		gatewayCluster, err = cluster.New(cluster.Config{
+			ServerID:    uuid.New(),
+			BindPort:    clusterPort,
+			Seeds:       clusterSeeds,
+			HealthProbe: gatewayHealthProbe(healthProbeEnabled),
+			OnJoin:      gateway.HandleMemberJoin,
+			OnLeave:     gateway.HandleMemberLeave,
+			OnUpdate:    gateway.HandleMemberUpdate,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize cluster membership: %v", err)
+		}
+		if n, err := gatewayCluster.Join(); err != nil {
+			log.Fatalf("Failed to join cluster: %v", err)
+		} else {
+			log.Printf("Joined cluster, reached %d/%d seeds", n, len(clusterSeeds))
+		}
+	}
+
+	if spoolDir != "" {
+		chunkSpool, err := spool.New(spoolDir, spoolMaxBytes)
+		if err != nil {
+			log.Fatalf("Failed to initialize spool: %v", err)
+		}
+		gateway.Spool = chunkSpool
+	}
+
+	var leaderCoordinator *coordinator.PostgresCoordinator
+	if leaderElectionEnabled {
+		leaderCoordinator = coordinator.NewPostgresCoordinator(dbPool, "api-gateway", leaderElectionHolderID, logger)
+		if err := leaderCoordinator.Start(ctx); err != nil {
+			log.Fatalf("Failed to start leader election: %v", err)
+		}
+		gateway.Coordinator = leaderCoordinator
+	}
+
+	// Start the periodic telemetry gauge updater (hash ring size, per-server
+	// circuit breaker state)
+	stopTelemetryGauges := startTelemetryGaugeLoop(ctx, gateway)
+	defer close(stopTelemetryGauges)
+
 	// Setup routes
 	setupRoutes(gateway)
 
@@ -84,6 +264,64 @@ func main() {
 	gateway.StartCleanupJob(ctx)
 	log.Println("Cleanup job started")
 
+	// Start the version compactor: retries DeleteChunk for chunks orphaned
+	// by a permanent object-version deletion whose first attempt failed
+	gateway.StartVersionCompactor(ctx)
+	log.Println("Version compactor started")
+
+	// Start the spool replayer, if spooling is configured
+	gateway.StartSpoolReplayer(ctx)
+	if gateway.Spool != nil {
+		log.Println("Spool replayer started")
+	}
+
+	// Start the erasure-coded shard repair loop
+	gateway.StartShardRepairLoop(ctx)
+	log.Println("Shard repair loop started")
+
+	// Start the reclaimer: detects chunks on dead servers, stuck uploads,
+	// and orphaned chunks, coordinating with other gateway replicas via the
+	// storage_cleanups table
+	workerID, err := os.Hostname()
+	if err != nil {
+		workerID = uuid.New().String()
+	}
+	reclaimer := storage.NewReclaimer(store, workerID)
+	reclaimer.Run(ctx, storage.DefaultReclaimerInterval)
+
+	// Chunk placement ring: tracks primary+replica chunk placement
+	// independently of the gateway's single-server hasher.PlacementRing,
+	// recomputing affected ranges and enqueueing rebalance jobs when
+	// storage servers come and go. A HashRingChangeListener keeps it in
+	// sync across coordinator replicas via LISTEN/NOTIFY rather than
+	// polling.
+	chunkPlacementRing := storage.NewHashRing()
+	if err := chunkPlacementRing.Reload(ctx, store); err != nil {
+		log.Fatalf("Failed to load placement ring: %v", err)
+	}
+	ringListener := storage.NewHashRingChangeListener(dbPool, store, chunkPlacementRing)
+	if err := ringListener.Run(ctx); err != nil {
+		log.Fatalf("Failed to start placement ring listener: %v", err)
+	}
+
+	// Session reaper: deletes expired resumable upload sessions and releases
+	// the chunk-slot reservations they held on the placement ring.
+	sessionReaper := storage.NewSessionReaper(store, chunkPlacementRing)
+	sessionReaper.Run(ctx, storage.DefaultSessionReaperInterval)
+
+	// Decommission manager: drives graceful draining of storage servers an
+	// operator wants to retire, placing each moved chunk via chunkPlacementRing.
+	gateway.StartDecommissionManager(chunkPlacementRing)
+
+	// Usage cache: backs GET /admin/usage and the usage_* /metrics gauges
+	// with at-most-once-per-TTL aggregates instead of a fresh full scan of
+	// files on every call.
+	gateway.StartUsageCache(usagecache.DefaultTTL, usagecache.DefaultTopN)
+
+	// Bulk delete manager: drives async, resumable bulk file deletion jobs
+	// and resumes any left "running" by a previous instance of this gateway.
+	gateway.StartBulkDeleteManager(ctx)
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", httpPort),
@@ -105,12 +343,51 @@ func main() {
 
 	log.Println("Shutting down gracefully...")
 
+	// Leave the gossip cluster, if joined
+	if gatewayCluster != nil {
+		if err := gatewayCluster.Shutdown(); err != nil {
+			log.Printf("Error leaving cluster: %v", err)
+		}
+	}
+
 	// Stop hash ring refresh loop
 	gateway.StopHashRingRefreshLoop()
 
 	// Stop cleanup job
 	gateway.StopCleanupJob()
 
+	// Stop version compactor
+	gateway.StopVersionCompactor()
+
+	// Stop the spool replayer
+	gateway.StopSpoolReplayer()
+
+	// Stop leader election, releasing the lease so another gateway can
+	// take over its singleton jobs immediately instead of waiting for TTL
+	if leaderCoordinator != nil {
+		leaderCoordinator.Stop()
+	}
+
+	// Stop shard repair loop
+	gateway.StopShardRepairLoop()
+
+	// Stop reclaimer
+	reclaimer.Stop()
+
+	// Stop the placement ring listener
+	ringListener.Stop()
+
+	// Stop the session reaper
+	sessionReaper.Stop()
+
+	// Stop any in-flight decommission jobs; they'll resume from their last
+	// cursor next time they're started/resumed
+	gateway.StopDecommissionManager()
+
+	// Stop any in-flight bulk delete jobs; ResumeAll picks them back up next
+	// time this gateway starts
+	gateway.StopBulkDeleteManager()
+
 	// Shutdown HTTP server
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -127,6 +404,14 @@ func main() {
 
 // setupRoutes configures all API routes
 func setupRoutes(gw *api.APIGateway) {
+	// Trace every request and record it in the RED latency histogram
+	gw.Router.Use(api.TracingMiddleware())
+	// Tag every log line emitted while handling a request with a request_id
+	gw.Router.Use(api.RequestLoggerMiddleware(gw.Logger))
+
+	// Prometheus metrics (RED histogram, hash ring/circuit breaker gauges, retry counters)
+	gw.Router.GET("/metrics", api.MetricsHandler())
+
 	// Health check
 	gw.Router.GET("/health", func(c *gin.Context) {
 		healthCheck(c, gw)
@@ -147,12 +432,106 @@ func setupRoutes(gw *api.APIGateway) {
 		apiGroup.GET("", func(c *gin.Context) {
 			gw.ListFiles(c)
 		})
+		apiGroup.GET("/versions/:filename", func(c *gin.Context) {
+			gw.ListFileVersions(c)
+		})
 		apiGroup.DELETE("/:file_id", func(c *gin.Context) {
 			gw.DeleteFile(c)
 		})
+		apiGroup.POST("/batch-delete", func(c *gin.Context) {
+			gw.BatchDeleteFiles(c)
+		})
+		apiGroup.DELETE("", func(c *gin.Context) {
+			gw.DeleteFilesByPrefix(c)
+		})
+	}
+
+	// Filesystem-style directory listing on top of the flat files table
+	dirsGroup := gw.Router.Group("/dirs")
+	{
+		dirsGroup.GET("/*path", func(c *gin.Context) {
+			gw.ListDirectory(c)
+		})
+	}
+
+	// Resumable, tus-style chunk uploads
+	uploadsGroup := gw.Router.Group("/uploads")
+	{
+		uploadsGroup.POST("", func(c *gin.Context) {
+			gw.CreateUploadSession(c)
+		})
+		uploadsGroup.PATCH("/:session_id", func(c *gin.Context) {
+			gw.AppendUpload(c)
+		})
+		uploadsGroup.HEAD("/:session_id", func(c *gin.Context) {
+			gw.GetUploadOffset(c)
+		})
+	}
+
+	// AWS S3 v4 wire-compatible frontend, alongside the existing /files group
+	s3.NewServer(gw).RegisterRoutes(gw.Router)
+
+	// Admin operations
+	adminGroup := gw.Router.Group("/admin")
+	{
+		adminGroup.DELETE("/cache/:chunk_id", func(c *gin.Context) {
+			gw.EvictCacheEntry(c)
+		})
+		adminGroup.GET("/usage", func(c *gin.Context) {
+			gw.GetUsageStats(c)
+		})
+		adminGroup.POST("/servers/:id/decommission", func(c *gin.Context) {
+			gw.StartDecommission(c)
+		})
+		adminGroup.GET("/servers/:id/decommission/status", func(c *gin.Context) {
+			gw.GetDecommissionStatus(c)
+		})
+		adminGroup.POST("/servers/:id/decommission/cancel", func(c *gin.Context) {
+			gw.CancelDecommission(c)
+		})
+		adminGroup.POST("/servers/:id/decommission/pause", func(c *gin.Context) {
+			gw.PauseDecommission(c)
+		})
+		adminGroup.POST("/servers/:id/decommission/resume", func(c *gin.Context) {
+			gw.ResumeDecommission(c)
+		})
+		adminGroup.GET("/jobs/:id", func(c *gin.Context) {
+			gw.GetDeleteJobStatus(c)
+		})
+		adminGroup.GET("/jobs/:id/stream", func(c *gin.Context) {
+			gw.StreamDeleteJobStatus(c)
+		})
+		adminGroup.POST("/jobs/:id/cancel", func(c *gin.Context) {
+			gw.CancelDeleteJob(c)
+		})
 	}
 }
 
+// startTelemetryGaugeLoop periodically refreshes the hash-ring-size and
+// circuit-breaker-state gauges exported on /metrics. It returns a channel
+// that stops the loop when closed.
+func startTelemetryGaugeLoop(ctx context.Context, gw *api.APIGateway) chan struct{} {
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(telemetryGaugeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				gw.UpdateTelemetryGauges()
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stop
+}
+
 // initializeHashRing initializes the consistent hash ring with active storage servers
 func initializeHashRing(ctx context.Context, gw *api.APIGateway, store *storage.PostgresStorage) error {
 	servers, err := store.GetActiveStorageServers(ctx, heartbeatCheckTimeout)
@@ -173,7 +552,7 @@ func initializeHashRing(ctx context.Context, gw *api.APIGateway, store *storage.
 		}
 
 		// Create gRPC connection
-		conn, err := connectToStorageServer(server.GRPCAddress)
+		conn, err := connectToStorageServer(gw, server.ServerID, server.GRPCAddress)
 		if err != nil {
 			log.Printf("Warning: failed to connect to storage server %s: %v", server.ServerID, err)
 			continue
@@ -187,18 +566,24 @@ func initializeHashRing(ctx context.Context, gw *api.APIGateway, store *storage.
 }
 
 // connectToStorageServer creates a gRPC connection to a storage server
-func connectToStorageServer(address string) (*grpc.ClientConn, error) {
+func connectToStorageServer(gw *api.APIGateway, serverID uuid.UUID, address string) (*grpc.ClientConn, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), storageServerTimeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, address,
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(1024*1024*1024), // 1GB
 			grpc.MaxCallSendMsgSize(1024*1024*1024), // 1GB
 		),
-	)
+	}
+	if gw.BWMeter != nil {
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(gw.BWMeter.StatsHandler(serverID.String())))
+	}
+
+	conn, err := grpc.DialContext(ctx, address, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to storage server: %w", err)
 	}
@@ -245,3 +630,74 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an environment variable as an int or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvFloat gets an environment variable as a float64 or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// splitNonEmpty splits a comma-separated env var into its trimmed,
+// non-empty parts. An empty or whitespace-only input returns nil.
+func splitNonEmpty(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// gatewayHealthProbe returns a cluster.Config.HealthProbe that dials a
+// suspect storage server directly and issues a gRPC health check, or nil if
+// enabled is false (trusting memberlist's own SWIM failure detection
+// outright).
+func gatewayHealthProbe(enabled bool) func(context.Context, string) error {
+	if !enabled {
+		return nil
+	}
+	return func(ctx context.Context, address string) error {
+		conn, err := grpc.DialContext(ctx, address,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithBlock(),
+		)
+		if err != nil {
+			return fmt.Errorf("dialing %s: %w", address, err)
+		}
+		defer conn.Close()
+
+		client := pb.NewStorageServiceClient(conn)
+		_, err = client.HealthCheck(ctx, &pb.HealthCheckRequest{})
+		if err != nil {
+			return fmt.Errorf("health check against %s: %w", address, err)
+		}
+		return nil
+	}
+}
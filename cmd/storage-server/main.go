@@ -2,28 +2,52 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/go-logr/stdr"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/cluster"
 	grpchandlers "github.com/s3storage/internal/grpc"
+	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/notify"
 	"github.com/s3storage/internal/storage"
+	"github.com/s3storage/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 )
 
 const (
-	defaultGRPCPort   = "50051"
-	defaultDataDir    = "/data"
-	heartbeatInterval = 10 * time.Second
-	virtualNodesCount = 150
+	defaultGRPCPort    = "50051"
+	defaultDataDir     = "/data"
+	defaultMetricsPort = "9090"
+	defaultClusterPort = "7946"
+	serviceName        = "storage-server"
+	livenessInterval   = 60 * time.Second
+	virtualNodesCount  = 150
+	// defaultDrainGrace is how long a draining server waits, after marking
+	// itself draining in the DB and over gossip, before GracefulStop - long
+	// enough for an in-flight coordinator lookup against the old ring state
+	// to land and for the gossiped Draining flag to reach every gateway.
+	defaultDrainGrace = 15 * time.Second
+	// replicationTimeout bounds a single peer replication RPC (dial + push).
+	replicationTimeout = 30 * time.Second
 )
 
 func main() {
@@ -31,7 +55,19 @@ func main() {
 	serverID := getEnv("SERVER_ID", "storage-1")
 	grpcPort := getEnv("GRPC_PORT", defaultGRPCPort)
 	dataDir := getEnv("DATA_DIR", defaultDataDir)
+	metricsPort := getEnv("METRICS_PORT", defaultMetricsPort)
 	databaseURL := getEnv("DATABASE_URL", "")
+	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	clusterSeeds := splitNonEmpty(getEnv("CLUSTER_SEEDS", ""))
+	clusterPort := getEnv("CLUSTER_PORT", defaultClusterPort)
+	healthProbeEnabled := getEnv("HEALTH_PROBE", "true") != "false"
+	metricsExporter := getEnv("METRICS_EXPORTER", "prometheus")
+	drainGrace := time.Duration(getEnvInt("DRAIN_GRACE_SECONDS", int(defaultDrainGrace/time.Second))) * time.Second
+	// replicationFactor is the total number of copies of a chunk across the
+	// cluster, including the primary that received it. 1 (the default)
+	// disables fan-out entirely - every deployment that hasn't opted in
+	// keeps today's single-copy behavior.
+	replicationFactor := getEnvInt("REPLICATION_FACTOR", 1)
 
 	if databaseURL == "" {
 		log.Fatal("DATABASE_URL environment variable is required")
@@ -43,7 +79,46 @@ func main() {
 
 	// Initialize database connection
 	ctx := context.Background()
-	dbPool, err := pgxpool.New(ctx, databaseURL)
+
+	shutdownTracer, err := telemetry.InitTracer(ctx, serviceName, otlpEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracer(shutdownCtx); err != nil {
+			log.Printf("Error shutting down tracer: %v", err)
+		}
+	}()
+
+	// Serve Prometheus metrics. METRICS_EXPORTER="otlp" skips the scrape
+	// endpoint entirely - metrics still get recorded into the same
+	// registered collectors, but nothing pulls them locally; a push-based
+	// OTLP metrics pipeline is left to a future request, same as how
+	// InitTracer's OTLP exporter is the only supported trace path today.
+	if metricsExporter == "prometheus" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			log.Printf("Metrics server listening on :%s", metricsPort)
+			if err := http.ListenAndServe(fmt.Sprintf(":%s", metricsPort), metricsMux); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+	} else {
+		log.Printf("METRICS_EXPORTER=%s: skipping local Prometheus scrape endpoint", metricsExporter)
+	}
+
+	dbLogger := stdr.New(log.New(os.Stderr, "", log.LstdFlags))
+
+	dbConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to parse database URL: %v", err)
+	}
+	dbConfig.ConnConfig.Tracer = storage.NewTraceLog(dbLogger)
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -56,7 +131,7 @@ func main() {
 	log.Println("Database connection established")
 
 	// Initialize storage
-	store := storage.NewPostgresStorage(dbPool)
+	store := storage.NewPostgresStorageWithLogger(dbPool, dbLogger)
 
 	// Register storage server in database
 	serverUUID := uuid.New()
@@ -88,12 +163,61 @@ func main() {
 	}
 	log.Printf("Created %d virtual nodes for consistent hashing", virtualNodesCount)
 
+	// Register this server with the reclaimer's scan-coordination table
+	if err := store.EnsureStorageCleanupRow(ctx, serverUUID); err != nil {
+		log.Fatalf("Failed to register storage server for cleanup scanning: %v", err)
+	}
+
+	// Join the gossip cluster so ring topology converges across the fleet
+	// in sub-second time, instead of waiting on the gateway's DB-polling
+	// refresh loop. The Postgres row remains the durable record; gossip is
+	// the fast path.
+	clusterPortNum, err := strconv.Atoi(clusterPort)
+	if err != nil {
+		log.Fatalf("Invalid CLUSTER_PORT %q: %v", clusterPort, err)
+	}
+	storageCluster, err := cluster.New(cluster.Config{
+		ServerID:       serverUUID,
+		GRPCAddress:    address,
+		AvailableBytes: storageServerRecord.AvailableSpace,
+		UsedBytes:      storageServerRecord.UsedSpace,
+		BindPort:       clusterPortNum,
+		Seeds:          clusterSeeds,
+		HealthProbe:    healthProbeFunc(healthProbeEnabled),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize cluster membership: %v", err)
+	}
+	if n, err := storageCluster.Join(); err != nil {
+		log.Fatalf("Failed to join cluster: %v", err)
+	} else if len(clusterSeeds) > 0 {
+		log.Printf("Joined cluster, reached %d/%d seeds", n, len(clusterSeeds))
+	}
+
 	// Initialize gRPC server
 	grpcServer, err := grpchandlers.NewStorageServer(dataDir)
 	if err != nil {
 		log.Fatalf("Failed to create gRPC server: %v", err)
 	}
 
+	// Start the resumable upload session sweeper
+	grpcServer.StartSessionSweeper(grpchandlers.DefaultSessionTTL, grpchandlers.DefaultSweepInterval)
+
+	// Notification queue: PutChunk enqueues a ChunkEvent on every successful
+	// write; when REPLICATION_FACTOR > 1 a subscriber fans it out to the
+	// next N-1 servers the ring picks for that chunk, decoupling replication
+	// from the original upload's latency.
+	notifyQueue, err := notify.NewQueue(filepath.Join(dataDir, "notify"), notify.DefaultQueueCapacity)
+	if err != nil {
+		log.Fatalf("Failed to initialize notify queue: %v", err)
+	}
+	grpcServer.SetNotifyQueue(notifyQueue)
+	if replicationFactor > 1 {
+		notifyQueue.Subscribe(replicationHandler(grpcServer, storageCluster, serverUUID, replicationFactor-1))
+	}
+	notifyCtx, cancelNotify := context.WithCancel(context.Background())
+	go notifyQueue.Run(notifyCtx)
+
 	// Create gRPC listener
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
 	if err != nil {
@@ -104,6 +228,7 @@ func main() {
 	grpcOpts := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(1024 * 1024 * 1024), // 1GB max receive
 		grpc.MaxSendMsgSize(1024 * 1024 * 1024), // 1GB max send
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	}
 	server := grpc.NewServer(grpcOpts...)
 	pb.RegisterStorageServiceServer(server, grpcServer)
@@ -111,10 +236,13 @@ func main() {
 	// Register reflection service for debugging
 	reflection.Register(server)
 
-	// Start heartbeat goroutine
+	// Start the liveness-marker goroutine. Ring membership is now driven by
+	// cluster gossip, which reacts in sub-second time; this DB write only
+	// needs to be frequent enough to back GetActiveStorageServers' stale-
+	// server fallback and the admin-facing "last seen" timestamp.
 	stopHeartbeat := make(chan struct{})
 	go func() {
-		ticker := time.NewTicker(heartbeatInterval)
+		ticker := time.NewTicker(livenessInterval)
 		defer ticker.Stop()
 
 		for {
@@ -122,7 +250,10 @@ func main() {
 			case <-ticker.C:
 				if err := store.UpdateHeartbeat(ctx, serverUUID); err != nil {
 					log.Printf("Failed to update heartbeat: %v", err)
+					telemetry.HeartbeatFailures.WithLabelValues(serverUUID.String()).Inc()
 				}
+				telemetry.StorageServerAvailableBytes.Set(float64(storageServerRecord.AvailableSpace))
+				telemetry.StorageServerUsedBytes.Set(float64(storageServerRecord.UsedSpace))
 			case <-stopHeartbeat:
 				return
 			}
@@ -147,11 +278,142 @@ func main() {
 	// Stop heartbeat
 	close(stopHeartbeat)
 
-	// Graceful shutdown
+	// Mark this server draining in the DB and over gossip so coordinators
+	// stop selecting it for new writes, then give in-flight lookups
+	// DRAIN_GRACE_SECONDS to observe the change before streams are cut.
+	grpcServer.SetDraining(true)
+	if err := store.UpdateStorageServerStatus(ctx, serverUUID, "draining"); err != nil {
+		log.Printf("Failed to mark storage server draining: %v", err)
+	}
+	if err := storageCluster.SetDraining(true); err != nil {
+		log.Printf("Failed to gossip draining state: %v", err)
+	}
+	log.Printf("Draining for %s before stopping gRPC...", drainGrace)
+	time.Sleep(drainGrace)
+
+	// Graceful shutdown - let existing streams complete.
 	server.GracefulStop()
+
+	// Leave the gossip cluster and delete the DB row (and its hash ring
+	// nodes, which cascade) now that no new writes should be in flight, so a
+	// restart under the same hostname doesn't collide with the old row.
+	if err := storageCluster.Shutdown(); err != nil {
+		log.Printf("Error leaving cluster: %v", err)
+	}
+	if err := store.DeleteStorageServer(ctx, serverUUID); err != nil {
+		log.Printf("Failed to delete storage server row: %v", err)
+	}
+
+	// Stop the resumable upload session sweeper
+	grpcServer.StopSessionSweeper()
+	cancelNotify()
+
 	log.Println("Storage server stopped")
 }
 
+// replicationHandler returns a notify.Queue subscriber that copies a
+// just-written chunk to up to replicaCount other live, non-draining cluster
+// members. Targets are selected via a hash ring built fresh from current
+// membership on every call rather than a ring maintained continuously,
+// since replication fan-out isn't on the hot path and membership rarely
+// changes between one chunk write and the next.
+func replicationHandler(gs *grpchandlers.StorageServer, c *cluster.Cluster, self uuid.UUID, replicaCount int) func(notify.ChunkEvent) error {
+	return func(ev notify.ChunkEvent) error {
+		targets := replicationTargets(c, self, ev.ChunkID.String(), replicaCount)
+		if len(targets) == 0 {
+			return nil
+		}
+
+		f, err := gs.ReadChunk(ev.ChunkID.String())
+		if err != nil {
+			return fmt.Errorf("reading local chunk for replication: %w", err)
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return fmt.Errorf("reading chunk bytes for replication: %w", err)
+		}
+
+		var errs []error
+		for _, addr := range targets {
+			if err := replicateChunk(addr, ev.ChunkID.String(), ev.Checksum, data); err != nil {
+				errs = append(errs, fmt.Errorf("replicating to %s: %w", addr, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// replicationTargets builds a hash ring over every live, non-draining
+// member other than self and returns the gRPC addresses of the replicaCount
+// servers it picks for chunkID, so the same chunk consistently lands on the
+// same replicas as long as membership doesn't change.
+func replicationTargets(c *cluster.Cluster, self uuid.UUID, chunkID string, replicaCount int) []string {
+	if replicaCount <= 0 {
+		return nil
+	}
+
+	members := c.Members()
+	ring := hasher.NewHashRing()
+	addrByID := make(map[string]string, len(members))
+	for _, m := range members {
+		if m.ServerID == self || m.Draining {
+			continue
+		}
+		if err := ring.AddServer(m.ServerID.String(), m.GRPCAddress); err != nil {
+			continue
+		}
+		addrByID[m.ServerID.String()] = m.GRPCAddress
+	}
+
+	serverIDs, err := ring.GetServers(chunkID, replicaCount)
+	if err != nil {
+		return nil
+	}
+
+	addrs := make([]string, 0, len(serverIDs))
+	for _, id := range serverIDs {
+		addrs = append(addrs, addrByID[id])
+	}
+	return addrs
+}
+
+// replicateChunk dials address and pushes data as a new chunk via PutChunk,
+// the same RPC pair internal/decommission uses to move a chunk between
+// servers it already holds connections to - this dials fresh since a
+// storage server doesn't otherwise keep connections to its peers open.
+func replicateChunk(address, chunkID, checksum string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicationTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return fmt.Errorf("dialing %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	client := pb.NewStorageServiceClient(conn)
+	stream, err := client.PutChunk(ctx)
+	if err != nil {
+		return fmt.Errorf("opening put stream: %w", err)
+	}
+	if err := stream.Send(&pb.PutChunkRequest{ChunkId: chunkID, Data: data, Checksum: checksum}); err != nil {
+		return fmt.Errorf("sending chunk data: %w", err)
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("closing put stream: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("target reported failure replicating chunk %s", chunkID)
+	}
+	return nil
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -159,3 +421,59 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an environment variable as an int or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// splitNonEmpty splits a comma-separated env var into its trimmed,
+// non-empty parts. An empty or whitespace-only input returns nil.
+func splitNonEmpty(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// healthProbeFunc returns a cluster.Config.HealthProbe that dials a peer's
+// gRPC address and issues a HealthCheck RPC, or nil if enabled is false
+// (trusting memberlist's own SWIM failure detection outright).
+func healthProbeFunc(enabled bool) func(context.Context, string) error {
+	if !enabled {
+		return nil
+	}
+	return func(ctx context.Context, grpcAddress string) error {
+		conn, err := grpc.DialContext(ctx, grpcAddress,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithBlock(),
+		)
+		if err != nil {
+			return fmt.Errorf("dialing %s: %w", grpcAddress, err)
+		}
+		defer conn.Close()
+
+		client := pb.NewStorageServiceClient(conn)
+		_, err = client.HealthCheck(ctx, &pb.HealthCheckRequest{})
+		if err != nil {
+			return fmt.Errorf("health check against %s: %w", grpcAddress, err)
+		}
+		return nil
+	}
+}
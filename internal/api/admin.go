@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EvictCacheEntry handles `DELETE /admin/cache/:chunk_id`, forcing a chunk
+// out of the local chunk cache (e.g. after it's known to be corrupt or
+// stale on disk).
+func (gw *APIGateway) EvictCacheEntry(c *gin.Context) {
+	chunkID := c.Param("chunk_id")
+
+	if gw.ChunkCache == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chunk cache is not enabled"})
+		return
+	}
+
+	if !gw.ChunkCache.Remove(chunkID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chunk not cached", "chunk_id": chunkID})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUsageStats handles `GET /admin/usage`, returning total bytes stored,
+// per-server used/available bytes, per-status file counts, and the top-N
+// largest files. The response is served from gw.UsageCache rather than
+// computed fresh on every call, so repeated requests (a dashboard polling,
+// a Prometheus scrape) don't turn into a full files-table scan each time.
+func (gw *APIGateway) GetUsageStats(c *gin.Context) {
+	if gw.UsageCache == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage cache is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	stats, err := gw.UsageCache.Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute usage stats", "details": err.Error()})
+		return
+	}
+
+	servers := make([]gin.H, 0, len(stats.Servers))
+	for _, server := range stats.Servers {
+		servers = append(servers, gin.H{
+			"server_id":       server.ServerID,
+			"grpc_address":    server.GRPCAddress,
+			"used_bytes":      server.UsedBytes,
+			"available_bytes": server.AvailableBytes,
+		})
+	}
+
+	topFiles := make([]gin.H, 0, len(stats.TopFiles))
+	for _, file := range stats.TopFiles {
+		topFiles = append(topFiles, gin.H{
+			"file_id":  file.FileID,
+			"filename": file.Filename,
+			"size":     file.Size,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_bytes": stats.TotalBytes,
+		"by_status":   stats.StatusCounts,
+		"servers":     servers,
+		"top_files":   topFiles,
+	})
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/bulkdelete"
+	"github.com/s3storage/internal/storage"
+)
+
+// batchDeleteRequest is the body for POST /files/batch-delete.
+type batchDeleteRequest struct {
+	FileIDs []uuid.UUID `json:"file_ids" binding:"required,min=1"`
+}
+
+// BatchDeleteFiles handles `POST /files/batch-delete`, kicking off an async
+// job that deletes every listed file's chunks and metadata in the
+// background. It returns 202 Accepted with the job's ID and initial status;
+// poll GET /admin/jobs/:id or GET /admin/jobs/:id/stream for progress.
+func (gw *APIGateway) BatchDeleteFiles(c *gin.Context) {
+	if gw.BulkDeleteManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bulk delete is not enabled"})
+		return
+	}
+
+	var req batchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	job, err := gw.BulkDeleteManager.StartBulkDelete(ctx, req.FileIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start bulk delete", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deleteJobToJSON(bulkdelete.JobStatusFor(job)))
+}
+
+// DeleteFilesByPrefix handles `DELETE /files?prefix=`, kicking off an async
+// job that deletes every non-deleted file whose path starts with prefix, at
+// the moment the request is made (files that land under the prefix later
+// are not included).
+func (gw *APIGateway) DeleteFilesByPrefix(c *gin.Context) {
+	if gw.BulkDeleteManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bulk delete is not enabled"})
+		return
+	}
+
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	job, err := gw.BulkDeleteManager.StartBulkDeleteByPrefix(ctx, prefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start bulk delete", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deleteJobToJSON(bulkdelete.JobStatusFor(job)))
+}
+
+// GetDeleteJobStatus handles `GET /admin/jobs/:id`, returning a single
+// snapshot of a bulk delete job's progress.
+func (gw *APIGateway) GetDeleteJobStatus(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id", "details": err.Error()})
+		return
+	}
+
+	if gw.BulkDeleteManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bulk delete is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	status, err := gw.BulkDeleteManager.GetStatus(ctx, jobID)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no such delete job", "job_id": jobID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get delete job status", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deleteJobToJSON(status))
+}
+
+// CancelDeleteJob handles `POST /admin/jobs/:id/cancel`, stopping a bulk
+// delete job's worker. Files already deleted stay deleted.
+func (gw *APIGateway) CancelDeleteJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id", "details": err.Error()})
+		return
+	}
+
+	if gw.BulkDeleteManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bulk delete is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := gw.BulkDeleteManager.Cancel(ctx, jobID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel delete job", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "delete job cancelled", "job_id": jobID})
+}
+
+// deleteJobStreamInterval is how often StreamDeleteJobStatus pushes a
+// progress event.
+const deleteJobStreamInterval = 1 * time.Second
+
+// StreamDeleteJobStatus handles `GET /admin/jobs/:id/stream`, server-sending
+// a JSON progress event roughly once a second until the job reaches a
+// terminal status or the client disconnects.
+func (gw *APIGateway) StreamDeleteJobStatus(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id", "details": err.Error()})
+		return
+	}
+
+	if gw.BulkDeleteManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bulk delete is not enabled"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ticker := time.NewTicker(deleteJobStreamInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := gw.BulkDeleteManager.GetStatus(c.Request.Context(), jobID)
+		if err != nil {
+			fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", err.Error())
+			c.Writer.Flush()
+			return
+		}
+
+		body, err := json.Marshal(deleteJobToJSON(status))
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(c.Writer, "data: %s\n\n", body)
+		c.Writer.Flush()
+
+		if status.Status != "running" {
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func deleteJobToJSON(status *bulkdelete.JobStatus) gin.H {
+	body := gin.H{
+		"job_id":                   status.JobID,
+		"prefix":                   status.Prefix,
+		"status":                   status.Status,
+		"files_total":              status.FilesTotal,
+		"files_deleted":            status.FilesDeleted,
+		"files_failed":             status.FilesFailed,
+		"chunks_deleted":           status.ChunksDeleted,
+		"chunks_failed":            status.ChunksFailed,
+		"bytes_total":              status.BytesTotal,
+		"bytes_reclaimed":          status.BytesReclaimed,
+		"percent_complete":         status.PercentComplete,
+		"throughput_bytes_per_sec": status.ThroughputBytesPerSec,
+		"started_at":               status.StartedAt,
+		"completed_at":             status.CompletedAt,
+	}
+	if status.ETA != nil {
+		body["eta_seconds"] = status.ETA.Seconds()
+	}
+	return body
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/decommission"
+	"github.com/s3storage/internal/storage"
+)
+
+// StartDecommission handles `POST /admin/servers/:id/decommission`, kicking
+// off (or resuming, if one is already in flight) a graceful drain of every
+// chunk on the given storage server onto the rest of the ring.
+func (gw *APIGateway) StartDecommission(c *gin.Context) {
+	serverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server id", "details": err.Error()})
+		return
+	}
+
+	if gw.DecommissionManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "decommissioning is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := gw.DecommissionManager.StartDecommission(ctx, serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start decommission", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobToJSON(decommission.JobStatusFor(job)))
+}
+
+// GetDecommissionStatus handles `GET /admin/servers/:id/decommission/status`,
+// returning the job's progress, throughput, and ETA.
+func (gw *APIGateway) GetDecommissionStatus(c *gin.Context) {
+	serverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server id", "details": err.Error()})
+		return
+	}
+
+	if gw.DecommissionManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "decommissioning is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := gw.Storage.GetActiveDecommissionJobForServer(ctx, serverID)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no decommission job for server", "server_id": serverID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get decommission status", "details": err.Error()})
+		return
+	}
+
+	status, err := gw.DecommissionManager.GetStatus(ctx, job.JobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get decommission status", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobToJSON(status))
+}
+
+// CancelDecommission handles
+// `POST /admin/servers/:id/decommission/cancel`, stopping the server's
+// active decommission job. Chunks already moved stay on their new server.
+func (gw *APIGateway) CancelDecommission(c *gin.Context) {
+	serverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server id", "details": err.Error()})
+		return
+	}
+
+	if gw.DecommissionManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "decommissioning is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := gw.Storage.GetActiveDecommissionJobForServer(ctx, serverID)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no decommission job for server", "server_id": serverID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up decommission job", "details": err.Error()})
+		return
+	}
+
+	if err := gw.DecommissionManager.Cancel(ctx, job.JobID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel decommission", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "decommission cancelled", "job_id": job.JobID})
+}
+
+// PauseDecommission handles `POST /admin/servers/:id/decommission/pause`.
+func (gw *APIGateway) PauseDecommission(c *gin.Context) {
+	serverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server id", "details": err.Error()})
+		return
+	}
+
+	if gw.DecommissionManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "decommissioning is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := gw.Storage.GetActiveDecommissionJobForServer(ctx, serverID)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no decommission job for server", "server_id": serverID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up decommission job", "details": err.Error()})
+		return
+	}
+
+	if err := gw.DecommissionManager.Pause(ctx, job.JobID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to pause decommission", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "decommission paused", "job_id": job.JobID})
+}
+
+// ResumeDecommission handles `POST /admin/servers/:id/decommission/resume`.
+func (gw *APIGateway) ResumeDecommission(c *gin.Context) {
+	serverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid server id", "details": err.Error()})
+		return
+	}
+
+	if gw.DecommissionManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "decommissioning is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := gw.Storage.GetActiveDecommissionJobForServer(ctx, serverID)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no decommission job for server", "server_id": serverID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up decommission job", "details": err.Error()})
+		return
+	}
+
+	resumed, err := gw.DecommissionManager.Resume(ctx, job.JobID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to resume decommission", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobToJSON(decommission.JobStatusFor(resumed)))
+}
+
+func jobToJSON(status *decommission.JobStatus) gin.H {
+	body := gin.H{
+		"job_id":                   status.JobID,
+		"server_id":                status.ServerID,
+		"status":                   status.Status,
+		"chunks_total":             status.ChunksTotal,
+		"chunks_moved":             status.ChunksMoved,
+		"failed_chunks":            status.FailedChunks,
+		"bytes_total":              status.BytesTotal,
+		"bytes_moved":              status.BytesMoved,
+		"percent_complete":         status.PercentComplete,
+		"throughput_bytes_per_sec": status.ThroughputBytesPerSec,
+		"started_at":               status.StartedAt,
+		"completed_at":             status.CompletedAt,
+	}
+	if status.ETA != nil {
+		body["eta_seconds"] = status.ETA.Seconds()
+	}
+	return body
+}
@@ -10,7 +10,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/chunkcache"
+	"github.com/s3storage/internal/fetcher"
+	"github.com/s3storage/internal/logctx"
 	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/storage"
 )
 
 const (
@@ -35,8 +39,9 @@ func (gw *APIGateway) DownloadFile(c *gin.Context) {
 	defer cancel()
 
 	// Get file metadata
-	file, err := gw.Storage.GetFileByID(ctx, fileID)
+	file, err := gw.MetaCache.GetFileByID(ctx, fileID)
 	if err != nil {
+		logctx.FromContext(ctx).Info("file not found", "file_id", fileIDStr)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "file not found",
 			"file_id": fileIDStr,
@@ -54,48 +59,239 @@ func (gw *APIGateway) DownloadFile(c *gin.Context) {
 	}
 
 	// Set response headers
+	etag := fmt.Sprintf("%q", file.Checksum)
 	c.Header("Content-Type", file.ContentType)
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
-	c.Header("Content-Length", fmt.Sprintf("%d", file.TotalSize))
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", etag)
 
-	// Stream chunks to client
-	c.Status(http.StatusOK)
+	rangeHeader := c.GetHeader("Range")
+	if rangeHeader != "" {
+		// If-Range pins the Range request to the file content it was
+		// computed against: if the file changed (different ETag) since,
+		// fall back to a full 200 response instead of serving a range of
+		// the new content under the old offsets.
+		if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != etag {
+			rangeHeader = ""
+		}
+	}
 
-	for _, chunk := range file.Chunks {
-		// Get storage client
-		client, err := gw.getStorageClient(chunk.StorageServerID)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
-				"error":        "storage server unavailable",
-				"chunk_number": chunk.ChunkNumber,
-				"details":      err.Error(),
+	if rangeHeader == "" {
+		c.Header("Content-Length", fmt.Sprintf("%d", file.TotalSize))
+		c.Status(http.StatusOK)
+
+		if err := gw.streamChunksInOrder(ctx, file.Chunks, c.Writer); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to download file",
+				"details": err.Error(),
 			})
-			return
 		}
+		return
+	}
 
-		// Get circuit breaker for this server
-		cb := gw.getCircuitBreaker(chunk.StorageServerID)
+	ranges, err := ParseRange(rangeHeader, file.TotalSize)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", file.TotalSize))
+		c.AbortWithStatusJSON(http.StatusRequestedRangeNotSatisfiable, gin.H{
+			"error": "range not satisfiable",
+		})
+		return
+	}
+	// Only the first range is served; see ParseRange's doc comment on why a
+	// multipart/byteranges response for a multi-range request isn't
+	// implemented.
+	br := ranges[0]
+
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", br.Start, br.End, file.TotalSize))
+	c.Header("Content-Length", fmt.Sprintf("%d", br.Length()))
+	c.Status(http.StatusPartialContent)
 
-		// Download chunk from storage server with retry and circuit breaker
-		downloadErr := cb.Execute(func() error {
-			return gw.downloadChunkFromServerWithRetry(ctx, client, chunk.ChunkID.String(), c.Writer)
+	if err := gw.streamChunkRange(ctx, file.Chunks, br, c.Writer); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to download range",
+			"details": err.Error(),
 		})
+		return
+	}
+}
+
+// streamChunkRange writes only the bytes of chunks (sorted by ChunkNumber,
+// as GetFileByID returns them) that fall within br to w: chunks entirely
+// outside br are skipped without being fetched at all, and the first/last
+// intersecting chunk is fetched via a GetChunk sub-range request instead of
+// its whole body. Erasure-coded chunks can't be read as a sub-range off a
+// single storage server, so they're fetched and reconstructed whole and
+// trimmed to br in memory - the "read-and-discard" fallback.
+func (gw *APIGateway) streamChunkRange(ctx context.Context, chunks []*storage.Chunk, br ByteRange, w io.Writer) error {
+	var offset int64
+	for _, chunk := range chunks {
+		chunkStart := offset
+		chunkEnd := offset + chunk.ChunkSize - 1
+		offset += chunk.ChunkSize
+
+		if chunkEnd < br.Start || chunkStart > br.End {
+			continue // entirely outside the requested range
+		}
+
+		subStart := int64(0)
+		if br.Start > chunkStart {
+			subStart = br.Start - chunkStart
+		}
+		subEnd := chunk.ChunkSize - 1
+		if br.End < chunkEnd {
+			subEnd = br.End - chunkStart
+		}
+		subLength := subEnd - subStart + 1
+
+		if chunk.ErasureCoded {
+			data, err := gw.downloadChunkErasureCoded(ctx, chunk)
+			if err != nil {
+				return fmt.Errorf("failed to download erasure-coded chunk %d: %w", chunk.ChunkNumber, err)
+			}
+			if _, err := w.Write(data[subStart : subStart+subLength]); err != nil {
+				return fmt.Errorf("failed to write chunk %d: %w", chunk.ChunkNumber, err)
+			}
+			continue
+		}
 
+		client, err := gw.getStorageClient(chunk.StorageServerID)
+		if err != nil {
+			return fmt.Errorf("failed to get storage client for chunk %d: %w", chunk.ChunkNumber, err)
+		}
+		downloadErr := gw.withBandwidthLimit(ctx, chunk.StorageServerID, func() error {
+			return gw.downloadChunkRangeFromServerWithRetry(ctx, client, chunk.ChunkID.String(), subStart, subLength, w)
+		})
 		if downloadErr != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error":        "failed to download chunk",
-				"chunk_number": chunk.ChunkNumber,
-				"details":      downloadErr.Error(),
-			})
-			return
+			return fmt.Errorf("failed to download chunk %d range: %w", chunk.ChunkNumber, downloadErr)
 		}
 	}
+	return nil
+}
+
+// chunkCachePrefetchAhead is how many chunks following the one just served
+// are opportunistically warmed into the cache.
+const chunkCachePrefetchAhead = chunkcache.DefaultPrefetchAhead
+
+// streamChunksInOrder serves chunks from the local cache where possible,
+// fetches the rest through the priority-queued, hedged fetcher, and writes
+// them to w in chunk-number order as they arrive, buffering out-of-order
+// arrivals in a small reorder map. Each chunk served triggers read-ahead
+// prefetch of the chunks that follow it.
+func (gw *APIGateway) streamChunksInOrder(ctx context.Context, chunks []*storage.Chunk, w io.Writer) error {
+	pending := make(map[int][]byte, len(chunks))
+	var misses []*storage.Chunk
+	var ecMisses []*storage.Chunk
+
+	for _, chunk := range chunks {
+		if gw.ChunkCache != nil {
+			if data, ok := gw.ChunkCache.Get(chunk.ChunkID.String(), chunk.ChunkHash); ok {
+				pending[chunk.ChunkNumber] = data
+				continue
+			}
+		}
+		if chunk.ErasureCoded {
+			ecMisses = append(ecMisses, chunk)
+			continue
+		}
+		misses = append(misses, chunk)
+	}
+
+	for _, chunk := range ecMisses {
+		data, err := gw.downloadChunkErasureCoded(ctx, chunk)
+		if err != nil {
+			return fmt.Errorf("failed to download erasure-coded chunk %d: %w", chunk.ChunkNumber, err)
+		}
+		pending[chunk.ChunkNumber] = data
+		if gw.ChunkCache != nil {
+			gw.ChunkCache.Put(chunk.ChunkID.String(), chunk.ChunkHash, data)
+		}
+	}
+
+	requests := make([]fetcher.Request, len(misses))
+	for i, chunk := range misses {
+		// The chunks table currently records a single storage server per
+		// chunk, so there is only one replica to race today. Replicas is
+		// sized for >1 once chunk placement grows real replica sets.
+		requests[i] = fetcher.Request{
+			ChunkID:     chunk.ChunkID,
+			ChunkNumber: chunk.ChunkNumber,
+			Priority:    chunk.ChunkNumber,
+			Replicas:    []uuid.UUID{chunk.StorageServerID},
+		}
+	}
+
+	f := fetcher.NewFetcher(&gatewaySource{gw: gw}, fetcher.DefaultWorkers, fetcher.DefaultHedgeDelay, gw.RetryConfig)
+	results := f.FetchAll(ctx, requests)
+
+	chunkByNumber := make(map[int]*storage.Chunk, len(chunks))
+	for _, chunk := range chunks {
+		chunkByNumber[chunk.ChunkNumber] = chunk
+	}
+
+	for result := range results {
+		if result.Err != nil {
+			return fmt.Errorf("failed to download chunk %d: %w", result.ChunkNumber, result.Err)
+		}
+		pending[result.ChunkNumber] = result.Data
+		if gw.ChunkCache != nil {
+			if chunk := chunkByNumber[result.ChunkNumber]; chunk != nil {
+				gw.ChunkCache.Put(chunk.ChunkID.String(), chunk.ChunkHash, result.Data)
+			}
+		}
+	}
+
+	next := 0
+	for {
+		data, ok := pending[next]
+		if !ok {
+			break
+		}
+		if gw.ChunkCache != nil {
+			gw.prefetchFollowing(ctx, chunkByNumber, next, chunkCachePrefetchAhead)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", next, err)
+		}
+		delete(pending, next)
+		next++
+	}
+	if next != len(chunks) {
+		return fmt.Errorf("download incomplete: wrote %d of %d chunks", next, len(chunks))
+	}
+
+	return nil
+}
+
+// prefetchFollowing asynchronously warms the cache for the chunkCachePrefetchAhead
+// chunks following chunkNumber, so the next few reads in this download (or a
+// concurrent read of the same file) hit the cache instead of gRPC.
+func (gw *APIGateway) prefetchFollowing(ctx context.Context, chunkByNumber map[int]*storage.Chunk, chunkNumber, ahead int) {
+	source := &gatewaySource{gw: gw}
+	for n := chunkNumber + 1; n <= chunkNumber+ahead; n++ {
+		chunk, ok := chunkByNumber[n]
+		if !ok {
+			break
+		}
+		gw.ChunkCache.Prefetch(ctx, chunk.ChunkID.String(), chunk.ChunkHash, func(fetchCtx context.Context) ([]byte, error) {
+			return source.Fetch(fetchCtx, chunk.StorageServerID, chunk.ChunkID.String())
+		})
+	}
 }
 
 // downloadChunkFromServer downloads a chunk from a storage server via gRPC
 func (gw *APIGateway) downloadChunkFromServer(ctx context.Context, client pb.StorageServiceClient, chunkID string, writer io.Writer) error {
+	return gw.downloadChunkRangeFromServer(ctx, client, chunkID, 0, 0, writer)
+}
+
+// downloadChunkRangeFromServer downloads chunkID from a storage server via
+// gRPC, optionally restricted to length bytes starting at offset - offset
+// and length both 0 means the whole chunk, the same as
+// downloadChunkFromServer.
+func (gw *APIGateway) downloadChunkRangeFromServer(ctx context.Context, client pb.StorageServiceClient, chunkID string, offset, length int64, writer io.Writer) error {
 	stream, err := client.GetChunk(ctx, &pb.GetChunkRequest{
 		ChunkId: chunkID,
+		Offset:  offset,
+		Length:  length,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create download stream: %w", err)
@@ -120,7 +316,15 @@ func (gw *APIGateway) downloadChunkFromServer(ctx context.Context, client pb.Sto
 
 // downloadChunkFromServerWithRetry downloads a chunk with retry logic
 func (gw *APIGateway) downloadChunkFromServerWithRetry(ctx context.Context, client pb.StorageServiceClient, chunkID string, writer io.Writer) error {
-	return retry.Do(ctx, gw.RetryConfig, func() error {
-		return gw.downloadChunkFromServer(ctx, client, chunkID, writer)
+	return retry.Do(ctx, gw.RetryConfig, func(attemptCtx context.Context) error {
+		return gw.downloadChunkFromServer(attemptCtx, client, chunkID, writer)
+	})
+}
+
+// downloadChunkRangeFromServerWithRetry downloads a chunk's sub-range with
+// retry logic, as downloadChunkFromServerWithRetry does for whole chunks.
+func (gw *APIGateway) downloadChunkRangeFromServerWithRetry(ctx context.Context, client pb.StorageServiceClient, chunkID string, offset, length int64, writer io.Writer) error {
+	return retry.Do(ctx, gw.RetryConfig, func(attemptCtx context.Context) error {
+		return gw.downloadChunkRangeFromServer(attemptCtx, client, chunkID, offset, length, writer)
 	})
 }
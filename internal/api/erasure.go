@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/ec"
+	"github.com/s3storage/internal/storage"
+)
+
+// shardChunkID derives the storage-node-facing chunk ID for one shard of an
+// erasure-coded chunk. Shards are stored as ordinary chunks on their
+// assigned servers; only the gateway knows they belong together.
+func shardChunkID(chunkID uuid.UUID, shardIndex int) string {
+	return fmt.Sprintf("%s-shard-%d", chunkID, shardIndex)
+}
+
+// uploadChunkErasureCoded splits chunkData into gw.ECConfig's data+parity
+// shards, places each shard on a distinct server chosen by walking the hash
+// ring from chunkID, and uploads every shard in turn. It returns the chunk
+// record (StorageServerID pointing at the shard-0 server, ErasureCoded set)
+// and the per-shard placement records to persist alongside it.
+func (gw *APIGateway) uploadChunkErasureCoded(ctx context.Context, fileID uuid.UUID, chunkNumber int, chunkData []byte, chunkHash string) (*storage.Chunk, []*storage.ChunkShard, error) {
+	cfg := gw.ECConfig.ToECConfig()
+	chunkID := uuid.New()
+
+	shards, err := ec.Encode(cfg, chunkData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to erasure-code chunk: %w", err)
+	}
+
+	serverIDs, err := gw.HashRing.GetServers(chunkID.String(), cfg.TotalShards())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to place erasure-coded shards: %w", err)
+	}
+	if len(serverIDs) < cfg.TotalShards() {
+		return nil, nil, fmt.Errorf("not enough storage servers for erasure coding: need %d, have %d", cfg.TotalShards(), len(serverIDs))
+	}
+
+	shardRecords := make([]*storage.ChunkShard, 0, cfg.TotalShards())
+	var primaryServerUUID uuid.UUID
+
+	for i, shard := range shards {
+		serverUUID, err := uuid.Parse(serverIDs[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid server ID for shard %d: %w", i, err)
+		}
+		if i == 0 {
+			primaryServerUUID = serverUUID
+		}
+
+		client, err := gw.getStorageClient(serverUUID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get storage client for shard %d: %w", i, err)
+		}
+
+		cb := gw.getCircuitBreaker(serverUUID)
+		uploadErr := cb.Execute(func() error {
+			return gw.UploadChunkToServerWithRetry(ctx, client, shardChunkID(chunkID, i), shard, "")
+		})
+		if uploadErr != nil {
+			return nil, nil, fmt.Errorf("failed to upload shard %d to server %s: %w", i, serverIDs[i], uploadErr)
+		}
+
+		shardRecords = append(shardRecords, &storage.ChunkShard{
+			ChunkID:    chunkID,
+			ShardIndex: i,
+			ServerID:   serverUUID,
+			IsParity:   i >= cfg.DataShards,
+		})
+	}
+
+	chunk := &storage.Chunk{
+		ChunkID:         chunkID,
+		FileID:          fileID,
+		ChunkNumber:     chunkNumber,
+		StorageServerID: primaryServerUUID,
+		ChunkSize:       int64(len(chunkData)),
+		ChunkHash:       chunkHash,
+		Status:          "completed",
+		ErasureCoded:    true,
+	}
+
+	return chunk, shardRecords, nil
+}
+
+// pickReplacementServer chooses a server for a regenerated shard by walking
+// the hash ring from chunkID, skipping any server ID already present in
+// exclude (the servers already holding one of this chunk's other shards).
+func (gw *APIGateway) pickReplacementServer(chunkID uuid.UUID, exclude []string) (uuid.UUID, error) {
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
+	candidates, err := gw.HashRing.GetServers(chunkID.String(), len(exclude)+1)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	for _, candidate := range candidates {
+		if !excluded[candidate] {
+			return uuid.Parse(candidate)
+		}
+	}
+
+	return uuid.Nil, fmt.Errorf("no replacement server available for chunk %s", chunkID)
+}
+
+// downloadChunkErasureCoded reconstructs an erasure-coded chunk by racing
+// GetChunk calls for all of its k+m shards and rebuilding the original data
+// as soon as at least k of them succeed.
+func (gw *APIGateway) downloadChunkErasureCoded(ctx context.Context, chunk *storage.Chunk) ([]byte, error) {
+	cfg := gw.ECConfig.ToECConfig()
+
+	shardRecords, err := gw.Storage.GetChunkShardsByChunkID(ctx, chunk.ChunkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shard placement for chunk %s: %w", chunk.ChunkID, err)
+	}
+	if len(shardRecords) == 0 {
+		return nil, fmt.Errorf("no shard placement recorded for erasure-coded chunk %s", chunk.ChunkID)
+	}
+
+	source := &gatewaySource{gw: gw}
+
+	type shardResult struct {
+		index int
+		data  []byte
+		err   error
+	}
+
+	results := make(chan shardResult, len(shardRecords))
+	for _, sr := range shardRecords {
+		go func(sr *storage.ChunkShard) {
+			data, err := source.Fetch(ctx, sr.ServerID, shardChunkID(chunk.ChunkID, sr.ShardIndex))
+			results <- shardResult{index: sr.ShardIndex, data: data, err: err}
+		}(sr)
+	}
+
+	shards := make([][]byte, cfg.TotalShards())
+	present := 0
+	for i := 0; i < len(shardRecords) && present < cfg.DataShards; i++ {
+		r := <-results
+		if r.err != nil {
+			continue
+		}
+		shards[r.index] = r.data
+		present++
+	}
+
+	if present < cfg.DataShards {
+		return nil, fmt.Errorf("failed to reconstruct chunk %s: only %d of %d required shards available", chunk.ChunkID, present, cfg.DataShards)
+	}
+
+	return ec.Reconstruct(cfg, shards, int(chunk.ChunkSize))
+}
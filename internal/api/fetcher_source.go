@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/circuitbreaker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// gatewaySource adapts APIGateway's storage-client/circuit-breaker plumbing
+// to the fetcher.Source interface so internal/fetcher doesn't need to know
+// about gRPC or circuit breakers directly.
+type gatewaySource struct {
+	gw *APIGateway
+}
+
+// Available reports whether serverID's circuit breaker currently allows
+// traffic, so the fetcher can skip tripped servers without attempting (and
+// paying for) a doomed RPC.
+func (g *gatewaySource) Available(serverID uuid.UUID) bool {
+	return g.gw.getCircuitBreaker(serverID).GetState() != circuitbreaker.StateOpen
+}
+
+// Fetch downloads a single chunk from serverID, recording the outcome on
+// that server's circuit breaker.
+func (g *gatewaySource) Fetch(ctx context.Context, serverID uuid.UUID, chunkID string) ([]byte, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "fetchChunk", trace.WithAttributes(
+		attribute.String("chunk_id", chunkID),
+		attribute.String("replica_server_id", serverID.String()),
+	))
+	defer span.End()
+
+	client, err := g.gw.getStorageClient(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := g.gw.getCircuitBreaker(serverID)
+
+	var buf bytes.Buffer
+	err = cb.Execute(func() error {
+		buf.Reset()
+		return g.gw.downloadChunkFromServer(ctx, client, chunkID, &buf)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -3,19 +3,33 @@ package api
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/backoff"
+	"github.com/s3storage/internal/bulkdelete"
+	"github.com/s3storage/internal/bwmeter"
+	"github.com/s3storage/internal/chunkcache"
 	"github.com/s3storage/internal/circuitbreaker"
 	"github.com/s3storage/internal/cleanup"
+	"github.com/s3storage/internal/cluster"
+	"github.com/s3storage/internal/coordinator"
+	"github.com/s3storage/internal/decommission"
+	"github.com/s3storage/internal/ec"
 	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/metacache"
 	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/spool"
 	"github.com/s3storage/internal/storage"
+	"github.com/s3storage/internal/telemetry"
+	"github.com/s3storage/internal/usagecache"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
@@ -35,21 +49,98 @@ const (
 var (
 	// ErrStorageServerNotFound is returned when a storage server is not found
 	ErrStorageServerNotFound = errors.New("storage server not found")
+	// ErrBackoffNotReady is returned when a reconnect is skipped because
+	// the server's backoff.Backoff hasn't reached its next attempt time yet.
+	ErrBackoffNotReady = errors.New("storage server reconnect backoff not ready")
 )
 
+// ECConfig controls whether new uploads are erasure-coded (split into
+// DataShards data shards + ParityShards parity shards placed on distinct
+// servers) instead of stored on a single replica. Replication remains the
+// default behavior when Enabled is false.
+type ECConfig struct {
+	Enabled      bool
+	DataShards   int
+	ParityShards int
+}
+
+// ToECConfig converts the gateway config into the ec package's Config.
+func (c ECConfig) ToECConfig() ec.Config {
+	return ec.Config{DataShards: c.DataShards, ParityShards: c.ParityShards}
+}
+
 // APIGateway represents the API Gateway server
 type APIGateway struct {
-	Router          *gin.Engine
-	DB              *pgxpool.Pool
-	Storage         *storage.PostgresStorage
-	StorageClients  map[uuid.UUID]*grpc.ClientConn
-	CircuitBreakers map[uuid.UUID]*circuitbreaker.CircuitBreaker
-	HashRing        *hasher.HashRing
-	CleanupJob      *cleanup.CleanupJob
-	RetryConfig     *retry.RetryConfig
-	clientsMu       sync.RWMutex
-	stopRefresh     chan struct{}
-	stopHealthCheck chan struct{}
+	Router *gin.Engine
+	DB     *pgxpool.Pool
+	// Logger is the structured logger background loops below (hash ring
+	// refresh, connection health checks, shard repair) log through.
+	// Per-request handlers should prefer logctx.FromContext(ctx) instead,
+	// which carries this same logger enriched with a request_id (and,
+	// where a handler sets it, a file_id) by RequestLoggerMiddleware.
+	Logger              logr.Logger
+	Storage             *storage.PostgresStorage
+	StorageClients      map[uuid.UUID]*grpc.ClientConn
+	CircuitBreakers     map[uuid.UUID]*circuitbreaker.CircuitBreaker
+	// StorageBackoffs holds each storage server's reconnect backoff state
+	// (see internal/backoff), kept alongside CircuitBreakers and guarded by
+	// the same clientsMu.
+	StorageBackoffs map[uuid.UUID]*backoff.Backoff
+	// BackoffConfig controls new entries in StorageBackoffs. nil uses
+	// backoff.DefaultConfig.
+	BackoffConfig       *backoff.Config
+	// HashRing is the in-memory placement backend used to assign chunks to
+	// storage servers. Its type is the hasher.PlacementRing interface so
+	// either hasher.HashRing (virtual-node consistent hashing, the
+	// default) or hasher.RendezvousRing (HRW hashing) can be selected via
+	// cmd/api-gateway's PLACEMENT_STRATEGY env var. Bounded-load placement
+	// is consistent-hashing-specific and not part of this interface; see
+	// uploadOneChunk's type assertion.
+	HashRing hasher.PlacementRing
+	CleanupJob          *cleanup.CleanupJob
+	VersionCompactor    *cleanup.VersionCompactor
+	DecommissionManager *decommission.Manager
+	BulkDeleteManager   *bulkdelete.Manager
+	RetryConfig         *retry.RetryConfig
+	ChunkCache          *chunkcache.Cache
+	UsageCache          *usagecache.Cache
+	// MetaCache is an optional Redis-backed read cache in front of
+	// Storage's GetFileByID/GetChunksByFileID lookups; see package
+	// metacache. It is always non-nil (constructed as a no-op passthrough
+	// when no Redis URL is configured), so callers never need a nil check.
+	MetaCache *metacache.Cache
+	ECConfig  ECConfig
+	// Spool is where uploadOneChunk parks a chunk it couldn't deliver to
+	// its hash-ring-assigned server synchronously, for SpoolReplayer to
+	// retry in the background. nil disables spooling: a chunk upload that
+	// exhausts its attempts fails the request, as before.
+	Spool         *spool.Spool
+	SpoolReplayer *spool.Replayer
+	// Coordinator elects a single leader among an HA deployment's gateway
+	// instances to run singleton background jobs (CleanupJob, the hash
+	// ring refresh loop) - see internal/coordinator. nil preserves the
+	// single-node behavior of every gateway running everything.
+	Coordinator coordinator.Coordinator
+	// BWMeter tracks per-storage-server bandwidth and adaptively limits
+	// gRPC chunk-transfer concurrency - see internal/bwmeter. nil disables
+	// both the stats collection and the concurrency gate, leaving uploads
+	// and downloads bounded only by UploadConcurrency and the caller's own
+	// parallelism, as before.
+	BWMeter *bwmeter.Meter
+	// UploadConcurrency bounds how many chunks UploadFile uploads in
+	// parallel to storage servers. 0 uses DefaultUploadConcurrency.
+	UploadConcurrency int
+	// UploadQueueDepth bounds the buffered channel between UploadFile's
+	// reader goroutine and its upload worker pool, capping memory at
+	// roughly UploadQueueDepth*chunkSize. 0 defaults to UploadConcurrency.
+	UploadQueueDepth int
+	clientsMu                sync.RWMutex
+	stopRefresh              chan struct{}
+	stopHealthCheck          chan struct{}
+	stopShardRepair          chan struct{}
+	hashRingRefreshWg        sync.WaitGroup
+	stopCleanupJobWatch      func()
+	stopHashRingRefreshWatch func()
 }
 
 // getStorageClient returns a gRPC client for a storage server
@@ -60,14 +151,19 @@ func (gw *APIGateway) getStorageClient(serverID uuid.UUID) (pb.StorageServiceCli
 	gw.clientsMu.RUnlock()
 
 	if !exists {
-		log.Printf("Storage client not found for server %s", serverID)
+		gw.Logger.Info("storage client not found", "server_id", serverID)
 		return nil, ErrStorageServerNotFound
 	}
 
 	// Check connection state and reconnect if needed
 	state := conn.GetState()
 	if state == connectivity.TransientFailure || state == connectivity.Shutdown {
-		log.Printf("Connection to server %s is in state %v, attempting reconnect", serverID, state)
+		bo := gw.getBackoff(serverID)
+		if !bo.Ready() {
+			return nil, ErrBackoffNotReady
+		}
+
+		gw.Logger.Info("connection unhealthy, attempting reconnect", "server_id", serverID, "state", state)
 
 		// Get server address from hash ring
 		servers := gw.HashRing.GetAllServers()
@@ -80,17 +176,18 @@ func (gw *APIGateway) getStorageClient(serverID uuid.UUID) (pb.StorageServiceCli
 		}
 
 		if serverAddr == "" {
-			log.Printf("Server %s not found in hash ring (total servers: %d)", serverID, len(servers))
+			gw.Logger.Info("server not found in hash ring", "server_id", serverID, "known_servers", len(servers))
 			return nil, ErrStorageServerNotFound
 		}
 
 		// Attempt reconnection
 		if err := gw.reconnectToStorageServer(serverID, serverAddr); err != nil {
-			log.Printf("Failed to reconnect to server %s at %s: %v", serverID, serverAddr, err)
+			delay := bo.Failure()
+			gw.Logger.Error(err, "failed to reconnect to server", "server_id", serverID, "address", serverAddr, "next_attempt_in", delay)
 			return nil, err
 		}
 
-		log.Printf("Successfully reconnected to server %s", serverID)
+		gw.Logger.Info("successfully reconnected to server", "server_id", serverID)
 
 		// Get new connection
 		gw.clientsMu.RLock()
@@ -116,52 +213,279 @@ func (gw *APIGateway) getCircuitBreaker(serverID uuid.UUID) *circuitbreaker.Circ
 	return cb
 }
 
-// StartHashRingRefreshLoop starts the background loop that refreshes the hash ring
-func (gw *APIGateway) StartHashRingRefreshLoop(ctx context.Context) {
-	gw.stopRefresh = make(chan struct{})
-	ticker := time.NewTicker(HashRingRefreshInterval)
+// getBackoff returns the reconnect backoff state for a storage server,
+// creating one using gw.BackoffConfig on first use.
+func (gw *APIGateway) getBackoff(serverID uuid.UUID) *backoff.Backoff {
+	gw.clientsMu.Lock()
+	defer gw.clientsMu.Unlock()
+
+	if bo, exists := gw.StorageBackoffs[serverID]; exists {
+		return bo
+	}
+
+	if gw.StorageBackoffs == nil {
+		gw.StorageBackoffs = make(map[uuid.UUID]*backoff.Backoff)
+	}
+	bo := backoff.New(gw.BackoffConfig)
+	gw.StorageBackoffs[serverID] = bo
+	return bo
+}
+
+// UpdateTelemetryGauges refreshes the hash-ring-size and per-server
+// circuit-breaker-state gauges exported on /metrics. It's cheap enough to
+// call on a short ticker (see cmd/api-gateway's telemetry gauge loop).
+func (gw *APIGateway) UpdateTelemetryGauges() {
+	telemetry.HashRingSize.Set(float64(len(gw.HashRing.GetAllServers())))
+
+	gw.clientsMu.RLock()
+	for serverID, cb := range gw.CircuitBreakers {
+		telemetry.CircuitBreakerState.WithLabelValues(serverID.String()).Set(float64(cb.GetState()))
+	}
+	gw.clientsMu.RUnlock()
+
+	if gw.BWMeter == nil {
+		return
+	}
+	gw.BWMeter.Sample()
+	for _, serverID := range gw.BWMeter.ServerIDs() {
+		snap := gw.BWMeter.Snapshot(serverID)
+		telemetry.BandwidthBytesIn.WithLabelValues(serverID).Set(float64(snap.BytesIn))
+		telemetry.BandwidthBytesOut.WithLabelValues(serverID).Set(float64(snap.BytesOut))
+		telemetry.BandwidthThroughputIn.WithLabelValues(serverID).Set(snap.ThroughputInBytesPerSec)
+		telemetry.BandwidthThroughputOut.WithLabelValues(serverID).Set(snap.ThroughputOutBytesPerSec)
+		telemetry.BandwidthInFlightStreams.WithLabelValues(serverID).Set(float64(snap.InFlightStreams))
+		telemetry.BandwidthConcurrencyLimit.WithLabelValues(serverID).Set(snap.ConcurrencyLimit)
+		telemetry.BandwidthConcurrencyInFlight.WithLabelValues(serverID).Set(float64(snap.ConcurrencyInFlight))
+	}
+}
+
+// withBandwidthLimit runs fn after acquiring a slot on serverID's adaptive
+// concurrency limiter (see internal/bwmeter), releasing it afterward with
+// an Outcome derived from fn's result: Overloaded if fn returned
+// circuitbreaker.ErrCircuitOpen (the server is already known to be
+// struggling), Success otherwise. If gw.BWMeter is nil, fn runs unguarded.
+func (gw *APIGateway) withBandwidthLimit(ctx context.Context, serverID uuid.UUID, fn func() error) error {
+	if gw.BWMeter == nil {
+		return fn()
+	}
+
+	limiter := gw.BWMeter.Limiter(serverID.String())
+	if err := limiter.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire bandwidth slot for storage server %s: %w", serverID, err)
+	}
+
+	err := fn()
+
+	outcome := bwmeter.Success
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		outcome = bwmeter.Overloaded
+	}
+	limiter.Release(outcome)
+
+	return err
+}
+
+// startWhenLeader ties start/stop to gw.Coordinator's leadership signal. If
+// gw.Coordinator is nil, start runs immediately and unconditionally,
+// preserving single-node "every gateway runs everything" behavior.
+// Otherwise start/stop run as leadership is gained/lost, so exactly one
+// gateway in the deployment has the job running at a time. The returned
+// func stops the job (if running) and blocks until it has; it does not
+// release the lease, which is Coordinator.Stop's job.
+func (gw *APIGateway) startWhenLeader(ctx context.Context, name string, start func(context.Context), stop func()) func() {
+	if gw.Coordinator == nil {
+		start(ctx)
+		return stop
+	}
+
+	leadershipChanged := gw.Coordinator.LeadershipChanged()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
 
 	go func() {
-		defer ticker.Stop()
-		log.Printf("Hash ring refresh loop started (interval: %v)", HashRingRefreshInterval)
+		defer close(stopped)
+
+		running := false
+		if gw.Coordinator.IsLeader() {
+			start(ctx)
+			running = true
+		}
 
 		for {
 			select {
-			case <-ticker.C:
-				if err := gw.RefreshHashRing(ctx); err != nil {
-					log.Printf("Error refreshing hash ring: %v", err)
+			case isLeader, ok := <-leadershipChanged:
+				if !ok {
+					if running {
+						stop()
+					}
+					return
+				}
+				if isLeader && !running {
+					gw.Logger.Info("acquired leadership, starting background job", "job", name)
+					start(ctx)
+					running = true
+				} else if !isLeader && running {
+					gw.Logger.Info("lost leadership, stopping background job", "job", name)
+					stop()
+					running = false
+				}
+			case <-done:
+				if running {
+					stop()
 				}
-			case <-gw.stopRefresh:
-				log.Println("Hash ring refresh loop stopped")
 				return
 			case <-ctx.Done():
-				log.Println("Hash ring refresh loop stopped due to context cancellation")
+				if running {
+					stop()
+				}
 				return
 			}
 		}
 	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// StartHashRingRefreshLoop starts the background loop that refreshes the
+// hash ring, gated to the leader if gw.Coordinator is set (see
+// startWhenLeader).
+func (gw *APIGateway) StartHashRingRefreshLoop(ctx context.Context) {
+	gw.stopHashRingRefreshWatch = gw.startWhenLeader(ctx, "hash ring refresh loop", func(ctx context.Context) {
+		gw.stopRefresh = make(chan struct{})
+		ticker := time.NewTicker(HashRingRefreshInterval)
+
+		gw.hashRingRefreshWg.Add(1)
+		go func() {
+			defer gw.hashRingRefreshWg.Done()
+			defer ticker.Stop()
+			gw.Logger.Info("hash ring refresh loop started", "interval", HashRingRefreshInterval.String())
+
+			for {
+				select {
+				case <-ticker.C:
+					if err := gw.RefreshHashRing(ctx); err != nil {
+						gw.Logger.Error(err, "error refreshing hash ring")
+					}
+				case <-gw.stopRefresh:
+					gw.Logger.Info("hash ring refresh loop stopped")
+					return
+				case <-ctx.Done():
+					gw.Logger.Info("hash ring refresh loop stopped due to context cancellation")
+					return
+				}
+			}
+		}()
+	}, func() {
+		close(gw.stopRefresh)
+		gw.hashRingRefreshWg.Wait()
+	})
 }
 
 // StopHashRingRefreshLoop stops the hash ring refresh loop
 func (gw *APIGateway) StopHashRingRefreshLoop() {
-	if gw.stopRefresh != nil {
-		close(gw.stopRefresh)
+	if gw.stopHashRingRefreshWatch != nil {
+		gw.stopHashRingRefreshWatch()
 	}
 }
 
-// StartCleanupJob starts the cleanup job for expired sessions
+// StartCleanupJob starts the cleanup job for expired sessions, gated to the
+// leader if gw.Coordinator is set (see startWhenLeader).
 func (gw *APIGateway) StartCleanupJob(ctx context.Context) {
-	gw.CleanupJob = cleanup.NewCleanupJob(gw.Storage, gw.StorageClients, &gw.clientsMu)
-	gw.CleanupJob.Start(ctx)
+	gw.stopCleanupJobWatch = gw.startWhenLeader(ctx, "cleanup job", func(ctx context.Context) {
+		gw.CleanupJob = cleanup.NewCleanupJob(gw.MetaCache, gw.StorageClients, &gw.clientsMu, gw.Logger)
+		gw.CleanupJob.Start(ctx)
+	}, func() {
+		gw.CleanupJob.Stop()
+	})
 }
 
 // StopCleanupJob stops the cleanup job
 func (gw *APIGateway) StopCleanupJob() {
-	if gw.CleanupJob != nil {
-		gw.CleanupJob.Stop()
+	if gw.stopCleanupJobWatch != nil {
+		gw.stopCleanupJobWatch()
 	}
 }
 
+// StartSpoolReplayer starts the background loop that retries delivering
+// chunks uploadOneChunk spooled instead of failing the upload outright.
+// Requires gw.Spool to already be set (see cmd/api-gateway/main.go); it's a
+// no-op if spooling isn't configured.
+func (gw *APIGateway) StartSpoolReplayer(ctx context.Context) {
+	if gw.Spool == nil {
+		return
+	}
+	gw.SpoolReplayer = spool.NewReplayer(gw.Spool, gw.MetaCache, gw.HashRing, gw.StorageClients, &gw.clientsMu, gw.Logger)
+	gw.SpoolReplayer.Start(ctx)
+}
+
+// StopSpoolReplayer stops the spool replayer
+func (gw *APIGateway) StopSpoolReplayer() {
+	if gw.SpoolReplayer != nil {
+		gw.SpoolReplayer.Stop()
+	}
+}
+
+// StartVersionCompactor starts the background loop that retries DeleteChunk
+// RPCs for chunks orphaned by a permanent object-version deletion.
+func (gw *APIGateway) StartVersionCompactor(ctx context.Context) {
+	gw.VersionCompactor = cleanup.NewVersionCompactor(gw.Storage, gw.StorageClients, &gw.clientsMu, gw.Logger)
+	gw.VersionCompactor.Start(ctx)
+}
+
+// StopVersionCompactor stops the version compactor
+func (gw *APIGateway) StopVersionCompactor() {
+	if gw.VersionCompactor != nil {
+		gw.VersionCompactor.Stop()
+	}
+}
+
+// StartDecommissionManager wires up the Manager that drives
+// graceful storage-server draining, backed by ring for picking each moved
+// chunk's new home. ring is owned by main() rather than the gateway (like
+// the session reaper's placement ring), so it's passed in here instead of
+// being constructed internally.
+func (gw *APIGateway) StartDecommissionManager(ring *storage.HashRing) {
+	gw.DecommissionManager = decommission.NewManager(gw.MetaCache, ring, gw.StorageClients, &gw.clientsMu, gw.HashRing)
+}
+
+// StopDecommissionManager stops every in-flight decommission job's worker
+// without changing its database status, so it picks back up from its last
+// cursor next time it's started or resumed.
+func (gw *APIGateway) StopDecommissionManager() {
+	if gw.DecommissionManager != nil {
+		gw.DecommissionManager.StopAll()
+	}
+}
+
+// StartBulkDeleteManager wires up the Manager that drives async bulk file
+// deletion, then re-launches a worker for every job left "running" from
+// before a restart.
+func (gw *APIGateway) StartBulkDeleteManager(ctx context.Context) {
+	gw.BulkDeleteManager = bulkdelete.NewManager(gw.Storage, gw.StorageClients, &gw.clientsMu, gw.HashRing)
+	if err := gw.BulkDeleteManager.ResumeAll(ctx); err != nil {
+		gw.Logger.Error(err, "failed to resume in-flight bulk delete jobs")
+	}
+}
+
+// StopBulkDeleteManager stops every in-flight bulk delete job's worker
+// without changing its database status, so it picks back up from its last
+// cursor next time the gateway starts.
+func (gw *APIGateway) StopBulkDeleteManager() {
+	if gw.BulkDeleteManager != nil {
+		gw.BulkDeleteManager.StopAll()
+	}
+}
+
+// StartUsageCache wires up the cache backing GET /admin/usage and the
+// usage_* gauges on /metrics, so neither hits Postgres more than once per
+// ttl no matter how many callers ask.
+func (gw *APIGateway) StartUsageCache(ttl time.Duration, topN int) {
+	gw.UsageCache = usagecache.New(gw.Storage, ttl, topN)
+}
+
 // StartConnectionHealthCheck starts periodic health checks for storage server connections
 func (gw *APIGateway) StartConnectionHealthCheck(ctx context.Context) {
 	gw.stopHealthCheck = make(chan struct{})
@@ -169,17 +493,17 @@ func (gw *APIGateway) StartConnectionHealthCheck(ctx context.Context) {
 
 	go func() {
 		defer ticker.Stop()
-		log.Printf("Connection health check loop started (interval: %v)", ConnectionHealthCheckInterval)
+		gw.Logger.Info("connection health check loop started", "interval", ConnectionHealthCheckInterval.String())
 
 		for {
 			select {
 			case <-ticker.C:
 				gw.checkAndReconnectStorageServers(ctx)
 			case <-gw.stopHealthCheck:
-				log.Println("Connection health check loop stopped")
+				gw.Logger.Info("connection health check loop stopped")
 				return
 			case <-ctx.Done():
-				log.Println("Connection health check loop stopped due to context cancellation")
+				gw.Logger.Info("connection health check loop stopped due to context cancellation")
 				return
 			}
 		}
@@ -215,7 +539,13 @@ func (gw *APIGateway) checkAndReconnectStorageServers(ctx context.Context) {
 
 		// Reconnect if connection is in bad state
 		if state == connectivity.TransientFailure || state == connectivity.Shutdown || state == connectivity.Idle {
-			log.Printf("Connection to server %s is in state %v, attempting reconnect", serverID, state)
+			bo := gw.getBackoff(serverID)
+			if !bo.Ready() {
+				gw.Logger.Info("skipping reconnect, backoff not ready", "server_id", serverID, "next_attempt", bo.NextAttempt())
+				continue
+			}
+
+			gw.Logger.Info("connection unhealthy, attempting reconnect", "server_id", serverID, "state", state)
 
 			// Get server address from hash ring
 			servers := gw.HashRing.GetAllServers()
@@ -228,20 +558,21 @@ func (gw *APIGateway) checkAndReconnectStorageServers(ctx context.Context) {
 			}
 
 			if serverAddr == "" {
-				log.Printf("Server %s not found in hash ring, skipping reconnect", serverID)
+				gw.Logger.Info("server not found in hash ring, skipping reconnect", "server_id", serverID)
 				continue
 			}
 
 			// Attempt reconnection
 			if err := gw.reconnectToStorageServer(serverID, serverAddr); err != nil {
-				log.Printf("Failed to reconnect to server %s: %v", serverID, err)
+				delay := bo.Failure()
+				gw.Logger.Error(err, "failed to reconnect to server", "server_id", serverID, "next_attempt_in", delay)
 
 				// Reset circuit breaker on connection failure
 				if cb := gw.getCircuitBreaker(serverID); cb != nil {
 					cb.Reset()
 				}
 			} else {
-				log.Printf("Successfully reconnected to server %s", serverID)
+				gw.Logger.Info("successfully reconnected to server", "server_id", serverID)
 			}
 		}
 	}
@@ -269,12 +600,14 @@ func (gw *APIGateway) RefreshHashRing(ctx context.Context) error {
 	}
 
 	// Remove inactive servers from hash ring
+	serverRemoved := false
 	for _, server := range currentServers {
 		if _, exists := activeServers[server.ID]; !exists {
-			log.Printf("Removing inactive server from hash ring: %s", server.ID)
+			gw.Logger.Info("removing inactive server from hash ring", "server_id", server.ID)
 			if err := gw.HashRing.RemoveServer(server.ID); err != nil {
-				log.Printf("Error removing server %s from hash ring: %v", server.ID, err)
+				gw.Logger.Error(err, "error removing server from hash ring", "server_id", server.ID)
 			}
+			serverRemoved = true
 
 			// Close gRPC connection
 			serverUUID, _ := uuid.Parse(server.ID)
@@ -282,18 +615,32 @@ func (gw *APIGateway) RefreshHashRing(ctx context.Context) error {
 		}
 	}
 
+	// A removed server may have been holding erasure-coded shards; don't
+	// wait for the next ShardRepairInterval tick to notice, since every
+	// shard fetch from it will otherwise fail (and downloads degrade) until
+	// then.
+	if serverRemoved && gw.ECConfig.Enabled {
+		go func() {
+			repairCtx, cancel := context.WithTimeout(context.Background(), ShardRepairInterval)
+			defer cancel()
+			if err := gw.repairErasureCodedChunks(repairCtx); err != nil {
+				gw.Logger.Error(err, "error repairing erasure-coded shards after server removal")
+			}
+		}()
+	}
+
 	// Add new servers to hash ring
 	for serverID, server := range activeServers {
 		if !currentServerMap[serverID] {
-			log.Printf("Adding new server to hash ring: %s at %s", serverID, server.GRPCAddress)
+			gw.Logger.Info("adding new server to hash ring", "server_id", serverID, "address", server.GRPCAddress)
 			if err := gw.HashRing.AddServer(serverID, server.GRPCAddress); err != nil {
-				log.Printf("Error adding server %s to hash ring: %v", serverID, err)
+				gw.Logger.Error(err, "error adding server to hash ring", "server_id", serverID)
 				continue
 			}
 
 			// Create gRPC connection
 			if err := gw.connectToStorageServer(server.ServerID, server.GRPCAddress); err != nil {
-				log.Printf("Warning: failed to connect to storage server %s: %v", serverID, err)
+				gw.Logger.Error(err, "failed to connect to storage server", "server_id", serverID)
 			} else {
 				// Create circuit breaker for new server
 				gw.getCircuitBreaker(server.ServerID)
@@ -301,21 +648,90 @@ func (gw *APIGateway) RefreshHashRing(ctx context.Context) error {
 		}
 	}
 
-	log.Printf("Hash ring refreshed: %d active servers", len(activeServers))
+	gw.Logger.Info("hash ring refreshed", "active_servers", len(activeServers))
 	return nil
 }
 
+// HandleMemberJoin adds a gossiped cluster.Member to the hash ring and opens
+// a connection to it, the same work RefreshHashRing does for a server it
+// discovers via DB polling - except this fires as soon as the gossip event
+// arrives, not on the next poll tick. Intended as the cluster.Config.OnJoin
+// callback (see cmd/api-gateway/main.go).
+func (gw *APIGateway) HandleMemberJoin(m cluster.Member) {
+	if err := gw.HashRing.AddServer(m.ServerID.String(), m.GRPCAddress); err != nil {
+		gw.Logger.Error(err, "error adding gossiped member to hash ring", "server_id", m.ServerID)
+		return
+	}
+
+	if err := gw.connectToStorageServer(m.ServerID, m.GRPCAddress); err != nil {
+		gw.Logger.Error(err, "failed to connect to gossiped storage server", "server_id", m.ServerID)
+		return
+	}
+	gw.getCircuitBreaker(m.ServerID)
+	gw.Logger.Info("added gossiped member to hash ring", "server_id", m.ServerID, "address", m.GRPCAddress)
+}
+
+// HandleMemberLeave removes a gossiped cluster.Member from the hash ring and
+// closes its connection. Intended as the cluster.Config.OnLeave callback;
+// cluster.Cluster only calls OnLeave after cfg.HealthProbe (if set) also
+// failed to reach the member directly, so this isn't triggered by a
+// transient gossip flap alone.
+func (gw *APIGateway) HandleMemberLeave(m cluster.Member) {
+	if err := gw.HashRing.RemoveServer(m.ServerID.String()); err != nil {
+		gw.Logger.Error(err, "error removing gossiped member from hash ring", "server_id", m.ServerID)
+	}
+	gw.closeStorageClient(m.ServerID)
+	gw.Logger.Info("removed gossiped member from hash ring", "server_id", m.ServerID)
+
+	if gw.ECConfig.Enabled {
+		go func() {
+			repairCtx, cancel := context.WithTimeout(context.Background(), ShardRepairInterval)
+			defer cancel()
+			if err := gw.repairErasureCodedChunks(repairCtx); err != nil {
+				gw.Logger.Error(err, "error repairing erasure-coded shards after gossiped member leave")
+			}
+		}()
+	}
+}
+
+// HandleMemberUpdate logs a gossiped capacity update and, when the update
+// carries Draining, pulls the member out of the hash ring immediately rather
+// than waiting for NotifyLeave - a draining server is still connected and
+// answering health checks, so memberlist has no reason to ever report it as
+// left. The gRPC connection is left open: reads for chunks it already holds
+// still need to succeed during its grace period, only new placements stop.
+// The hash ring itself doesn't weight placement by available/used bytes
+// today, so a non-draining capacity update has nothing further to apply.
+func (gw *APIGateway) HandleMemberUpdate(m cluster.Member) {
+	gw.Logger.Info("gossiped member capacity updated", "server_id", m.ServerID, "available_bytes", m.AvailableBytes, "used_bytes", m.UsedBytes, "draining", m.Draining)
+
+	if !m.Draining {
+		return
+	}
+	if err := gw.HashRing.RemoveServer(m.ServerID.String()); err != nil && err != hasher.ErrServerNotFound {
+		gw.Logger.Error(err, "error removing draining member from hash ring", "server_id", m.ServerID)
+		return
+	}
+	gw.Logger.Info("removed draining member from hash ring", "server_id", m.ServerID)
+}
+
 // connectToStorageServer creates a gRPC connection to a storage server
 // Connection is non-blocking - it will connect in background
 func (gw *APIGateway) connectToStorageServer(serverID uuid.UUID, address string) error {
-	// Create connection without blocking
-	conn, err := grpc.Dial(address,
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(1024*1024*1024), // 1GB
 			grpc.MaxCallSendMsgSize(1024*1024*1024), // 1GB
 		),
-	)
+	}
+	if gw.BWMeter != nil {
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(gw.BWMeter.StatsHandler(serverID.String())))
+	}
+
+	// Create connection without blocking
+	conn, err := grpc.Dial(address, dialOpts...)
 	if err != nil {
 		return err
 	}
@@ -324,7 +740,7 @@ func (gw *APIGateway) connectToStorageServer(serverID uuid.UUID, address string)
 	gw.StorageClients[serverID] = conn
 	gw.clientsMu.Unlock()
 
-	log.Printf("Initiated connection to storage server: %s at %s", serverID, address)
+	gw.Logger.Info("initiated connection to storage server", "server_id", serverID, "address", address)
 
 	// Check connection state in background
 	go func() {
@@ -335,9 +751,10 @@ func (gw *APIGateway) connectToStorageServer(serverID uuid.UUID, address string)
 		if conn.WaitForStateChange(ctx, connectivity.Idle) {
 			state := conn.GetState()
 			if state == connectivity.Ready {
-				log.Printf("Successfully connected to storage server: %s at %s", serverID, address)
+				gw.Logger.Info("successfully connected to storage server", "server_id", serverID, "address", address)
+				gw.getBackoff(serverID).Success()
 			} else {
-				log.Printf("Connection to storage server %s is in state: %v", serverID, state)
+				gw.Logger.Info("connection to storage server in unexpected state", "server_id", serverID, "state", state)
 			}
 		}
 	}()
@@ -357,13 +774,19 @@ func (gw *APIGateway) reconnectToStorageServer(serverID uuid.UUID, address strin
 	gw.clientsMu.Unlock()
 
 	// Create new connection without blocking
-	conn, err := grpc.Dial(address,
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(1024*1024*1024), // 1GB
 			grpc.MaxCallSendMsgSize(1024*1024*1024), // 1GB
 		),
-	)
+	}
+	if gw.BWMeter != nil {
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(gw.BWMeter.StatsHandler(serverID.String())))
+	}
+
+	conn, err := grpc.Dial(address, dialOpts...)
 	if err != nil {
 		return err
 	}
@@ -372,7 +795,7 @@ func (gw *APIGateway) reconnectToStorageServer(serverID uuid.UUID, address strin
 	gw.StorageClients[serverID] = conn
 	gw.clientsMu.Unlock()
 
-	log.Printf("Initiated reconnection to storage server: %s at %s", serverID, address)
+	gw.Logger.Info("initiated reconnection to storage server", "server_id", serverID, "address", address)
 
 	// Check connection state in background
 	go func() {
@@ -383,9 +806,10 @@ func (gw *APIGateway) reconnectToStorageServer(serverID uuid.UUID, address strin
 		if conn.WaitForStateChange(ctx, connectivity.Idle) {
 			state := conn.GetState()
 			if state == connectivity.Ready {
-				log.Printf("Successfully reconnected to storage server: %s at %s", serverID, address)
+				gw.Logger.Info("successfully reconnected to storage server", "server_id", serverID, "address", address)
+				gw.getBackoff(serverID).Success()
 			} else {
-				log.Printf("Reconnection to storage server %s is in state: %v", serverID, state)
+				gw.Logger.Info("reconnection to storage server in unexpected state", "server_id", serverID, "state", state)
 			}
 		}
 	}()
@@ -400,11 +824,12 @@ func (gw *APIGateway) closeStorageClient(serverID uuid.UUID) {
 
 	if conn, exists := gw.StorageClients[serverID]; exists {
 		if err := conn.Close(); err != nil {
-			log.Printf("Error closing connection to server %s: %v", serverID, err)
+			gw.Logger.Error(err, "error closing connection to server", "server_id", serverID)
 		}
 		delete(gw.StorageClients, serverID)
 		delete(gw.CircuitBreakers, serverID)
-		log.Printf("Closed connection to storage server: %s", serverID)
+		delete(gw.StorageBackoffs, serverID)
+		gw.Logger.Info("closed connection to storage server", "server_id", serverID)
 	}
 }
 
@@ -415,9 +840,9 @@ func (gw *APIGateway) CloseAllStorageClients() {
 
 	for serverID, conn := range gw.StorageClients {
 		if err := conn.Close(); err != nil {
-			log.Printf("Error closing connection to server %s: %v", serverID, err)
+			gw.Logger.Error(err, "error closing connection to server", "server_id", serverID)
 		}
 	}
 	gw.StorageClients = make(map[uuid.UUID]*grpc.ClientConn)
-	log.Println("All storage client connections closed")
+	gw.Logger.Info("all storage client connections closed")
 }
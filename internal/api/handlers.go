@@ -3,33 +3,44 @@ package api
 import (
 	"context"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/logctx"
+	"github.com/s3storage/internal/storage"
 )
 
-// GetFileMetadata handles file metadata requests
+// GetFileMetadata handles file metadata requests, looked up by a ?path=
+// query param when one is given (e.g. GET
+// /files/by-path/metadata?path=/photos/2024/img.jpg), and otherwise by the
+// :file_id route param, which must then be a valid UUID.
 func (gw *APIGateway) GetFileMetadata(c *gin.Context) {
 	fileIDStr := c.Param("file_id")
 
-	fileID, err := uuid.Parse(fileIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid file_id",
-			"details": err.Error(),
-		})
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	// Get file metadata
-	file, err := gw.Storage.GetFileByID(ctx, fileID)
+	var file *storage.File
+	var err error
+
+	if path := c.Query("path"); path != "" {
+		file, err = gw.Storage.GetFileByPath(ctx, path)
+	} else {
+		var fileID uuid.UUID
+		fileID, err = uuid.Parse(fileIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid file_id",
+				"details": err.Error(),
+			})
+			return
+		}
+		file, err = gw.MetaCache.GetFileByID(ctx, fileID)
+	}
 	if err != nil {
+		logctx.FromContext(ctx).Info("file not found", "file_id", fileIDStr)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "file not found",
 			"file_id": fileIDStr,
@@ -70,138 +81,48 @@ func (gw *APIGateway) GetFileMetadata(c *gin.Context) {
 	})
 }
 
-// ListFiles handles file listing requests with pagination
-func (gw *APIGateway) ListFiles(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
-	defer cancel()
-
-	// Parse pagination parameters
-	page := 1
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
+// ListFileVersions handles listing every version (including delete markers)
+// of a given filename, newest first.
+func (gw *APIGateway) ListFileVersions(c *gin.Context) {
+	filename := c.Param("filename")
 
-	perPage := 20
-	if perPageStr := c.Query("per_page"); perPageStr != "" {
-		if pp, err := strconv.Atoi(perPageStr); err == nil && pp > 0 && pp <= 100 {
-			perPage = pp
-		}
-	}
-
-	// Parse filter parameters
-	status := c.Query("status")
-
-	// Query files from database
-	query := `
-		SELECT file_id, filename, content_type, total_size, upload_status, 
-		       COALESCE(checksum, ''), created_at, updated_at, completed_at
-		FROM files
-	`
-	args := make([]interface{}, 0)
-	argCount := 0
-
-	if status != "" {
-		argCount++
-		query += ` WHERE upload_status = $` + strconv.Itoa(argCount)
-		args = append(args, status)
-	}
-
-	query += ` ORDER BY created_at DESC`
-
-	// Add pagination
-	argCount++
-	query += ` LIMIT $` + strconv.Itoa(argCount)
-	args = append(args, perPage)
-
-	argCount++
-	query += ` OFFSET $` + strconv.Itoa(argCount)
-	args = append(args, (page-1)*perPage)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
 
-	rows, err := gw.DB.Query(ctx, query, args...)
+	versions, err := gw.Storage.ListFileVersions(ctx, filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to query files",
+			"error":   "failed to list file versions",
 			"details": err.Error(),
 		})
 		return
 	}
-	defer rows.Close()
-
-	files := make([]gin.H, 0)
-	for rows.Next() {
-		var fileID uuid.UUID
-		var filename, contentType, uploadStatus, checksum string
-		var totalSize int64
-		var createdAt, updatedAt time.Time
-		var completedAt *time.Time
-
-		err := rows.Scan(&fileID, &filename, &contentType, &totalSize, &uploadStatus,
-			&checksum, &createdAt, &updatedAt, &completedAt)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "failed to scan file",
-				"details": err.Error(),
-			})
-			return
-		}
 
-		files = append(files, gin.H{
-			"file_id":      fileID,
-			"filename":     filename,
-			"content_type": contentType,
-			"size":         totalSize,
-			"status":       uploadStatus,
-			"checksum":     checksum,
-			"created_at":   createdAt,
-			"updated_at":   updatedAt,
-			"completed_at": completedAt,
+	result := make([]gin.H, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, gin.H{
+			"file_id":          v.FileID,
+			"version_id":       v.VersionID,
+			"is_delete_marker": v.IsDeleteMarker,
+			"size":             v.TotalSize,
+			"status":           v.UploadStatus,
+			"created_at":       v.CreatedAt,
+			"updated_at":       v.UpdatedAt,
 		})
 	}
 
-	if err := rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "error iterating files",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Get total count
-	countQuery := `SELECT COUNT(*) FROM files`
-	if status != "" {
-		countQuery += ` WHERE upload_status = $1`
-	}
-
-	var totalCount int64
-	var countArgs []interface{}
-	if status != "" {
-		countArgs = append(countArgs, status)
-	}
-
-	if err := gw.DB.QueryRow(ctx, countQuery, countArgs...).Scan(&totalCount); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to get total count",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	totalPages := (totalCount + int64(perPage) - 1) / int64(perPage)
-
 	c.JSON(http.StatusOK, gin.H{
-		"files": files,
-		"pagination": gin.H{
-			"page":        page,
-			"per_page":    perPage,
-			"total_count": totalCount,
-			"total_pages": totalPages,
-		},
+		"filename": filename,
+		"versions": result,
 	})
 }
 
-// DeleteFile handles file deletion requests
+// DeleteFile handles file deletion requests. By default it behaves like an
+// S3 versioned bucket: the target version's row and chunks are left alone
+// and a new zero-size row with IsDeleteMarker=true becomes the current
+// version of that filename. Passing ?version_id=<uuid> instead permanently
+// deletes that one specific version - only then are its chunks dispatched
+// to storage servers for DeleteChunk.
 func (gw *APIGateway) DeleteFile(c *gin.Context) {
 	fileIDStr := c.Param("file_id")
 
@@ -217,9 +138,9 @@ func (gw *APIGateway) DeleteFile(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
 	defer cancel()
 
-	// Get file with chunks
-	file, err := gw.Storage.GetFileByID(ctx, fileID)
+	file, err := gw.MetaCache.GetFileByID(ctx, fileID)
 	if err != nil {
+		logctx.FromContext(ctx).Info("file not found", "file_id", fileIDStr)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "file not found",
 			"file_id": fileIDStr,
@@ -227,7 +148,47 @@ func (gw *APIGateway) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	// Delete chunks from storage servers
+	logger := logctx.FromContext(ctx).WithValues("file_id", fileID)
+	ctx = logctx.WithLogger(ctx, logger)
+
+	if c.Query("version_id") == "" {
+		marker, err := gw.Storage.CreateDeleteMarker(ctx, file)
+		if err != nil {
+			logger.Error(err, "failed to create delete marker")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to create delete marker",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":          "delete marker created",
+			"file_id":          fileID,
+			"delete_marker_id": marker.FileID,
+			"version_id":       marker.VersionID,
+		})
+		return
+	}
+
+	versionID, err := uuid.Parse(c.Query("version_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid version_id",
+			"details": err.Error(),
+		})
+		return
+	}
+	if versionID != file.VersionID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "version_id does not match file_id",
+		})
+		return
+	}
+
+	// Permanent deletion of this one version: dispatch DeleteChunk to every
+	// storage server, queuing a retry for any that fail rather than leaking
+	// the chunk on disk.
 	deletedChunks := 0
 	failedChunks := 0
 
@@ -235,6 +196,9 @@ func (gw *APIGateway) DeleteFile(c *gin.Context) {
 		client, err := gw.getStorageClient(chunk.StorageServerID)
 		if err != nil {
 			failedChunks++
+			if enqueueErr := gw.Storage.EnqueuePendingChunkDelete(ctx, chunk.ChunkID, chunk.StorageServerID); enqueueErr != nil {
+				logger.Error(enqueueErr, "failed to enqueue pending chunk delete", "chunk_id", chunk.ChunkID)
+			}
 			continue
 		}
 
@@ -243,15 +207,19 @@ func (gw *APIGateway) DeleteFile(c *gin.Context) {
 		})
 		if err != nil {
 			failedChunks++
+			if enqueueErr := gw.Storage.EnqueuePendingChunkDelete(ctx, chunk.ChunkID, chunk.StorageServerID); enqueueErr != nil {
+				logger.Error(enqueueErr, "failed to enqueue pending chunk delete", "chunk_id", chunk.ChunkID)
+			}
 		} else {
 			deletedChunks++
 		}
 	}
 
-	// Delete file record from database (CASCADE will delete chunks)
+	// Delete file record from database (CASCADE will delete chunk rows)
 	deleteQuery := `DELETE FROM files WHERE file_id = $1`
 	result, err := gw.DB.Exec(ctx, deleteQuery, fileID)
 	if err != nil {
+		logger.Error(err, "failed to delete file record")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to delete file record",
 			"details": err.Error(),
@@ -268,8 +236,9 @@ func (gw *APIGateway) DeleteFile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":        "file deleted successfully",
+		"message":        "version permanently deleted",
 		"file_id":        fileID,
+		"version_id":     versionID,
 		"deleted_chunks": deletedChunks,
 		"failed_chunks":  failedChunks,
 	})
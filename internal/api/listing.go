@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/s3storage/internal/storage"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 1000
+)
+
+// parseListParams reads the limit/start_after query params shared by
+// ListFiles and ListDirectory.
+func parseListParams(c *gin.Context, defaultLimit int) (limit int, after *storage.FileCursor, err error) {
+	limit = defaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, convErr := strconv.Atoi(limitStr); convErr == nil && l > 0 && l <= maxListLimit {
+			limit = l
+		}
+	}
+
+	if token := c.Query("start_after"); token != "" {
+		cursor, decodeErr := storage.DecodeFileCursor(token)
+		if decodeErr != nil {
+			return 0, nil, decodeErr
+		}
+		after = &cursor
+	}
+
+	return limit, after, nil
+}
+
+// fileEntryJSON renders a file the same way across ListFiles and
+// ListDirectory.
+func fileEntryJSON(file *storage.File) gin.H {
+	return gin.H{
+		"file_id":      file.FileID,
+		"filename":     file.Filename,
+		"path":         file.Path,
+		"content_type": file.ContentType,
+		"size":         file.TotalSize,
+		"status":       file.UploadStatus,
+		"checksum":     file.Checksum,
+		"version_id":   file.VersionID,
+		"created_at":   file.CreatedAt,
+		"updated_at":   file.UpdatedAt,
+		"completed_at": file.CompletedAt,
+	}
+}
+
+// ListFiles handles `GET /files?prefix=&delimiter=&start_after=&limit=`,
+// paging through the files table with (created_at, file_id) keyset
+// pagination instead of OFFSET, which gets slower the further into a large
+// table a page is. If delimiter is set, paths that contain it past prefix
+// are collapsed into common_prefixes (S3 ListObjectsV2-style) rather than
+// listed individually.
+func (gw *APIGateway) ListFiles(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	prefix := c.Query("prefix")
+	delimiter := c.Query("delimiter")
+
+	limit, after, err := parseListParams(c, defaultListLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_after cursor", "details": err.Error()})
+		return
+	}
+
+	files := make([]gin.H, 0, limit)
+	commonPrefixSet := make(map[string]bool)
+	var last *storage.File
+
+	for file := range gw.Storage.ListEntries(ctx, prefix, after, limit) {
+		last = file
+
+		if delimiter != "" {
+			rest := strings.TrimPrefix(file.Path, prefix)
+			if idx := strings.Index(rest, delimiter); idx >= 0 {
+				commonPrefixSet[prefix+rest[:idx+len(delimiter)]] = true
+				continue
+			}
+		}
+
+		files = append(files, fileEntryJSON(file))
+	}
+
+	commonPrefixes := make([]string, 0, len(commonPrefixSet))
+	for p := range commonPrefixSet {
+		commonPrefixes = append(commonPrefixes, p)
+	}
+	sort.Strings(commonPrefixes)
+
+	resp := gin.H{
+		"files":           files,
+		"common_prefixes": commonPrefixes,
+	}
+	if last != nil {
+		resp["next_cursor"] = storage.EncodeFileCursor(storage.FileCursor{CreatedAt: last.CreatedAt, FileID: last.FileID})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListDirectory handles `GET /dirs/*path`, the filesystem-style view of
+// ListFiles: it lists only the immediate files and subdirectories under
+// path, grouping anything further nested under its first path segment
+// instead of returning it directly.
+func (gw *APIGateway) ListDirectory(c *gin.Context) {
+	dir := strings.TrimSuffix(c.Param("path"), "/") + "/"
+	if !strings.HasPrefix(dir, "/") {
+		dir = "/" + dir
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	limit, after, err := parseListParams(c, maxListLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_after cursor", "details": err.Error()})
+		return
+	}
+
+	files := make([]gin.H, 0)
+	subdirSet := make(map[string]bool)
+	var last *storage.File
+
+	for file := range gw.Storage.ListEntries(ctx, dir, after, limit) {
+		last = file
+
+		rest := strings.TrimPrefix(file.Path, dir)
+		if idx := strings.Index(rest, "/"); idx >= 0 {
+			subdirSet[dir+rest[:idx+1]] = true
+			continue
+		}
+
+		files = append(files, fileEntryJSON(file))
+	}
+
+	subdirectories := make([]string, 0, len(subdirSet))
+	for d := range subdirSet {
+		subdirectories = append(subdirectories, d)
+	}
+	sort.Strings(subdirectories)
+
+	resp := gin.H{
+		"directory":      dir,
+		"files":          files,
+		"subdirectories": subdirectories,
+	}
+	if last != nil {
+		resp["next_cursor"] = storage.EncodeFileCursor(storage.FileCursor{CreatedAt: last.CreatedAt, FileID: last.FileID})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
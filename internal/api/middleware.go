@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/s3storage/internal/logctx"
+	"github.com/s3storage/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/s3storage/internal/api"
+
+// TracingMiddleware starts a root span for every request, extracting an
+// incoming traceparent header (if any) so gateway spans join a caller's
+// trace, and records the request in the RED request_duration_seconds
+// histogram keyed by route and outcome status.
+func TracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
+
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		start := time.Now()
+		ctx, span := tracer.Start(ctx, spanName(c), trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Request.Method),
+				semconv.HTTPRoute(c.FullPath()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if len(c.Errors) > 0 {
+			span.SetStatus(codes.Error, c.Errors.String())
+		}
+
+		outcome := "success"
+		if status >= 500 {
+			outcome = "error"
+		} else if status >= 400 {
+			outcome = "client_error"
+		}
+		telemetry.RequestDuration.WithLabelValues(spanName(c), outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RequestLoggerMiddleware attaches a per-request logr.Logger - base
+// enriched with a fresh request_id - to the request's context.Context, so
+// any log line emitted while handling it (via logctx.FromContext) is
+// automatically tagged with that ID without threading a logger through
+// every function signature. Handlers that learn a more specific ID, e.g.
+// UploadFile's file_id once it creates the file record, should further
+// enrich it with WithValues and store the result back with
+// logctx.WithLogger before logging. It also emits one structured line per
+// request summarizing the outcome - the same information TracingMiddleware
+// records as a span, but in the JSON log stream.
+func RequestLoggerMiddleware(base logr.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := uuid.New().String()
+		logger := base.WithValues("request_id", requestID)
+
+		c.Request = c.Request.WithContext(logctx.WithLogger(c.Request.Context(), logger))
+		c.Writer.Header().Set("X-Request-Id", requestID)
+
+		start := time.Now()
+		c.Next()
+
+		logger.Info("request completed",
+			"method", c.Request.Method,
+			"route", spanName(c),
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}
+}
+
+// spanName identifies the matched route, falling back to the raw path for
+// requests that didn't match one (e.g. 404s).
+func spanName(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return fmt.Sprintf("%s %s", c.Request.Method, route)
+	}
+	return fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.Path)
+}
+
+// MetricsHandler exposes the process's Prometheus metrics, including the
+// RED histogram, hash ring/circuit breaker gauges, and retry counters.
+func MetricsHandler() gin.HandlerFunc {
+	h := promhttp.Handler()
+	return func(c *gin.Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
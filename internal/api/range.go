@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsatisfiableRange is returned by ParseRange when the requested range
+// doesn't overlap the resource at all, the signal DownloadFile uses to
+// respond 416 Range Not Satisfiable.
+var ErrUnsatisfiableRange = errors.New("range not satisfiable")
+
+// ByteRange is an inclusive, resolved [Start, End] byte range into a
+// resource of a known size - "resolved" meaning any open-ended or
+// suffix-length form in the Range header has already been pinned to
+// concrete offsets.
+type ByteRange struct {
+	Start, End int64
+}
+
+// Length returns the number of bytes the range covers.
+func (r ByteRange) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// ParseRange parses a "Range: bytes=..." header value against a resource of
+// size bytes and returns every range it names, resolved to concrete
+// [Start, End] offsets. It accepts the standard forms: "bytes=0-499"
+// (explicit), "bytes=500-" (open-ended, to EOF), "bytes=-500" (suffix,
+// last 500 bytes), and a comma-separated list of any of those for a
+// multi-range request. Ranges are returned in the order given and are not
+// merged or deduplicated, matching RFC 7233's "satisfiable ranges" scope;
+// DownloadFile itself only serves the first one, since a real
+// multipart/byteranges response isn't implemented.
+//
+// Malformed ranges and ranges starting at or beyond the end of the resource
+// are skipped. It returns ErrUnsatisfiableRange if header doesn't start with
+// "bytes=", or if no range in it is left after that.
+func ParseRange(header string, size int64) ([]ByteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, ErrUnsatisfiableRange
+	}
+	spec := strings.TrimPrefix(header, prefix)
+
+	var ranges []ByteRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		r, err := parseOneRange(part, size)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return nil, ErrUnsatisfiableRange
+	}
+	return ranges, nil
+}
+
+// parseOneRange resolves a single "start-end", "start-", or "-suffixLength"
+// range spec against a resource of size bytes.
+func parseOneRange(part string, size int64) (ByteRange, error) {
+	dash := strings.IndexByte(part, '-')
+	if dash < 0 {
+		return ByteRange{}, fmt.Errorf("malformed range %q", part)
+	}
+
+	startStr, endStr := part[:dash], part[dash+1:]
+
+	if startStr == "" {
+		// Suffix form: last N bytes.
+		suffixLen, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffixLen <= 0 {
+			return ByteRange{}, fmt.Errorf("malformed suffix range %q", part)
+		}
+		if suffixLen > size {
+			suffixLen = size
+		}
+		return ByteRange{Start: size - suffixLen, End: size - 1}, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return ByteRange{}, fmt.Errorf("range start out of bounds: %q", part)
+	}
+
+	if endStr == "" {
+		// Open-ended form: start to EOF.
+		return ByteRange{Start: start, End: size - 1}, nil
+	}
+
+	end, err := strconv.ParseInt(endStr, 10, 64)
+	if err != nil || end < start {
+		return ByteRange{}, fmt.Errorf("malformed range end: %q", part)
+	}
+	if end >= size {
+		end = size - 1
+	}
+	return ByteRange{Start: start, End: end}, nil
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TusResumableVersion is the tus protocol version this gateway speaks.
+	TusResumableVersion = "1.0.0"
+	resumableRPCTimeout = 30 * time.Second
+)
+
+// resumableSessionRoute remembers which storage server owns a session so
+// later AppendUpload/GetUploadOffset calls for the same session can be
+// routed back to it.
+type resumableSessionRoute struct {
+	serverID uuid.UUID
+}
+
+var (
+	resumableRoutes   = make(map[string]resumableSessionRoute)
+	resumableRoutesMu sync.RWMutex
+)
+
+// CreateUploadSession starts a resumable, tus-style upload for a single
+// chunk and returns the session id the client should use for AppendUpload.
+func (gw *APIGateway) CreateUploadSession(c *gin.Context) {
+	var req struct {
+		ChunkID   string `json:"chunk_id" binding:"required"`
+		TotalSize int64  `json:"total_size" binding:"required"`
+		Checksum  string `json:"checksum"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	serverID, err := gw.HashRing.GetServer(req.ChunkID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no storage servers available", "details": err.Error()})
+		return
+	}
+
+	serverUUID, err := uuid.Parse(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid server ID", "details": err.Error()})
+		return
+	}
+
+	client, err := gw.getStorageClient(serverUUID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to get storage client", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), resumableRPCTimeout)
+	defer cancel()
+
+	resp, err := client.CreateUploadSession(ctx, &pb.CreateUploadSessionRequest{
+		ChunkId:   req.ChunkID,
+		TotalSize: req.TotalSize,
+		Checksum:  req.Checksum,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload session", "details": err.Error()})
+		return
+	}
+
+	resumableRoutesMu.Lock()
+	resumableRoutes[resp.SessionId] = resumableSessionRoute{serverID: serverUUID}
+	resumableRoutesMu.Unlock()
+
+	c.Header("Tus-Resumable", TusResumableVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(resp.CommittedOffset, 10))
+	c.JSON(http.StatusCreated, gin.H{
+		"session_id":       resp.SessionId,
+		"committed_offset": resp.CommittedOffset,
+	})
+}
+
+// AppendUpload streams the request body to the storage server owning the
+// session, resuming from the offset carried in the Upload-Offset header.
+func (gw *APIGateway) AppendUpload(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+	isLast := c.GetHeader("Upload-Complete") == "true"
+
+	resumableRoutesMu.RLock()
+	route, exists := resumableRoutes[sessionID]
+	resumableRoutesMu.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload session not found", "session_id": sessionID})
+		return
+	}
+
+	client, err := gw.getStorageClient(route.serverID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to get storage client", "details": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), resumableRPCTimeout)
+	defer cancel()
+
+	stream, err := client.AppendUpload(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open upload stream", "details": err.Error()})
+		return
+	}
+
+	if err := stream.Send(&pb.AppendUploadRequest{
+		SessionId: sessionID,
+		Offset:    offset,
+		Data:      data,
+		IsLast:    isLast,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send upload data", "details": err.Error()})
+		return
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition {
+			c.Header("Tus-Resumable", TusResumableVersion)
+			c.JSON(http.StatusConflict, gin.H{"error": "offset mismatch", "details": st.Message()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to append upload data", "details": err.Error()})
+		return
+	}
+
+	if resp.Finalized {
+		resumableRoutesMu.Lock()
+		delete(resumableRoutes, sessionID)
+		resumableRoutesMu.Unlock()
+	}
+
+	c.Header("Tus-Resumable", TusResumableVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(resp.CommittedOffset, 10))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetUploadOffset reports the offset a client should resume an interrupted
+// upload from.
+func (gw *APIGateway) GetUploadOffset(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	resumableRoutesMu.RLock()
+	route, exists := resumableRoutes[sessionID]
+	resumableRoutesMu.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload session not found", "session_id": sessionID})
+		return
+	}
+
+	client, err := gw.getStorageClient(route.serverID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to get storage client", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), resumableRPCTimeout)
+	defer cancel()
+
+	resp, err := client.GetUploadOffset(ctx, &pb.GetUploadOffsetRequest{SessionId: sessionID})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload session not found", "details": err.Error()})
+		return
+	}
+
+	c.Header("Tus-Resumable", TusResumableVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(resp.CommittedOffset, 10))
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,234 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/chunker"
+	"github.com/s3storage/internal/storage"
+)
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completeMultipartUploadRequest struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	Bucket  string   `xml:"Bucket"`
+	Key     string   `xml:"Key"`
+	ETag    string   `xml:"ETag"`
+}
+
+// createMultipartUpload handles `POST /{bucket}/{key}?uploads`.
+func (s *Server) createMultipartUpload(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	if _, err := s.storage.GetBucket(ctx, bucket); err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchBucket", "bucket does not exist")
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	fileRecord := &storage.File{
+		FileID:       uuid.New(),
+		Filename:     key,
+		ContentType:  contentType,
+		UploadStatus: "pending",
+	}
+	if err := s.storage.CreateFile(ctx, fileRecord); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	upload := &storage.MultipartUpload{Bucket: bucket, Key: key, FileID: fileRecord.FileID, ContentType: contentType}
+	if err := s.storage.CreateMultipartUpload(ctx, upload); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.XML(http.StatusOK, initiateMultipartUploadResult{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: upload.UploadID.String(),
+	})
+}
+
+// uploadPart handles `PUT /{bucket}/{key}?partNumber=N&uploadId=U`. Each part
+// is stored as a single chunk via the existing PutChunk gRPC.
+func (s *Server) uploadPart(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	uploadID, err := uuid.Parse(c.Query("uploadId"))
+	if err != nil {
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", "invalid uploadId")
+		return
+	}
+	partNumber, err := strconv.Atoi(c.Query("partNumber"))
+	if err != nil || partNumber < 1 {
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", "invalid partNumber")
+		return
+	}
+
+	upload, err := s.storage.GetMultipartUpload(ctx, uploadID)
+	if err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchUpload", "multipart upload does not exist")
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	chunkID := uuid.New()
+	chunkHash := chunker.CalculateChecksum(data)
+
+	serverID, err := s.gw.HashRing.GetServer(chunkID.String())
+	if err != nil {
+		writeS3Error(c, http.StatusServiceUnavailable, "ServiceUnavailable", err.Error())
+		return
+	}
+	serverUUID, err := uuid.Parse(serverID)
+	if err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	client, err := s.gw.StorageClientForServer(serverUUID)
+	if err != nil {
+		writeS3Error(c, http.StatusServiceUnavailable, "ServiceUnavailable", err.Error())
+		return
+	}
+
+	cb := s.gw.CircuitBreakerForServer(serverUUID)
+	if err := cb.Execute(func() error {
+		return s.gw.UploadChunkWithRetry(ctx, client, chunkID.String(), data, chunkHash)
+	}); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	if err := s.storage.CreateChunk(ctx, &storage.Chunk{
+		ChunkID:         chunkID,
+		FileID:          upload.FileID,
+		ChunkNumber:     partNumber,
+		StorageServerID: serverUUID,
+		ChunkSize:       int64(len(data)),
+		ChunkHash:       chunkHash,
+	}); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	partMD5 := md5.Sum(data)
+	if err := s.storage.PutMultipartPart(ctx, &storage.MultipartPart{
+		UploadID:   uploadID,
+		PartNumber: partNumber,
+		ChunkID:    chunkID,
+		Size:       int64(len(data)),
+		MD5:        hex.EncodeToString(partMD5[:]),
+	}); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", hex.EncodeToString(partMD5[:])))
+	c.Status(http.StatusOK)
+}
+
+// completeMultipartUpload handles `POST /{bucket}/{key}?uploadId=U`,
+// assembling the uploaded parts into a single file record and computing the
+// S3-style ETag (md5 of concatenated part MD5s, suffixed with the part
+// count).
+func (s *Server) completeMultipartUpload(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	uploadID, err := uuid.Parse(c.Query("uploadId"))
+	if err != nil {
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", "invalid uploadId")
+		return
+	}
+
+	upload, err := s.storage.GetMultipartUpload(ctx, uploadID)
+	if err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchUpload", "multipart upload does not exist")
+		return
+	}
+
+	var req completeMultipartUploadRequest
+	if err := c.ShouldBindXML(&req); err != nil {
+		writeS3Error(c, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	parts, err := s.storage.GetMultipartParts(ctx, uploadID)
+	if err != nil || len(parts) == 0 {
+		writeS3Error(c, http.StatusBadRequest, "InvalidPart", "no parts found for upload")
+		return
+	}
+
+	// Each part's chunk already attached itself to upload.FileID in
+	// uploadPart, so completing the upload only needs to finalize that file
+	// record rather than re-inserting chunks.
+	concatenatedMD5 := make([]byte, 0, len(parts)*md5.Size)
+	var totalSize int64
+	for _, part := range parts {
+		decoded, _ := hex.DecodeString(part.MD5)
+		concatenatedMD5 = append(concatenatedMD5, decoded...)
+		totalSize += part.Size
+	}
+
+	if err := s.storage.UpdateFileSize(ctx, upload.FileID, totalSize); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	if err := s.storage.UpdateFileStatus(ctx, upload.FileID, "completed"); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	if err := s.storage.CompleteMultipartUpload(ctx, uploadID); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	sum := md5.Sum(concatenatedMD5)
+	etag := fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(parts))
+	if err := s.storage.PutObjectKey(ctx, &storage.ObjectKey{
+		Bucket: bucket,
+		Key:    key,
+		FileID: upload.FileID,
+		ETag:   etag,
+	}); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.XML(http.StatusOK, completeMultipartUploadResult{Bucket: bucket, Key: key, ETag: etag})
+}
@@ -0,0 +1,368 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/chunker"
+	"github.com/s3storage/internal/storage"
+)
+
+const (
+	objectNumChunks  = 6
+	objectRPCTimeout = 5 * time.Minute
+	defaultListLimit = 1000
+)
+
+// createBucket handles `PUT /{bucket}`.
+func (s *Server) createBucket(c *gin.Context, bucket string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	owner := c.GetHeader("X-Amz-Credential-Owner")
+	if owner == "" {
+		owner = "unknown"
+	}
+
+	err := s.storage.CreateBucket(ctx, &storage.Bucket{Name: bucket, Owner: owner, Policy: "private"})
+	if err != nil && err != storage.ErrDuplicate {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// putObject handles `PUT /{bucket}/{key}`, chunking the body the same way
+// APIGateway.UploadFile does and recording the (bucket, key) -> file_id
+// mapping.
+func (s *Server) putObject(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	if _, err := s.storage.GetBucket(ctx, bucket); err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchBucket", "bucket does not exist")
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	fileRecord := &storage.File{
+		FileID:       uuid.New(),
+		Filename:     key,
+		ContentType:  contentType,
+		TotalSize:    int64(len(body)),
+		UploadStatus: "pending",
+		Path:         "/" + bucket + "/" + key,
+	}
+	if err := s.storage.CreateFile(ctx, fileRecord); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	chunks, err := s.writeChunks(ctx, fileRecord.FileID, body)
+	if err != nil {
+		s.storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	if err := s.storage.CreateChunksBatch(ctx, chunks); err != nil {
+		s.storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	if err := s.storage.UpdateFileStatus(ctx, fileRecord.FileID, "completed"); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	etag := fmt.Sprintf("%x", md5.Sum(body))
+
+	if err := s.storage.PutObjectKey(ctx, &storage.ObjectKey{
+		Bucket: bucket,
+		Key:    key,
+		FileID: fileRecord.FileID,
+		ETag:   etag,
+	}); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", etag))
+	c.Status(http.StatusOK)
+}
+
+// writeChunks splits data into objectNumChunks chunks and uploads each to a
+// storage server chosen by the hash ring, returning chunk records ready for
+// CreateChunksBatch.
+func (s *Server) writeChunks(ctx context.Context, fileID uuid.UUID, data []byte) ([]*storage.Chunk, error) {
+	boundaries, err := chunker.CalculateChunkBoundaries(int64(len(data)), objectNumChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate chunk boundaries: %w", err)
+	}
+
+	chunks := make([]*storage.Chunk, 0, len(boundaries))
+
+	for i, boundary := range boundaries {
+		chunkData := data[boundary.Offset : boundary.Offset+boundary.Size]
+		chunkHash := chunker.CalculateChecksum(chunkData)
+
+		chunkID := uuid.New()
+		serverID, err := s.gw.HashRing.GetServer(chunkID.String())
+		if err != nil {
+			return nil, fmt.Errorf("no storage servers available for chunk %d: %w", i, err)
+		}
+		serverUUID, err := uuid.Parse(serverID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server id: %w", err)
+		}
+
+		client, err := s.gw.StorageClientForServer(serverUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get storage client for chunk %d: %w", i, err)
+		}
+
+		cb := s.gw.CircuitBreakerForServer(serverUUID)
+		if err := cb.Execute(func() error {
+			return s.gw.UploadChunkWithRetry(ctx, client, chunkID.String(), chunkData, chunkHash)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to upload chunk %d: %w", i, err)
+		}
+
+		chunks = append(chunks, &storage.Chunk{
+			ChunkID:         chunkID,
+			FileID:          fileID,
+			ChunkNumber:     i,
+			StorageServerID: serverUUID,
+			ChunkSize:       boundary.Size,
+			ChunkHash:       chunkHash,
+			Status:          "completed",
+		})
+	}
+
+	return chunks, nil
+}
+
+// getObject handles `GET /{bucket}/{key}`, including `Range:` byte-range
+// requests that only fetch the chunks overlapping the requested range.
+func (s *Server) getObject(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	obj, file, err := s.resolveObject(ctx, bucket, key)
+	if err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchKey", "object does not exist")
+		return
+	}
+
+	start, end, partial, err := parseRange(c.GetHeader("Range"), file.TotalSize)
+	if err != nil {
+		writeS3Error(c, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", err.Error())
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", obj.ETag))
+	c.Header("Content-Type", file.ContentType)
+
+	if partial {
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, file.TotalSize))
+		c.Header("Content-Length", strconv.FormatInt(end-start+1, 10))
+		c.Status(http.StatusPartialContent)
+	} else {
+		c.Header("Content-Length", strconv.FormatInt(file.TotalSize, 10))
+		c.Status(http.StatusOK)
+	}
+
+	offset := int64(0)
+	for _, chunk := range file.Chunks {
+		chunkStart := offset
+		chunkEnd := offset + chunk.ChunkSize - 1
+		offset += chunk.ChunkSize
+
+		if partial && (chunkEnd < start || chunkStart > end) {
+			continue // chunk falls entirely outside the requested range
+		}
+
+		var buf bytes.Buffer
+		client, err := s.gw.StorageClientForServer(chunk.StorageServerID)
+		if err != nil {
+			return
+		}
+		if err := s.gw.DownloadChunkWithRetry(ctx, client, chunk.ChunkID.String(), &buf); err != nil {
+			return
+		}
+
+		chunkBytes := buf.Bytes()
+		if partial {
+			loStart := int64(0)
+			if start > chunkStart {
+				loStart = start - chunkStart
+			}
+			hiEnd := int64(len(chunkBytes))
+			if end < chunkEnd {
+				hiEnd = end - chunkStart + 1
+			}
+			if loStart < hiEnd {
+				chunkBytes = chunkBytes[loStart:hiEnd]
+			} else {
+				chunkBytes = nil
+			}
+		}
+
+		c.Writer.Write(chunkBytes)
+	}
+}
+
+// headObject handles `HEAD /{bucket}/{key}`.
+func (s *Server) headObject(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	obj, file, err := s.resolveObject(ctx, bucket, key)
+	if err != nil {
+		writeS3Error(c, http.StatusNotFound, "NoSuchKey", "object does not exist")
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", obj.ETag))
+	c.Header("Content-Type", file.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(file.TotalSize, 10))
+	c.Header("Last-Modified", file.UpdatedAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// deleteObject handles `DELETE /{bucket}/{key}`.
+func (s *Server) deleteObject(c *gin.Context, bucket, key string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	obj, err := s.storage.GetObjectKey(ctx, bucket, key)
+	if err != nil {
+		c.Status(http.StatusNoContent) // S3 DELETE is idempotent
+		return
+	}
+
+	if err := s.storage.DeleteObjectKey(ctx, bucket, key); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	if err := s.storage.DeleteFile(ctx, obj.FileID); err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listObjectsV2 handles `GET /{bucket}?list-type=2`.
+func (s *Server) listObjectsV2(c *gin.Context, bucket string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), objectRPCTimeout)
+	defer cancel()
+
+	prefix := c.Query("prefix")
+	startAfter := c.Query("start-after")
+	maxKeys := defaultListLimit
+	if mk, err := strconv.Atoi(c.Query("max-keys")); err == nil && mk > 0 {
+		maxKeys = mk
+	}
+
+	objects, err := s.storage.ListObjectKeys(ctx, bucket, prefix, startAfter, maxKeys+1)
+	if err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	truncated := len(objects) > maxKeys
+	if truncated {
+		objects = objects[:maxKeys]
+	}
+
+	result := listBucketResult{
+		Name:        bucket,
+		Prefix:      prefix,
+		KeyCount:    len(objects),
+		MaxKeys:     maxKeys,
+		IsTruncated: truncated,
+	}
+	for _, obj := range objects {
+		result.Contents = append(result.Contents, listBucketObject{
+			Key:          obj.Key,
+			LastModified: obj.UpdatedAt.UTC().Format(time.RFC3339),
+			ETag:         fmt.Sprintf("%q", obj.ETag),
+			StorageClass: "STANDARD",
+		})
+	}
+
+	c.XML(http.StatusOK, result)
+}
+
+// resolveObject looks up an object's key mapping and underlying file record.
+func (s *Server) resolveObject(ctx context.Context, bucket, key string) (*storage.ObjectKey, *storage.File, error) {
+	obj, err := s.storage.GetObjectKey(ctx, bucket, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.storage.GetFileByID(ctx, obj.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return obj, file, nil
+}
+
+// parseRange parses a "bytes=start-end" Range header. It returns
+// partial=false when no Range header is present.
+func parseRange(header string, totalSize int64) (start, end int64, partial bool, err error) {
+	if header == "" {
+		return 0, totalSize - 1, false, nil
+	}
+
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, 0, false, fmt.Errorf("unsupported range unit")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false, fmt.Errorf("malformed range header")
+	}
+
+	start, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("malformed range start")
+	}
+
+	if parts[1] == "" {
+		end = totalSize - 1
+	} else {
+		end, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("malformed range end")
+		}
+	}
+
+	if start < 0 || end >= totalSize || start > end {
+		return 0, 0, false, fmt.Errorf("range out of bounds")
+	}
+
+	return start, end, true, nil
+}
@@ -0,0 +1,140 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/s3storage/internal/api"
+	"github.com/s3storage/internal/storage"
+)
+
+// Server exposes an AWS S3 v4 wire-compatible HTTP surface on top of the
+// existing gRPC chunk plane, so tools like aws-cli, mc, s3cmd, and the
+// ceph/s3-tests suite can drive this storage cluster.
+type Server struct {
+	gw       *api.APIGateway
+	storage  *storage.PostgresStorage
+	keyStore KeyStore
+}
+
+// NewServer creates a new S3-compatible frontend backed by the given API
+// gateway.
+func NewServer(gw *api.APIGateway) *Server {
+	return &Server{
+		gw:       gw,
+		storage:  gw.Storage,
+		keyStore: &postgresKeyStore{storage: gw.Storage},
+	}
+}
+
+// RegisterRoutes wires the S3 surface onto router, alongside the existing
+// /files and /uploads groups.
+func (s *Server) RegisterRoutes(router *gin.Engine) {
+	router.Any("/:bucket", s.handleBucket)
+	router.Any("/:bucket/*key", s.handleObject)
+}
+
+// handleBucket dispatches bucket-level operations (list objects, create
+// bucket).
+func (s *Server) handleBucket(c *gin.Context) {
+	if err := s.authenticate(c); err != nil {
+		writeS3Error(c, http.StatusForbidden, "AccessDenied", err.Error())
+		return
+	}
+
+	bucket := c.Param("bucket")
+
+	switch c.Request.Method {
+	case http.MethodPut:
+		s.createBucket(c, bucket)
+	case http.MethodGet:
+		if c.Query("list-type") == "2" {
+			s.listObjectsV2(c, bucket)
+			return
+		}
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", "only list-type=2 listing is supported")
+	default:
+		writeS3Error(c, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported bucket operation")
+	}
+}
+
+// handleObject dispatches object-level operations (PutObject, GetObject,
+// HeadObject, DeleteObject) and multipart upload operations.
+func (s *Server) handleObject(c *gin.Context) {
+	if err := s.authenticate(c); err != nil {
+		writeS3Error(c, http.StatusForbidden, "AccessDenied", err.Error())
+		return
+	}
+
+	bucket := c.Param("bucket")
+	key := normalizeObjectKey(c.Param("key"))
+
+	if _, hasUploadID := c.GetQuery("uploadId"); hasUploadID {
+		s.handleMultipartRequest(c, bucket, key)
+		return
+	}
+	if _, isMultipartInit := c.GetQuery("uploads"); isMultipartInit {
+		s.createMultipartUpload(c, bucket, key)
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodPut:
+		s.putObject(c, bucket, key)
+	case http.MethodGet:
+		s.getObject(c, bucket, key)
+	case http.MethodHead:
+		s.headObject(c, bucket, key)
+	case http.MethodDelete:
+		s.deleteObject(c, bucket, key)
+	default:
+		writeS3Error(c, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported object operation")
+	}
+}
+
+func (s *Server) handleMultipartRequest(c *gin.Context, bucket, key string) {
+	switch c.Request.Method {
+	case http.MethodPut:
+		s.uploadPart(c, bucket, key)
+	case http.MethodPost:
+		s.completeMultipartUpload(c, bucket, key)
+	default:
+		writeS3Error(c, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported multipart operation")
+	}
+}
+
+// authenticate verifies the request's AWS SigV4 Authorization header.
+func (s *Server) authenticate(c *gin.Context) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return VerifyRequest(c.Request.Context(), c.Request, body, s.keyStore)
+}
+
+// normalizeObjectKey strips the leading slash gin's "*key" wildcard leaves
+// in place.
+func normalizeObjectKey(raw string) string {
+	if len(raw) > 0 && raw[0] == '/' {
+		return raw[1:]
+	}
+	return raw
+}
+
+// postgresKeyStore resolves SigV4 access keys via the access_keys table.
+type postgresKeyStore struct {
+	storage *storage.PostgresStorage
+}
+
+func (k *postgresKeyStore) GetSecretKey(ctx context.Context, accessKeyID string) (string, error) {
+	key, err := k.storage.GetAccessKey(ctx, accessKeyID)
+	if err != nil {
+		return "", err
+	}
+	return key.SecretKey, nil
+}
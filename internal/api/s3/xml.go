@@ -0,0 +1,38 @@
+package s3
+
+import (
+	"encoding/xml"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listBucketResult mirrors AWS's ListObjectsV2 XML response shape.
+type listBucketResult struct {
+	XMLName     xml.Name           `xml:"ListBucketResult"`
+	Name        string             `xml:"Name"`
+	Prefix      string             `xml:"Prefix"`
+	KeyCount    int                `xml:"KeyCount"`
+	MaxKeys     int                `xml:"MaxKeys"`
+	IsTruncated bool               `xml:"IsTruncated"`
+	Contents    []listBucketObject `xml:"Contents"`
+}
+
+type listBucketObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// s3Error mirrors AWS's XML error response shape.
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// writeS3Error writes an S3-style XML error body with the given HTTP status.
+func writeS3Error(c *gin.Context, status int, code, message string) {
+	c.XML(status, s3Error{Code: code, Message: message})
+}
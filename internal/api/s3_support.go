@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/circuitbreaker"
+)
+
+// StorageClientForServer returns the gRPC client for a storage server,
+// reconnecting if necessary. It is exported so sibling packages (e.g.
+// internal/api/s3) can drive chunk uploads/downloads without duplicating
+// connection management.
+func (gw *APIGateway) StorageClientForServer(serverID uuid.UUID) (pb.StorageServiceClient, error) {
+	return gw.getStorageClient(serverID)
+}
+
+// CircuitBreakerForServer returns the circuit breaker guarding calls to a
+// storage server.
+func (gw *APIGateway) CircuitBreakerForServer(serverID uuid.UUID) *circuitbreaker.CircuitBreaker {
+	return gw.getCircuitBreaker(serverID)
+}
+
+// UploadChunkWithRetry uploads a chunk to a storage server with retry logic.
+func (gw *APIGateway) UploadChunkWithRetry(ctx context.Context, client pb.StorageServiceClient, chunkID string, data []byte, checksum string) error {
+	return gw.UploadChunkToServerWithRetry(ctx, client, chunkID, data, checksum)
+}
+
+// DownloadChunkWithRetry downloads a chunk from a storage server with retry
+// logic, writing its bytes to w.
+func (gw *APIGateway) DownloadChunkWithRetry(ctx context.Context, client pb.StorageServiceClient, chunkID string, w io.Writer) error {
+	return gw.downloadChunkFromServerWithRetry(ctx, client, chunkID, w)
+}
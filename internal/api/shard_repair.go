@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/s3storage/internal/ec"
+	"github.com/s3storage/internal/storage"
+)
+
+// ShardRepairInterval is how often the background job scans erasure-coded
+// chunks for missing shards.
+const ShardRepairInterval = 10 * time.Minute
+
+// StartShardRepairLoop starts the background loop that detects and repairs
+// missing shards of erasure-coded chunks.
+func (gw *APIGateway) StartShardRepairLoop(ctx context.Context) {
+	gw.stopShardRepair = make(chan struct{})
+	ticker := time.NewTicker(ShardRepairInterval)
+
+	go func() {
+		defer ticker.Stop()
+		gw.Logger.Info("shard repair loop started", "interval", ShardRepairInterval)
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := gw.repairErasureCodedChunks(ctx); err != nil {
+					gw.Logger.Error(err, "error repairing erasure-coded shards")
+				}
+			case <-gw.stopShardRepair:
+				gw.Logger.Info("shard repair loop stopped")
+				return
+			case <-ctx.Done():
+				gw.Logger.Info("shard repair loop stopped due to context cancellation")
+				return
+			}
+		}
+	}()
+}
+
+// StopShardRepairLoop stops the shard repair loop.
+func (gw *APIGateway) StopShardRepairLoop() {
+	if gw.stopShardRepair != nil {
+		close(gw.stopShardRepair)
+	}
+}
+
+// repairErasureCodedChunks scans every erasure-coded chunk, reconstructs the
+// ones missing a shard, and re-uploads the missing shard(s) to freshly
+// chosen servers.
+func (gw *APIGateway) repairErasureCodedChunks(ctx context.Context) error {
+	chunks, err := gw.Storage.GetErasureCodedChunks(ctx)
+	if err != nil {
+		return err
+	}
+
+	repaired := 0
+	for _, chunk := range chunks {
+		ok, err := gw.repairChunkShards(ctx, chunk)
+		if err != nil {
+			gw.Logger.Error(err, "failed to repair shards for chunk", "chunk_id", chunk.ChunkID)
+			continue
+		}
+		if ok {
+			repaired++
+		}
+	}
+
+	if repaired > 0 {
+		gw.Logger.Info("shard repair: regenerated shards", "chunks_repaired", repaired)
+	}
+	return nil
+}
+
+// repairChunkShards checks chunk's shards for availability and re-encodes
+// and re-uploads any that are missing. It reports whether a repair happened.
+func (gw *APIGateway) repairChunkShards(ctx context.Context, chunk *storage.Chunk) (bool, error) {
+	cfg := gw.ECConfig.ToECConfig()
+
+	shardRecords, err := gw.Storage.GetChunkShardsByChunkID(ctx, chunk.ChunkID)
+	if err != nil {
+		return false, err
+	}
+
+	source := &gatewaySource{gw: gw}
+	present := make([]bool, cfg.TotalShards())
+	missing := 0
+	for _, sr := range shardRecords {
+		if _, err := source.Fetch(ctx, sr.ServerID, shardChunkID(chunk.ChunkID, sr.ShardIndex)); err == nil {
+			present[sr.ShardIndex] = true
+		} else {
+			missing++
+		}
+	}
+
+	if missing == 0 {
+		return false, nil
+	}
+
+	data, err := gw.downloadChunkErasureCoded(ctx, chunk)
+	if err != nil {
+		return false, err
+	}
+
+	shards, err := ec.Encode(cfg, data)
+	if err != nil {
+		return false, err
+	}
+
+	excluded := make([]string, 0, len(shardRecords))
+	for _, sr := range shardRecords {
+		if present[sr.ShardIndex] {
+			excluded = append(excluded, sr.ServerID.String())
+		}
+	}
+
+	for _, sr := range shardRecords {
+		if present[sr.ShardIndex] {
+			continue
+		}
+
+		serverID, err := gw.pickReplacementServer(chunk.ChunkID, excluded)
+		if err != nil {
+			return false, err
+		}
+
+		client, err := gw.getStorageClient(serverID)
+		if err != nil {
+			return false, err
+		}
+
+		cb := gw.getCircuitBreaker(serverID)
+		if err := cb.Execute(func() error {
+			return gw.UploadChunkToServerWithRetry(ctx, client, shardChunkID(chunk.ChunkID, sr.ShardIndex), shards[sr.ShardIndex], "")
+		}); err != nil {
+			return false, err
+		}
+
+		if serverID != sr.ServerID {
+			if err := gw.Storage.UpdateChunkShardServer(ctx, chunk.ChunkID, sr.ShardIndex, serverID); err != nil {
+				return false, err
+			}
+		}
+
+		excluded = append(excluded, serverID.String())
+	}
+
+	return true, nil
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -13,7 +12,9 @@ import (
 	"github.com/google/uuid"
 	pb "github.com/s3storage/api/proto"
 	"github.com/s3storage/internal/chunker"
+	"github.com/s3storage/internal/logctx"
 	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/spool"
 	"github.com/s3storage/internal/storage"
 )
 
@@ -22,8 +23,23 @@ const (
 	numChunks        = 6
 	uploadBufferSize = 64 * 1024 // 64KB
 	uploadTimeout    = 5 * time.Minute
+	// BoundedLoadCap is the factor applied to average per-server chunk
+	// count when placing new chunks: a server is skipped once its tracked
+	// load exceeds avgLoad*BoundedLoadCap. 1.25 is Google's commonly cited
+	// default for consistent hashing with bounded loads - it lets the
+	// consistent-hash primary absorb a one-off hot key while still capping
+	// how far any single server can be overloaded relative to its peers.
+	BoundedLoadCap = 1.25
 )
 
+// boundedLoadRing is satisfied by hasher.HashRing, letting uploadOneChunk
+// opt into bounded-load placement when the configured gw.HashRing backend
+// supports it without hasher.PlacementRing needing to expose it generally.
+type boundedLoadRing interface {
+	GetServerBoundedTracked(key string, cap float64) (string, error)
+	IncLoad(serverID string, delta int64)
+}
+
 // UploadFile handles file upload requests
 func (gw *APIGateway) UploadFile(c *gin.Context) {
 	// Parse multipart form
@@ -72,16 +88,27 @@ func (gw *APIGateway) UploadFile(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), uploadTimeout)
 	defer cancel()
 
-	// Create file record in database
+	// Create file record in database. An optional "path" form field lets the
+	// caller place the file in a directory hierarchy (e.g. "/photos/2024/img.jpg")
+	// for GET /dirs/*path and prefix/delimiter listing; it defaults to
+	// "/"+filename in CreateFile if left blank.
+	scheme := "replicated"
+	if gw.ECConfig.Enabled {
+		scheme = fmt.Sprintf("ec(%d,%d)", gw.ECConfig.DataShards, gw.ECConfig.ParityShards)
+	}
+
 	fileRecord := &storage.File{
 		FileID:       uuid.New(),
 		Filename:     header.Filename,
 		ContentType:  contentType,
 		TotalSize:    header.Size,
 		UploadStatus: "pending",
+		Path:         c.Request.FormValue("path"),
+		Scheme:       scheme,
 	}
 
-	if err := gw.Storage.CreateFile(ctx, fileRecord); err != nil {
+	if err := gw.MetaCache.CreateFile(ctx, fileRecord); err != nil {
+		logctx.FromContext(ctx).Error(err, "failed to create file record", "filename", fileRecord.Filename)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to create file record",
 			"details": err.Error(),
@@ -89,136 +116,85 @@ func (gw *APIGateway) UploadFile(c *gin.Context) {
 		return
 	}
 
-	// Calculate chunk boundaries
-	chunkBoundaries, err := chunker.CalculateChunkBoundaries(header.Size, numChunks)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to calculate chunk boundaries",
-			"details": err.Error(),
-		})
-		return
-	}
+	// Every log line from here on is tagged with file_id, so it's easy to
+	// pull the full upload history for one file out of the JSON log stream.
+	logger := logctx.FromContext(ctx).WithValues("file_id", fileRecord.FileID)
+	ctx = logctx.WithLogger(ctx, logger)
+
+	// An optional "chunking_mode" form field selects content-defined chunking
+	// (variable-size, content-aligned cuts) over the default fixed-count
+	// split; see chunker.StreamCDCChunks for why that helps dedup and delta
+	// efficiency. Defaults to the existing fixed-count behavior when absent.
+	useCDC := c.Request.FormValue("chunking_mode") == "cdc"
 
-	// Upload chunks to storage servers
 	chunks := make([]*storage.Chunk, 0, numChunks)
 	fileHasher := sha256.New()
-
-	for i, boundary := range chunkBoundaries {
-		// Read chunk data
-		chunkData := make([]byte, boundary.Size)
-		n, err := io.ReadFull(file, chunkData)
-		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
-			// Cleanup: update file status to failed
-			gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":        "failed to read chunk data",
-				"chunk_number": i,
-				"details":      err.Error(),
+	var deduplicatedChunks int
+
+	if useCDC {
+		err = chunker.StreamCDCChunks(file, chunker.DefaultMinCDCChunkSize, chunker.DefaultAvgCDCChunkSize, chunker.DefaultMaxCDCChunkSize,
+			func(info chunker.ChunkInfo, chunkData []byte) error {
+				fileHasher.Write(chunkData)
+
+				chunkHash := sha256.Sum256(chunkData)
+				chunkHashStr := hex.EncodeToString(chunkHash[:])
+
+				// Advisory dedup check only: chunks are addressed by chunk_id
+				// at the storage-node layer (one row per file/chunk_number),
+				// so a hash match can't be used to skip the upload or share
+				// chunk_id across files - it just tells us this file's bytes
+				// already exist elsewhere, for observability/reporting.
+				if _, err := gw.Storage.GetChunkByHash(ctx, chunkHashStr, int64(len(chunkData))); err == nil {
+					deduplicatedChunks++
+				} else if err != storage.ErrNotFound {
+					return fmt.Errorf("failed to check chunk dedup index: %w", err)
+				}
+
+				chunk, err := gw.uploadOneChunk(ctx, fileRecord.FileID, info.Number, chunkData, chunkHashStr)
+				if err != nil {
+					return err
+				}
+				chunks = append(chunks, chunk)
+				return nil
 			})
-			return
-		}
-		chunkData = chunkData[:n]
-
-		// Update file hash
-		fileHasher.Write(chunkData)
-
-		// Calculate chunk hash
-		chunkHash := sha256.Sum256(chunkData)
-		chunkHashStr := hex.EncodeToString(chunkHash[:])
-
-		// Generate chunk ID
-		chunkID := uuid.New()
-
-		// Determine storage server using consistent hashing
-		serverID, err := gw.HashRing.GetServer(chunkID.String())
 		if err != nil {
-			gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
-
-			// Get more details about available servers
-			allServers := gw.HashRing.GetAllServers()
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":             "no storage servers available",
-				"details":           err.Error(),
-				"available_servers": len(allServers),
-				"chunk_number":      i,
+			logger.Error(err, "failed to upload content-defined chunks")
+			gw.MetaCache.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to upload content-defined chunks",
+				"details": err.Error(),
 			})
 			return
 		}
-
-		serverUUID, err := uuid.Parse(serverID)
+	} else {
+		// Calculate chunk boundaries
+		chunkBoundaries, err := chunker.CalculateChunkBoundaries(header.Size, numChunks)
 		if err != nil {
-			gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+			logger.Error(err, "failed to calculate chunk boundaries")
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "invalid server ID",
+				"error":   "failed to calculate chunk boundaries",
 				"details": err.Error(),
 			})
 			return
 		}
 
-		// Get storage client with retry on connection failure
-		var client pb.StorageServiceClient
-		var clientErr error
-
-		// Try to get client, with one retry if connection is broken
-		for attempt := 0; attempt < 2; attempt++ {
-			client, clientErr = gw.getStorageClient(serverUUID)
-			if clientErr == nil {
-				break
-			}
-
-			if attempt == 0 {
-				// First attempt failed, wait a bit and try again
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-
-		if clientErr != nil {
-			gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":        "failed to get storage client",
-				"server_id":    serverID,
-				"chunk_number": i,
-				"details":      clientErr.Error(),
-			})
-			return
-		}
-
-		// Get circuit breaker for this server
-		cb := gw.getCircuitBreaker(serverUUID)
-
-		// Upload chunk to storage server with retry and circuit breaker
-		uploadErr := cb.Execute(func() error {
-			return gw.UploadChunkToServerWithRetry(ctx, client, chunkID.String(), chunkData, chunkHashStr)
-		})
-
-		if uploadErr != nil {
-			gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+		uploaded, err := gw.uploadChunksParallel(ctx, fileRecord.FileID, file, chunkBoundaries, fileHasher)
+		if err != nil {
+			logger.Error(err, "failed to upload chunks")
+			gw.MetaCache.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":        "failed to upload chunk to storage server",
-				"chunk_number": i,
-				"server_id":    serverID,
-				"details":      uploadErr.Error(),
+				"error":   "failed to upload chunks",
+				"details": err.Error(),
 			})
 			return
 		}
-
-		// Create chunk record
-		chunk := &storage.Chunk{
-			ChunkID:         chunkID,
-			FileID:          fileRecord.FileID,
-			ChunkNumber:     i,
-			StorageServerID: serverUUID,
-			ChunkSize:       int64(len(chunkData)),
-			ChunkHash:       chunkHashStr,
-			Status:          "completed",
-		}
-
-		chunks = append(chunks, chunk)
+		chunks = uploaded
 	}
 
 	// Save all chunks to database in batch
-	if err := gw.Storage.CreateChunksBatch(ctx, chunks); err != nil {
-		gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
+	if err := gw.MetaCache.CreateChunksBatch(ctx, chunks); err != nil {
+		logger.Error(err, "failed to save chunk records")
+		gw.MetaCache.UpdateFileStatus(ctx, fileRecord.FileID, "failed")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to save chunk records",
 			"details": err.Error(),
@@ -230,7 +206,8 @@ func (gw *APIGateway) UploadFile(c *gin.Context) {
 	fileChecksum := hex.EncodeToString(fileHasher.Sum(nil))
 
 	// Update file status to completed
-	if err := gw.Storage.UpdateFileStatus(ctx, fileRecord.FileID, "completed"); err != nil {
+	if err := gw.MetaCache.UpdateFileStatus(ctx, fileRecord.FileID, "completed"); err != nil {
+		logger.Error(err, "failed to update file status to completed")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to update file status",
 			"details": err.Error(),
@@ -238,7 +215,7 @@ func (gw *APIGateway) UploadFile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"file_id":      fileRecord.FileID,
 		"filename":     fileRecord.Filename,
 		"size":         fileRecord.TotalSize,
@@ -246,7 +223,135 @@ func (gw *APIGateway) UploadFile(c *gin.Context) {
 		"checksum":     fileChecksum,
 		"chunks":       len(chunks),
 		"status":       "completed",
-	})
+	}
+	if useCDC {
+		response["chunking_mode"] = "cdc"
+		response["deduplicated_chunks"] = deduplicatedChunks
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// uploadOneChunk places a single chunk of a file, either erasure-coded or as
+// a single replica via consistent hashing, and returns the storage.Chunk
+// record to be batched into CreateChunksBatch by the caller. Shared by both
+// UploadFile's fixed-count boundary loop and its content-defined-chunking
+// path so the two only differ in how they cut chunkData out of the source
+// file.
+//
+// If the single-replica path's hash-ring-assigned server is unreachable and
+// gw.Spool is configured, the chunk is parked there for SpoolReplayer
+// instead of failing the upload; the returned record still names the
+// originally assigned server until the replayer delivers it and corrects
+// it.
+func (gw *APIGateway) uploadOneChunk(ctx context.Context, fileID uuid.UUID, chunkNumber int, chunkData []byte, chunkHashStr string) (*storage.Chunk, error) {
+	if gw.ECConfig.Enabled {
+		chunk, shardRecords, err := gw.uploadChunkErasureCoded(ctx, fileID, chunkNumber, chunkData, chunkHashStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload erasure-coded chunk: %w", err)
+		}
+
+		if err := gw.Storage.CreateChunkShardsBatch(ctx, shardRecords); err != nil {
+			return nil, fmt.Errorf("failed to save shard placement: %w", err)
+		}
+
+		return chunk, nil
+	}
+
+	// Generate chunk ID
+	chunkID := uuid.New()
+
+	// Determine storage server. When the configured placement backend is
+	// consistent hashing (the default), use its bounded-load variant so a
+	// hot chunk key can't push one server arbitrarily far past its fair
+	// 1/N share - see hasher.HashRing.GetServerBoundedTracked. Other
+	// backends (e.g. hasher.RendezvousRing) don't support bounded-load
+	// placement and fall back to plain GetServer.
+	var serverID string
+	var err error
+	if boundedRing, ok := gw.HashRing.(boundedLoadRing); ok {
+		serverID, err = boundedRing.GetServerBoundedTracked(chunkID.String(), BoundedLoadCap)
+		if err == nil {
+			boundedRing.IncLoad(serverID, 1)
+		}
+	} else {
+		serverID, err = gw.HashRing.GetServer(chunkID.String())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no storage servers available (%d known): %w", len(gw.HashRing.GetAllServers()), err)
+	}
+
+	serverUUID, err := uuid.Parse(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server ID %q: %w", serverID, err)
+	}
+
+	// Get storage client with retry on connection failure
+	var client pb.StorageServiceClient
+	var clientErr error
+
+	// Try to get client, with one retry if connection is broken
+	for attempt := 0; attempt < 2; attempt++ {
+		client, clientErr = gw.getStorageClient(serverUUID)
+		if clientErr == nil {
+			break
+		}
+
+		if attempt == 0 {
+			// First attempt failed, wait a bit and try again
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	var uploadErr error
+	if clientErr != nil {
+		uploadErr = fmt.Errorf("failed to get storage client for server %s: %w", serverID, clientErr)
+	} else {
+		// Get circuit breaker for this server
+		cb := gw.getCircuitBreaker(serverUUID)
+
+		// Upload chunk to storage server with retry, circuit breaker, and
+		// adaptive concurrency limiting
+		uploadErr = gw.withBandwidthLimit(ctx, serverUUID, func() error {
+			return cb.Execute(func() error {
+				return gw.UploadChunkToServerWithRetry(ctx, client, chunkID.String(), chunkData, chunkHashStr)
+			})
+		})
+	}
+
+	if uploadErr != nil {
+		if gw.Spool == nil {
+			return nil, fmt.Errorf("failed to upload chunk to storage server %s: %w", serverID, uploadErr)
+		}
+
+		// The server we hashed to is unreachable or rejected the chunk;
+		// park it for SpoolReplayer instead of failing the whole upload.
+		// The chunk record below still names serverUUID as its owner -
+		// SpoolReplayer repoints it via UpdateChunkStorageServerForReplay
+		// if it ends up delivering elsewhere.
+		spoolErr := gw.Spool.Enqueue(spool.Header{
+			ChunkID:        chunkID,
+			FileID:         fileID,
+			ChunkNumber:    chunkNumber,
+			TargetServerID: serverUUID,
+			SHA256:         chunkHashStr,
+			CreatedAt:      time.Now(),
+		}, chunkData)
+		if spoolErr != nil {
+			return nil, fmt.Errorf("failed to upload chunk to storage server %s (%v) and failed to spool it: %w", serverID, uploadErr, spoolErr)
+		}
+	}
+
+	// Create chunk record
+	return &storage.Chunk{
+		ChunkID:         chunkID,
+		FileID:          fileID,
+		ChunkNumber:     chunkNumber,
+		StorageServerID: serverUUID,
+		ChunkSize:       int64(len(chunkData)),
+		ChunkHash:       chunkHashStr,
+		Status:          "completed",
+	}, nil
 }
 
 // UploadChunkToServer uploads a chunk to a storage server via gRPC
@@ -292,7 +397,7 @@ func (gw *APIGateway) UploadChunkToServer(ctx context.Context, client pb.Storage
 
 // UploadChunkToServerWithRetry uploads a chunk with retry logic
 func (gw *APIGateway) UploadChunkToServerWithRetry(ctx context.Context, client pb.StorageServiceClient, chunkID string, data []byte, checksum string) error {
-	return retry.Do(ctx, gw.RetryConfig, func() error {
-		return gw.UploadChunkToServer(ctx, client, chunkID, data, checksum)
+	return retry.Do(ctx, gw.RetryConfig, func(attemptCtx context.Context) error {
+		return gw.UploadChunkToServer(attemptCtx, client, chunkID, data, checksum)
 	})
 }
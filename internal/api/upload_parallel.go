@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"runtime"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/chunker"
+	"github.com/s3storage/internal/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultUploadConcurrency returns the default number of chunks UploadFile
+// uploads in parallel when the gateway isn't configured with an explicit
+// UploadConcurrency: one worker per chunk, capped at the number of CPUs so
+// a small machine doesn't oversubscribe itself on a many-chunk upload.
+func DefaultUploadConcurrency(numChunks int) int {
+	n := runtime.NumCPU()
+	if numChunks < n {
+		n = numChunks
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// chunkReadJob is one chunk's data and precomputed hash, produced by
+// uploadChunksParallel's reader goroutine and consumed by its worker pool.
+type chunkReadJob struct {
+	index int
+	data  []byte
+	hash  string
+}
+
+// uploadChunksParallel reads every boundary's bytes from file strictly in
+// order on a single goroutine - so fileHasher's checksum stays deterministic
+// regardless of how uploads are scheduled - then hands each chunk to a
+// bounded worker pool that uploads it to its storage server concurrently.
+// The channel between them is bounded at queueDepth jobs so memory stays
+// around concurrency*chunkSize instead of buffering the whole file ahead of
+// slow uploads. The first non-retryable upload (or read) failure cancels
+// every other in-flight upload and stops the reader via errgroup.
+func (gw *APIGateway) uploadChunksParallel(ctx context.Context, fileID uuid.UUID, file io.Reader, boundaries []chunker.ChunkInfo, fileHasher hash.Hash) ([]*storage.Chunk, error) {
+	concurrency := gw.uploadConcurrency(len(boundaries))
+	queueDepth := gw.uploadQueueDepth(concurrency)
+
+	g, gctx := errgroup.WithContext(ctx)
+	jobs := make(chan chunkReadJob, queueDepth)
+
+	g.Go(func() error {
+		defer close(jobs)
+		for _, boundary := range boundaries {
+			chunkData := make([]byte, boundary.Size)
+			n, err := io.ReadFull(file, chunkData)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return fmt.Errorf("failed to read chunk %d data: %w", boundary.Number, err)
+			}
+			chunkData = chunkData[:n]
+
+			fileHasher.Write(chunkData)
+			chunkHash := sha256.Sum256(chunkData)
+
+			job := chunkReadJob{index: boundary.Number, data: chunkData, hash: hex.EncodeToString(chunkHash[:])}
+			select {
+			case jobs <- job:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		}
+		return nil
+	})
+
+	chunks := make([]*storage.Chunk, len(boundaries))
+	for i := 0; i < concurrency; i++ {
+		g.Go(func() error {
+			for job := range jobs {
+				chunk, err := gw.uploadOneChunk(gctx, fileID, job.index, job.data, job.hash)
+				if err != nil {
+					return fmt.Errorf("failed to upload chunk %d: %w", job.index, err)
+				}
+				chunks[job.index] = chunk
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+// uploadConcurrency resolves the worker pool size for an upload of
+// numChunks chunks: gw.UploadConcurrency if set (capped at numChunks so
+// small uploads don't spin up idle workers), otherwise
+// DefaultUploadConcurrency.
+func (gw *APIGateway) uploadConcurrency(numChunks int) int {
+	if gw.UploadConcurrency > 0 {
+		if gw.UploadConcurrency < numChunks {
+			return gw.UploadConcurrency
+		}
+		return numChunks
+	}
+	return DefaultUploadConcurrency(numChunks)
+}
+
+// uploadQueueDepth resolves the bounded channel depth between the reader
+// and the worker pool: gw.UploadQueueDepth if set, otherwise one slot per
+// worker.
+func (gw *APIGateway) uploadQueueDepth(concurrency int) int {
+	if gw.UploadQueueDepth > 0 {
+		return gw.UploadQueueDepth
+	}
+	return concurrency
+}
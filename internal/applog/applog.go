@@ -0,0 +1,35 @@
+// Package applog builds the structured logr.Logger every binary in this
+// module wires up at startup. It emits one JSON object per line - so
+// fields like session_id, file_id, chunk_id, server_id, and attempt are
+// filterable and shippable to Loki/ELK instead of living inside a
+// printf-style string - via go-logr's funcr backend, the same logr.Logger
+// type internal/storage's pgx tracing (see storage.NewTraceLog) already
+// expects.
+package applog
+
+import (
+	"log"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+// New builds a JSON logr.Logger writing to stderr. level selects
+// verbosity following logr's V(n) convention, where a higher number is
+// more verbose: "debug" enables V(1) (and above) Info logs in addition to
+// the default V(0); anything else (including "") only logs V(0).
+func New(level string) logr.Logger {
+	verbosity := 0
+	if level == "debug" {
+		verbosity = 1
+	}
+
+	stderr := log.New(os.Stderr, "", 0)
+	return funcr.NewJSON(func(obj string) {
+		stderr.Print(obj)
+	}, funcr.Options{
+		LogTimestamp: true,
+		Verbosity:    verbosity,
+	})
+}
@@ -0,0 +1,106 @@
+// Package backoff implements the gRPC connection-backoff recipe
+// (https://github.com/grpc/grpc/blob/master/doc/connection-backoff.md) as a
+// small, per-peer state machine: delay = min(MaxDelay,
+// BaseDelay*Factor^consecutiveFailures), randomized by uniform jitter of
+// +/- Jitter*delay, so repeatedly failing peers are retried with
+// increasing spacing instead of hammered on a fixed interval.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Config controls a Backoff's delay curve.
+type Config struct {
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// Factor is the multiplier applied per consecutive failure.
+	Factor float64
+	// Jitter is the fraction of the computed delay to randomize by, +/-.
+	Jitter float64
+	// MaxDelay caps the computed delay, however many consecutive failures
+	// have accumulated.
+	MaxDelay time.Duration
+}
+
+// DefaultConfig returns the gRPC connection-backoff recipe's default curve.
+func DefaultConfig() *Config {
+	return &Config{
+		BaseDelay: 1 * time.Second,
+		Factor:    1.6,
+		Jitter:    0.2,
+		MaxDelay:  120 * time.Second,
+	}
+}
+
+// Backoff tracks one peer's consecutive-failure count and the time its next
+// connection attempt is allowed. It's safe for concurrent use.
+type Backoff struct {
+	config *Config
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	nextAttempt         time.Time
+}
+
+// New creates a Backoff using config, or DefaultConfig if config is nil.
+func New(config *Config) *Backoff {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Backoff{config: config}
+}
+
+// Ready reports whether enough time has passed since the last recorded
+// Failure that a new connection attempt should be allowed.
+func (b *Backoff) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !time.Now().Before(b.nextAttempt)
+}
+
+// NextAttempt returns the time Ready will next return true, the zero time
+// if no Failure has been recorded (or the peer has since Succeeded).
+func (b *Backoff) NextAttempt() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextAttempt
+}
+
+// Failure records a failed connection attempt, advances the consecutive
+// failure count, and schedules NextAttempt using the backoff curve. It
+// returns the delay that was scheduled.
+func (b *Backoff) Failure() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delay := float64(b.config.BaseDelay) * math.Pow(b.config.Factor, float64(b.consecutiveFailures))
+	if max := float64(b.config.MaxDelay); delay > max {
+		delay = max
+	}
+	if b.config.Jitter > 0 {
+		jitter := delay * b.config.Jitter
+		delay += jitter*2*rand.Float64() - jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	b.consecutiveFailures++
+	d := time.Duration(delay)
+	b.nextAttempt = time.Now().Add(d)
+	return d
+}
+
+// Success resets the consecutive failure count and clears NextAttempt,
+// called on a peer's connectivity.Ready transition so its next failure
+// starts backing off from BaseDelay again.
+func (b *Backoff) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consecutiveFailures = 0
+	b.nextAttempt = time.Time{}
+}
@@ -0,0 +1,401 @@
+// Package bulkdelete runs async bulk file-deletion jobs: given an explicit
+// list of file IDs or a path prefix, it deletes every matching file's chunks
+// off their storage servers and then the file rows themselves, tracking
+// progress in delete_jobs/delete_job_files so a crashed gateway can resume
+// exactly where it left off.
+package bulkdelete
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/storage"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultBatchSize is how many pending files are paged and dispatched
+	// per round.
+	DefaultBatchSize = 50
+	// DefaultFileConcurrency bounds how many files are processed in
+	// parallel within a batch.
+	DefaultFileConcurrency = 8
+	// DefaultPerServerConcurrency bounds how many DeleteChunk calls are
+	// in flight against any one storage server at a time, regardless of how
+	// many files are being processed concurrently. This is the knob that
+	// keeps a prefix heavily colocated on one server from hammering it,
+	// which plain per-file concurrency wouldn't prevent.
+	DefaultPerServerConcurrency = 4
+	// chunkDeleteTimeout bounds each chunk's DeleteChunk round trip,
+	// including retries.
+	chunkDeleteTimeout = 30 * time.Second
+)
+
+// boundedLoadRing is satisfied by hasher.HashRing, mirroring
+// internal/api/upload.go's interface of the same name: a deleted chunk
+// should give back the bounded-load share it took on placement, but only
+// backends that actually track load need to implement this.
+type boundedLoadRing interface {
+	DecLoad(serverID string, delta int64)
+}
+
+// Manager runs and tracks bulk delete jobs, one goroutine per active job.
+type Manager struct {
+	storage         *storage.PostgresStorage
+	storageClients  map[uuid.UUID]*grpc.ClientConn
+	clientsMu       *sync.RWMutex
+	hashRing        hasher.PlacementRing
+	batchSize       int
+	fileConcurrency int
+	retryConfig     *retry.RetryConfig
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*runningJob
+
+	serverSemsMu sync.Mutex
+	serverSems   map[uuid.UUID]chan struct{}
+}
+
+// runningJob is the in-memory control handle for a job's background
+// goroutine; it doesn't survive a gateway restart, so ResumeAll knows how to
+// re-launch a worker for every job still "running" in the database.
+type runningJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager creates a Manager with the default batch size, concurrency, and
+// retry policy. hashRing is the same in-memory placement ring the gateway
+// routes uploads through (gw.HashRing); it may be nil, in which case bounded
+// load is simply not adjusted on delete.
+func NewManager(
+	store *storage.PostgresStorage,
+	storageClients map[uuid.UUID]*grpc.ClientConn,
+	clientsMu *sync.RWMutex,
+	hashRing hasher.PlacementRing,
+) *Manager {
+	return &Manager{
+		storage:         store,
+		storageClients:  storageClients,
+		clientsMu:       clientsMu,
+		hashRing:        hashRing,
+		batchSize:       DefaultBatchSize,
+		fileConcurrency: DefaultFileConcurrency,
+		retryConfig: &retry.RetryConfig{
+			MaxRetries:     retry.DefaultMaxRetries,
+			InitialBackoff: retry.DefaultInitialBackoff,
+			MaxBackoff:     retry.DefaultMaxBackoff,
+		},
+		jobs:       make(map[uuid.UUID]*runningJob),
+		serverSems: make(map[uuid.UUID]chan struct{}),
+	}
+}
+
+// JobStatus augments a persisted DeleteJob with progress derived at read
+// time.
+type JobStatus struct {
+	*storage.DeleteJob
+	PercentComplete       float64        `json:"percent_complete"`
+	ThroughputBytesPerSec float64        `json:"throughput_bytes_per_sec"`
+	ETA                   *time.Duration `json:"eta,omitempty"`
+}
+
+// JobStatusFor wraps a raw DeleteJob with its derived progress metrics.
+func JobStatusFor(job *storage.DeleteJob) *JobStatus {
+	status := &JobStatus{DeleteJob: job}
+
+	if job.FilesTotal > 0 {
+		status.PercentComplete = float64(job.FilesDeleted+job.FilesFailed) / float64(job.FilesTotal) * 100
+	}
+
+	elapsed := time.Since(job.StartedAt)
+	if job.CompletedAt != nil {
+		elapsed = job.CompletedAt.Sub(job.StartedAt)
+	}
+	if elapsed <= 0 {
+		return status
+	}
+	status.ThroughputBytesPerSec = float64(job.BytesReclaimed) / elapsed.Seconds()
+
+	if job.Status == "running" && status.ThroughputBytesPerSec > 0 {
+		remaining := job.BytesTotal - job.BytesReclaimed
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta := time.Duration(float64(remaining)/status.ThroughputBytesPerSec) * time.Second
+		status.ETA = &eta
+	}
+
+	return status
+}
+
+// StartBulkDelete creates and launches a job deleting exactly fileIDs.
+func (m *Manager) StartBulkDelete(ctx context.Context, fileIDs []uuid.UUID) (*storage.DeleteJob, error) {
+	job := &storage.DeleteJob{}
+	if err := m.storage.CreateDeleteJob(ctx, job, fileIDs); err != nil {
+		return nil, fmt.Errorf("failed to create bulk delete job: %w", err)
+	}
+
+	m.launch(job)
+	return job, nil
+}
+
+// StartBulkDeleteByPrefix snapshots every file under prefix and launches a
+// job deleting them.
+func (m *Manager) StartBulkDeleteByPrefix(ctx context.Context, prefix string) (*storage.DeleteJob, error) {
+	fileIDs, err := m.storage.CollectFileIDsByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect files under prefix %q: %w", prefix, err)
+	}
+
+	job := &storage.DeleteJob{Prefix: &prefix}
+	if err := m.storage.CreateDeleteJob(ctx, job, fileIDs); err != nil {
+		return nil, fmt.Errorf("failed to create bulk delete job for prefix %q: %w", prefix, err)
+	}
+
+	m.launch(job)
+	return job, nil
+}
+
+// GetStatus returns jobID's persisted state plus derived progress metrics.
+func (m *Manager) GetStatus(ctx context.Context, jobID uuid.UUID) (*JobStatus, error) {
+	job, err := m.storage.GetDeleteJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return JobStatusFor(job), nil
+}
+
+// Cancel stops jobID's worker (if running in this process) and marks it
+// cancelled. Files already deleted stay deleted.
+func (m *Manager) Cancel(ctx context.Context, jobID uuid.UUID) error {
+	m.stopLocal(jobID)
+	return m.storage.SetDeleteJobStatus(ctx, jobID, "cancelled")
+}
+
+// ResumeAll re-launches a worker for every job still "running" in the
+// database, for a gateway that just restarted.
+func (m *Manager) ResumeAll(ctx context.Context) error {
+	jobs, err := m.storage.ListRunningDeleteJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list running bulk delete jobs to resume: %w", err)
+	}
+
+	for _, job := range jobs {
+		log.Printf("Bulk delete %s: resuming after restart (%d/%d files done)", job.JobID, job.FilesDeleted+job.FilesFailed, job.FilesTotal)
+		m.launch(job)
+	}
+
+	return nil
+}
+
+// StopAll cancels every in-flight job's worker without changing its database
+// status, so ResumeAll picks it back up next time the gateway starts. Call
+// this during graceful shutdown, before closing storage client connections.
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	jobs := make([]*runningJob, 0, len(m.jobs))
+	for _, rj := range m.jobs {
+		jobs = append(jobs, rj)
+	}
+	m.mu.Unlock()
+
+	for _, rj := range jobs {
+		rj.cancel()
+		<-rj.done
+	}
+}
+
+func (m *Manager) stopLocal(jobID uuid.UUID) {
+	m.mu.Lock()
+	rj, ok := m.jobs[jobID]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	rj.cancel()
+	<-rj.done
+}
+
+func (m *Manager) launch(job *storage.DeleteJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rj := &runningJob{cancel: cancel, done: make(chan struct{})}
+
+	m.mu.Lock()
+	m.jobs[job.JobID] = rj
+	m.mu.Unlock()
+
+	go func() {
+		defer close(rj.done)
+		defer func() {
+			m.mu.Lock()
+			delete(m.jobs, job.JobID)
+			m.mu.Unlock()
+		}()
+		m.run(ctx, job)
+	}()
+}
+
+// run pages through job's pending files in batches, deleting up to
+// m.fileConcurrency files in parallel per batch, until a page comes back
+// empty (job done) or ctx is cancelled (Cancel was called).
+func (m *Manager) run(ctx context.Context, job *storage.DeleteJob) {
+	log.Printf("Bulk delete %s: deleting %d files (%d bytes)", job.JobID, job.FilesTotal, job.BytesTotal)
+
+	for {
+		if ctx.Err() != nil {
+			log.Printf("Bulk delete %s: stopped: %v", job.JobID, ctx.Err())
+			return
+		}
+
+		fileIDs, err := m.storage.GetPendingDeleteJobFiles(ctx, job.JobID, m.batchSize)
+		if err != nil {
+			log.Printf("Bulk delete %s: failed to page pending files: %v", job.JobID, err)
+			if serr := m.storage.SetDeleteJobStatus(context.Background(), job.JobID, "failed"); serr != nil {
+				log.Printf("Bulk delete %s: failed to mark job failed: %v", job.JobID, serr)
+			}
+			return
+		}
+		if len(fileIDs) == 0 {
+			if err := m.storage.SetDeleteJobStatus(context.Background(), job.JobID, "completed"); err != nil {
+				log.Printf("Bulk delete %s: failed to mark job completed: %v", job.JobID, err)
+			}
+			log.Printf("Bulk delete %s: done", job.JobID)
+			return
+		}
+
+		sem := make(chan struct{}, m.fileConcurrency)
+		var wg sync.WaitGroup
+		for _, fileID := range fileIDs {
+			fileID := fileID
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				m.deleteOneFile(ctx, job.JobID, fileID)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// deleteOneFile deletes every chunk of fileID (bounded per storage server,
+// with retry), then the file row itself, and records the outcome against
+// job.
+func (m *Manager) deleteOneFile(ctx context.Context, jobID, fileID uuid.UUID) {
+	file, err := m.storage.GetFileByID(ctx, fileID)
+	if err == storage.ErrNotFound {
+		// Already gone (e.g. deleted out of band); nothing left to do.
+		m.recordResult(jobID, fileID, false, 0, 0, 0)
+		return
+	}
+	if err != nil {
+		log.Printf("Bulk delete %s: failed to load file %s: %v", jobID, fileID, err)
+		m.recordResult(jobID, fileID, true, 0, 0, 0)
+		return
+	}
+
+	var chunksDeleted, chunksFailed int32
+	var bytesReclaimed int64
+	var wg sync.WaitGroup
+	for _, chunk := range file.Chunks {
+		chunk := chunk
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem := m.serverSem(chunk.StorageServerID)
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if m.deleteChunk(ctx, jobID, chunk) {
+				atomic.AddInt32(&chunksDeleted, 1)
+				atomic.AddInt64(&bytesReclaimed, chunk.ChunkSize)
+			} else {
+				atomic.AddInt32(&chunksFailed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := chunksFailed > 0
+	if err := m.storage.DeleteFileByID(context.Background(), fileID); err != nil && err != storage.ErrNotFound {
+		log.Printf("Bulk delete %s: failed to delete file row %s: %v", jobID, fileID, err)
+		failed = true
+	}
+
+	m.recordResult(jobID, fileID, failed, int(chunksDeleted), int(chunksFailed), bytesReclaimed)
+}
+
+// deleteChunk issues DeleteChunk against chunk's storage server with retry,
+// recording it as dangling if every attempt fails.
+func (m *Manager) deleteChunk(ctx context.Context, jobID uuid.UUID, chunk *storage.Chunk) bool {
+	client, err := m.client(chunk.StorageServerID)
+	if err != nil {
+		m.recordDangling(jobID, chunk, err)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, chunkDeleteTimeout)
+	defer cancel()
+
+	err = retry.Do(ctx, m.retryConfig, func(attemptCtx context.Context) error {
+		_, err := client.DeleteChunk(attemptCtx, &pb.DeleteChunkRequest{ChunkId: chunk.ChunkID.String()})
+		return err
+	})
+	if err != nil {
+		m.recordDangling(jobID, chunk, err)
+		return false
+	}
+
+	if boundedRing, ok := m.hashRing.(boundedLoadRing); ok {
+		boundedRing.DecLoad(chunk.StorageServerID.String(), 1)
+	}
+
+	return true
+}
+
+func (m *Manager) recordDangling(jobID uuid.UUID, chunk *storage.Chunk, cause error) {
+	log.Printf("Bulk delete %s: giving up on chunk %s (server %s): %v", jobID, chunk.ChunkID, chunk.StorageServerID, cause)
+	if err := m.storage.RecordDanglingChunk(context.Background(), chunk.ChunkID, chunk.StorageServerID, jobID, cause.Error()); err != nil {
+		log.Printf("Bulk delete %s: failed to record dangling chunk %s: %v", jobID, chunk.ChunkID, err)
+	}
+}
+
+func (m *Manager) recordResult(jobID, fileID uuid.UUID, failed bool, chunksDeleted, chunksFailed int, bytesReclaimed int64) {
+	if err := m.storage.RecordDeleteJobFileResult(context.Background(), jobID, fileID, failed, chunksDeleted, chunksFailed, bytesReclaimed); err != nil {
+		log.Printf("Bulk delete %s: failed to record result for file %s: %v", jobID, fileID, err)
+	}
+}
+
+func (m *Manager) client(serverID uuid.UUID) (pb.StorageServiceClient, error) {
+	m.clientsMu.RLock()
+	conn, ok := m.storageClients[serverID]
+	m.clientsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage server %s not available", serverID)
+	}
+	return pb.NewStorageServiceClient(conn), nil
+}
+
+// serverSem returns (creating if needed) the semaphore bounding concurrent
+// DeleteChunk calls against serverID.
+func (m *Manager) serverSem(serverID uuid.UUID) chan struct{} {
+	m.serverSemsMu.Lock()
+	defer m.serverSemsMu.Unlock()
+
+	sem, ok := m.serverSems[serverID]
+	if !ok {
+		sem = make(chan struct{}, DefaultPerServerConcurrency)
+		m.serverSems[serverID] = sem
+	}
+	return sem
+}
@@ -0,0 +1,222 @@
+// Package bwmeter tracks per-storage-server bandwidth (bytes in/out,
+// in-flight streams, EWMA throughput) via a grpc.StatsHandler attached to
+// each StorageServiceClient connection, and uses those signals to drive an
+// adaptive, AIMD-style concurrency limiter per server - see
+// ConcurrencyLimiter. Without this, a single slow or saturated storage
+// server can starve goroutines across the gateway that are all waiting on
+// it, while the rest of the fleet sits idle.
+package bwmeter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// ewmaAlpha weights each Sample call's instantaneous throughput against the
+// running average; 0.3 settles within a handful of samples without being
+// too noisy for a single spiky RPC to swing the reported rate.
+const ewmaAlpha = 0.3
+
+// Snapshot is a point-in-time read of one storage server's bandwidth and
+// concurrency state, returned by Meter.Snapshot for /metrics and any other
+// caller that wants the raw numbers.
+type Snapshot struct {
+	BytesIn                  uint64
+	BytesOut                 uint64
+	InFlightStreams          int64
+	ThroughputInBytesPerSec  float64
+	ThroughputOutBytesPerSec float64
+	ConcurrencyLimit         float64
+	ConcurrencyInFlight      int
+}
+
+type serverMeter struct {
+	bytesIn  uint64 // atomic
+	bytesOut uint64 // atomic
+	inFlight int64  // atomic; RPC streams currently open on this connection
+
+	sampleMu     sync.Mutex
+	lastSampleAt time.Time
+	lastBytesIn  uint64
+	lastBytesOut uint64
+	ewmaIn       float64
+	ewmaOut      float64
+
+	limiter *ConcurrencyLimiter
+}
+
+// Meter owns one serverMeter per storage server, keyed by server ID
+// (uuid.UUID.String()), created lazily on first use.
+type Meter struct {
+	mu      sync.RWMutex
+	servers map[string]*serverMeter
+
+	limiterFloor   float64
+	limiterCeiling float64
+}
+
+// New creates a Meter whose per-server ConcurrencyLimiters start at
+// limiterFloor and never grow past limiterCeiling.
+func New(limiterFloor, limiterCeiling float64) *Meter {
+	return &Meter{
+		servers:        make(map[string]*serverMeter),
+		limiterFloor:   limiterFloor,
+		limiterCeiling: limiterCeiling,
+	}
+}
+
+func (m *Meter) serverFor(serverID string) *serverMeter {
+	m.mu.RLock()
+	sm, ok := m.servers[serverID]
+	m.mu.RUnlock()
+	if ok {
+		return sm
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sm, ok := m.servers[serverID]; ok {
+		return sm
+	}
+	sm = &serverMeter{limiter: NewConcurrencyLimiter(m.limiterFloor, m.limiterCeiling)}
+	m.servers[serverID] = sm
+	return sm
+}
+
+// StatsHandler returns a grpc.StatsHandler to pass as a grpc.WithStatsHandler
+// dial option for serverID's connection, attributing every byte and stream
+// it reports to that server.
+func (m *Meter) StatsHandler(serverID string) stats.Handler {
+	return &connStatsHandler{sm: m.serverFor(serverID)}
+}
+
+// Limiter returns serverID's adaptive concurrency limiter, creating one
+// seeded at the configured floor on first use.
+func (m *Meter) Limiter(serverID string) *ConcurrencyLimiter {
+	return m.serverFor(serverID).limiter
+}
+
+// Snapshot returns serverID's current bandwidth and concurrency state. A
+// server that has never had a StatsHandler or Limiter created for it
+// returns a zero Snapshot.
+func (m *Meter) Snapshot(serverID string) Snapshot {
+	m.mu.RLock()
+	sm, ok := m.servers[serverID]
+	m.mu.RUnlock()
+	if !ok {
+		return Snapshot{}
+	}
+	return sm.snapshot()
+}
+
+// ServerIDs returns the IDs of every server with meter state, for a caller
+// (e.g. the /metrics handler) iterating to export a Snapshot per server.
+func (m *Meter) ServerIDs() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.servers))
+	for id := range m.servers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Sample refreshes every server's EWMA throughput from the byte counters
+// accumulated since the previous call. Call this periodically (e.g.
+// alongside APIGateway.UpdateTelemetryGauges) - the first call after a
+// server's meter is created only seeds the baseline and reports zero
+// throughput.
+func (m *Meter) Sample() {
+	m.mu.RLock()
+	servers := make([]*serverMeter, 0, len(m.servers))
+	for _, sm := range m.servers {
+		servers = append(servers, sm)
+	}
+	m.mu.RUnlock()
+
+	now := time.Now()
+	for _, sm := range servers {
+		sm.sample(now)
+	}
+}
+
+func (sm *serverMeter) sample(now time.Time) {
+	sm.sampleMu.Lock()
+	defer sm.sampleMu.Unlock()
+
+	bytesIn := atomic.LoadUint64(&sm.bytesIn)
+	bytesOut := atomic.LoadUint64(&sm.bytesOut)
+
+	if sm.lastSampleAt.IsZero() {
+		sm.lastSampleAt = now
+		sm.lastBytesIn = bytesIn
+		sm.lastBytesOut = bytesOut
+		return
+	}
+
+	elapsed := now.Sub(sm.lastSampleAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	rateIn := float64(bytesIn-sm.lastBytesIn) / elapsed
+	rateOut := float64(bytesOut-sm.lastBytesOut) / elapsed
+	sm.ewmaIn = ewmaAlpha*rateIn + (1-ewmaAlpha)*sm.ewmaIn
+	sm.ewmaOut = ewmaAlpha*rateOut + (1-ewmaAlpha)*sm.ewmaOut
+
+	sm.lastSampleAt = now
+	sm.lastBytesIn = bytesIn
+	sm.lastBytesOut = bytesOut
+}
+
+func (sm *serverMeter) snapshot() Snapshot {
+	sm.sampleMu.Lock()
+	ewmaIn, ewmaOut := sm.ewmaIn, sm.ewmaOut
+	sm.sampleMu.Unlock()
+
+	limit, inFlight := sm.limiter.state()
+
+	return Snapshot{
+		BytesIn:                  atomic.LoadUint64(&sm.bytesIn),
+		BytesOut:                 atomic.LoadUint64(&sm.bytesOut),
+		InFlightStreams:          atomic.LoadInt64(&sm.inFlight),
+		ThroughputInBytesPerSec:  ewmaIn,
+		ThroughputOutBytesPerSec: ewmaOut,
+		ConcurrencyLimit:         limit,
+		ConcurrencyInFlight:      inFlight,
+	}
+}
+
+// connStatsHandler implements google.golang.org/grpc/stats.Handler,
+// attributing one connection's RPC byte counts and in-flight stream count
+// to a single serverMeter.
+type connStatsHandler struct {
+	sm *serverMeter
+}
+
+func (h *connStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *connStatsHandler) HandleRPC(_ context.Context, s stats.RPCStats) {
+	switch v := s.(type) {
+	case *stats.Begin:
+		atomic.AddInt64(&h.sm.inFlight, 1)
+	case *stats.End:
+		atomic.AddInt64(&h.sm.inFlight, -1)
+	case *stats.InPayload:
+		atomic.AddUint64(&h.sm.bytesIn, uint64(v.Length))
+	case *stats.OutPayload:
+		atomic.AddUint64(&h.sm.bytesOut, uint64(v.Length))
+	}
+}
+
+func (h *connStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *connStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,119 @@
+package bwmeter
+
+import (
+	"context"
+	"sync"
+)
+
+// Outcome classifies how a caller's unit of work behind Acquire/Release
+// went, driving the AIMD adjustment: Success nudges the limit up
+// (additive increase), Overloaded nudges it sharply down (multiplicative
+// decrease), and Other leaves the limit unchanged.
+type Outcome int
+
+const (
+	// Success means the work completed normally; the limit increases
+	// additively, probing for more available capacity.
+	Success Outcome = iota
+	// Overloaded means the work failed in a way attributable to the
+	// server being saturated (e.g. it tripped the circuit breaker, or
+	// timed out); the limit decreases multiplicatively, backing off hard.
+	Overloaded
+	// Other means the work failed for a reason unrelated to server load
+	// (e.g. caller cancellation); the limit is left unchanged.
+	Other
+)
+
+const (
+	additiveIncrease       = 1.0
+	multiplicativeDecrease = 0.5
+)
+
+// ConcurrencyLimiter caps the number of in-flight chunk transfers to one
+// storage server using an AIMD scheme: each Release(Success) grows the
+// limit by a fixed amount, each Release(Overloaded) halves it, so the
+// gateway keeps probing for more throughput until the server pushes back
+// and then backs off fast - the same shape TCP congestion control uses,
+// applied to gRPC stream concurrency instead of packet rate.
+type ConcurrencyLimiter struct {
+	floor   float64
+	ceiling float64
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	notify   chan struct{} // closed and replaced whenever a waiter should recheck
+}
+
+// NewConcurrencyLimiter creates a limiter starting at floor, never growing
+// past ceiling and never shrinking below floor.
+func NewConcurrencyLimiter(floor, ceiling float64) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		floor:   floor,
+		ceiling: ceiling,
+		limit:   floor,
+		notify:  make(chan struct{}),
+	}
+}
+
+// Acquire blocks until a slot is available under the current limit, or ctx
+// is done. Every granted Acquire must be paired with exactly one Release.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if float64(l.inFlight) < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		wait := l.notify
+		l.mu.Unlock()
+
+		select {
+		case <-wait:
+			// Limit grew or a slot freed up; loop around and recheck.
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release returns the slot acquired by a prior Acquire and adjusts the
+// limit according to outcome.
+func (l *ConcurrencyLimiter) Release(outcome Outcome) {
+	l.mu.Lock()
+	l.inFlight--
+
+	switch outcome {
+	case Success:
+		l.limit += additiveIncrease
+		if l.limit > l.ceiling {
+			l.limit = l.ceiling
+		}
+	case Overloaded:
+		l.limit *= multiplicativeDecrease
+		if l.limit < l.floor {
+			l.limit = l.floor
+		}
+	}
+
+	old := l.notify
+	l.notify = make(chan struct{})
+	l.mu.Unlock()
+
+	close(old)
+}
+
+// Limit returns the current concurrency cap.
+func (l *ConcurrencyLimiter) Limit() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// state returns both the current limit and in-flight count for Snapshot.
+func (l *ConcurrencyLimiter) state() (float64, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit, l.inFlight
+}
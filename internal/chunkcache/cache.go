@@ -0,0 +1,215 @@
+// Package chunkcache is a bounded, in-process LRU cache for chunk bytes,
+// sitting in front of the gRPC GetChunk calls APIGateway.DownloadFile would
+// otherwise make for every read. It collapses concurrent reads of the same
+// chunk into a single upstream fetch and supports low-priority read-ahead
+// prefetch of the chunks following whatever was just served.
+package chunkcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/s3storage/internal/chunker"
+)
+
+const (
+	// DefaultMaxBytes bounds total cached chunk bytes.
+	DefaultMaxBytes = 512 * 1024 * 1024 // 512MB
+	// DefaultMaxEntries bounds the number of cached chunks, independent of size.
+	DefaultMaxEntries = 4096
+	// DefaultPrefetchAhead is how many following chunks are read ahead by
+	// default when a chunk is served.
+	DefaultPrefetchAhead = 4
+)
+
+// Fetch retrieves a chunk's bytes from its backing storage server. It is
+// supplied by the caller (the API gateway) so this package stays free of
+// gRPC/circuit-breaker concerns.
+type Fetch func(ctx context.Context) ([]byte, error)
+
+type entry struct {
+	chunkID    string
+	data       []byte
+	checksum   string
+	prefetched bool
+}
+
+// Cache is a size- and entry-bounded LRU cache for chunk bytes.
+type Cache struct {
+	mu         sync.Mutex
+	maxBytes   int64
+	maxEntries int
+	curBytes   int64
+	ll         *list.List
+	items      map[string]*list.Element
+
+	group   *singleflightGroup
+	metrics *Metrics
+}
+
+// NewCache creates a Cache bounded by maxBytes and maxEntries. A value <= 0
+// for either bound falls back to its package default.
+func NewCache(maxBytes int64, maxEntries int) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &Cache{
+		maxBytes:   maxBytes,
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		group:      newSingleflightGroup(),
+		metrics:    newMetrics(),
+	}
+}
+
+// Get returns a chunk's bytes from the cache, re-verifying its checksum
+// against what the metadata DB recorded before serving it. A checksum
+// mismatch is treated as corruption: the entry is evicted and Get reports a
+// miss. Hits, misses, and prefetch hits are recorded on the cache's metrics.
+func (c *Cache) Get(chunkID, checksum string) ([]byte, bool) {
+	data, wasPrefetched, ok := c.get(chunkID, checksum)
+	if !ok {
+		c.metrics.Misses.Inc()
+		return nil, false
+	}
+
+	c.metrics.Hits.Inc()
+	if wasPrefetched {
+		c.metrics.PrefetchHits.Inc()
+	}
+	return data, true
+}
+
+// Put inserts or replaces a chunk's cached bytes.
+func (c *Cache) Put(chunkID, checksum string, data []byte) {
+	c.put(chunkID, checksum, data, false)
+}
+
+// GetOrFetch is a convenience wrapper around Get that calls fetch on a
+// miss, collapsing concurrent callers for the same chunkID into one call
+// and populating the cache with the result.
+func (c *Cache) GetOrFetch(ctx context.Context, chunkID, checksum string, fetch Fetch) ([]byte, error) {
+	if data, ok := c.Get(chunkID, checksum); ok {
+		return data, nil
+	}
+
+	data, err := c.group.do(chunkID, func() ([]byte, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(chunkID, checksum, data, false)
+	return data, nil
+}
+
+// Prefetch populates the cache for chunkID in the background if it isn't
+// already cached, without blocking the caller or affecting hit/miss
+// metrics. Entries filled this way are flagged so a later Get hit can be
+// counted as a prefetch win.
+func (c *Cache) Prefetch(ctx context.Context, chunkID, checksum string, fetch Fetch) {
+	c.mu.Lock()
+	_, alreadyCached := c.items[chunkID]
+	c.mu.Unlock()
+	if alreadyCached {
+		return
+	}
+
+	go func() {
+		data, err := c.group.do(chunkID, func() ([]byte, error) {
+			return fetch(ctx)
+		})
+		if err != nil {
+			return
+		}
+		c.put(chunkID, checksum, data, true)
+	}()
+}
+
+// Remove evicts a chunk from the cache, e.g. via the admin cache-eviction
+// route. It reports whether the chunk was present.
+func (c *Cache) Remove(chunkID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[chunkID]
+	if !ok {
+		return false
+	}
+	c.removeElement(el)
+	c.metrics.Evictions.Inc()
+	return true
+}
+
+// get looks up chunkID, re-verifying its checksum and evicting it on a
+// mismatch. It reports whether the entry had been populated by Prefetch
+// (and clears that flag, so only the first post-prefetch read counts).
+func (c *Cache) get(chunkID, checksum string) (data []byte, wasPrefetched bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, exists := c.items[chunkID]
+	if !exists {
+		return nil, false, false
+	}
+	e := el.Value.(*entry)
+
+	if !verifyChecksum(e.data, checksum) {
+		c.removeElement(el)
+		c.metrics.Evictions.Inc()
+		return nil, false, false
+	}
+
+	c.ll.MoveToFront(el)
+	wasPrefetched = e.prefetched
+	e.prefetched = false
+	return e.data, wasPrefetched, true
+}
+
+func (c *Cache) put(chunkID, checksum string, data []byte, prefetched bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[chunkID]; ok {
+		c.removeElement(el)
+	}
+
+	e := &entry{chunkID: chunkID, data: data, checksum: checksum, prefetched: prefetched}
+	el := c.ll.PushFront(e)
+	c.items[chunkID] = el
+	c.curBytes += int64(len(data))
+
+	for c.curBytes > c.maxBytes || c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeElement(oldest)
+		c.metrics.Evictions.Inc()
+	}
+}
+
+// removeElement must be called with c.mu held.
+func (c *Cache) removeElement(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, e.chunkID)
+	c.curBytes -= int64(len(e.data))
+}
+
+// verifyChecksum reports whether data's checksum matches what the metadata
+// DB recorded for this chunk. An empty expected checksum skips verification
+// (callers that don't track one, e.g. in tests).
+func verifyChecksum(data []byte, expected string) bool {
+	if expected == "" {
+		return true
+	}
+	return chunker.VerifyChecksum(data, expected) == nil
+}
@@ -0,0 +1,46 @@
+package chunkcache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the Prometheus counters exported by a Cache.
+type Metrics struct {
+	Hits         prometheus.Counter
+	Misses       prometheus.Counter
+	Evictions    prometheus.Counter
+	PrefetchHits prometheus.Counter
+}
+
+// newMetrics creates and registers the cache's counters. A duplicate
+// registration (a second cache registering against the same default
+// registry) is ignored, mirroring how duplicate-registration is usually
+// tolerated for singleton caches; any other registration error panics.
+func newMetrics() *Metrics {
+	m := &Metrics{
+		Hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chunkcache_hits_total",
+			Help: "Number of chunk reads served from the local cache.",
+		}),
+		Misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chunkcache_misses_total",
+			Help: "Number of chunk reads that missed the local cache.",
+		}),
+		Evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chunkcache_evictions_total",
+			Help: "Number of cache entries evicted, either for space or a checksum mismatch.",
+		}),
+		PrefetchHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chunkcache_prefetch_hits_total",
+			Help: "Number of chunk reads served from an entry populated by read-ahead prefetch.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.Hits, m.Misses, m.Evictions, m.PrefetchHits} {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,47 @@
+package chunkcache
+
+import "sync"
+
+// call represents an in-flight or completed fetch for a single key.
+type call struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// singleflightGroup collapses concurrent fetches for the same key into a
+// single upstream call, so N readers hitting the same cold chunk only
+// trigger one gRPC round trip.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+func newSingleflightGroup() *singleflightGroup {
+	return &singleflightGroup{calls: make(map[string]*call)}
+}
+
+// do executes fn for key, or waits for and returns the result of an
+// already-in-flight call for the same key.
+func (g *singleflightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.data, c.err
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.data, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.data, c.err
+}
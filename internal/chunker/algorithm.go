@@ -0,0 +1,116 @@
+package chunker
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
+)
+
+// DefaultAlgorithm is the checksum algorithm CalculateChecksum uses, and the
+// one ParseChecksum assumes for a checksum with no "algo:" prefix.
+const DefaultAlgorithm = "sha256"
+
+// Algorithm names understood out of the box. Callers can add more with
+// RegisterHasher.
+const (
+	AlgorithmSHA256      = "sha256"
+	AlgorithmSHA512_256  = "sha512/256"
+	AlgorithmBLAKE3      = "blake3"
+	AlgorithmXXH3        = "xxh3"
+	// AlgorithmSize is a pseudo-hash that only tracks byte count, not
+	// content, for pipelines on a trusted network that want cheap
+	// length-only integrity checks instead of a real digest.
+	AlgorithmSize = "size"
+)
+
+// ErrUnknownAlgorithm is returned when a checksum names an algorithm no
+// hasher is registered for.
+var ErrUnknownAlgorithm = errors.New("unknown checksum algorithm")
+
+var hasherRegistry = struct {
+	mu        sync.RWMutex
+	factories map[string]func() hash.Hash
+}{
+	factories: map[string]func() hash.Hash{
+		AlgorithmSHA256:     sha256.New,
+		AlgorithmSHA512_256: sha512.New512_256,
+		AlgorithmBLAKE3:     func() hash.Hash { return blake3.New() },
+		AlgorithmXXH3:       func() hash.Hash { return xxh3.New() },
+		AlgorithmSize:       newSizeHash,
+	},
+}
+
+// RegisterHasher registers factory under name, so HashWith and
+// VerifyChecksum can dispatch to it via a "name:hexdigest" checksum string.
+// Registering a name a second time replaces the previous factory.
+func RegisterHasher(name string, factory func() hash.Hash) {
+	hasherRegistry.mu.Lock()
+	defer hasherRegistry.mu.Unlock()
+	hasherRegistry.factories[name] = factory
+}
+
+func lookupHasher(name string) (func() hash.Hash, bool) {
+	hasherRegistry.mu.RLock()
+	defer hasherRegistry.mu.RUnlock()
+	factory, ok := hasherRegistry.factories[name]
+	return factory, ok
+}
+
+// ParseChecksum splits a "algo:hexdigest" checksum into its algorithm and
+// digest. A checksum with no "algo:" prefix - i.e. one predating this
+// registry - is reported as DefaultAlgorithm, so every checksum
+// CalculateChecksum produced before this package supported other algorithms
+// still verifies correctly.
+func ParseChecksum(checksum string) (algorithm, digest string) {
+	if idx := strings.IndexByte(checksum, ':'); idx >= 0 {
+		return checksum[:idx], checksum[idx+1:]
+	}
+	return DefaultAlgorithm, checksum
+}
+
+// HashWith computes data's digest using the named algorithm and returns it
+// serialized as "algo:hexdigest", the format ChunkMetadata.Checksum and
+// VerifyChecksum expect for anything beyond plain SHA-256.
+func HashWith(algorithm string, data []byte) (string, error) {
+	factory, ok := lookupHasher(algorithm)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algorithm)
+	}
+	h := factory()
+	h.Write(data)
+	return algorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sizeHash is a pseudo-hash.Hash backing AlgorithmSize: it ignores the
+// content of every Write and only tracks the number of bytes seen, so its
+// "digest" is just that count. It still satisfies hash.Hash so it can sit
+// in the same registry as the real hashers.
+type sizeHash struct {
+	n uint64
+}
+
+func newSizeHash() hash.Hash { return &sizeHash{} }
+
+func (s *sizeHash) Write(p []byte) (int, error) {
+	s.n += uint64(len(p))
+	return len(p), nil
+}
+
+func (s *sizeHash) Sum(b []byte) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], s.n)
+	return append(b, buf[:]...)
+}
+
+func (s *sizeHash) Reset()         { s.n = 0 }
+func (s *sizeHash) Size() int      { return 8 }
+func (s *sizeHash) BlockSize() int { return 1 }
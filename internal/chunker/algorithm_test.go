@@ -0,0 +1,75 @@
+package chunker
+
+import (
+	"hash"
+	"hash/fnv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseChecksum_LegacyBareHexDefaultsToSHA256 verifies a checksum with
+// no "algo:" prefix - the format every checksum CalculateChecksum produced
+// before this registry existed - is still treated as SHA-256.
+func TestParseChecksum_LegacyBareHexDefaultsToSHA256(t *testing.T) {
+	algorithm, digest := ParseChecksum("ab12cd34")
+	assert.Equal(t, DefaultAlgorithm, algorithm)
+	assert.Equal(t, "ab12cd34", digest)
+}
+
+func TestParseChecksum_PrefixedSplitsOnFirstColon(t *testing.T) {
+	algorithm, digest := ParseChecksum("blake3:ab12cd34")
+	assert.Equal(t, "blake3", algorithm)
+	assert.Equal(t, "ab12cd34", digest)
+}
+
+// TestHashWith_RoundTripsThroughVerifyChecksum verifies every built-in
+// algorithm's HashWith output is accepted by VerifyChecksum.
+func TestHashWith_RoundTripsThroughVerifyChecksum(t *testing.T) {
+	data := []byte("pluggable hasher registry test payload")
+
+	for _, algorithm := range []string{AlgorithmSHA256, AlgorithmSHA512_256, AlgorithmBLAKE3, AlgorithmXXH3, AlgorithmSize} {
+		t.Run(algorithm, func(t *testing.T) {
+			checksum, err := HashWith(algorithm, data)
+			require.NoError(t, err)
+			assert.NoError(t, VerifyChecksum(data, checksum))
+		})
+	}
+}
+
+// TestVerifyChecksum_LegacyBareHexStillWorks verifies VerifyChecksum still
+// accepts a plain hex checksum CalculateChecksum produced, with no prefix.
+func TestVerifyChecksum_LegacyBareHexStillWorks(t *testing.T) {
+	data := []byte("legacy checksum format")
+	assert.NoError(t, VerifyChecksum(data, CalculateChecksum(data)))
+}
+
+// TestVerifyChecksum_UnknownAlgorithm verifies an unregistered algorithm
+// name in the prefix is rejected with ErrUnknownAlgorithm.
+func TestVerifyChecksum_UnknownAlgorithm(t *testing.T) {
+	err := VerifyChecksum([]byte("x"), "md5:deadbeef")
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	_, err = HashWith("md5", []byte("x"))
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
+
+// TestVerifyChecksum_Mismatch verifies a digest mismatch under a pluggable
+// algorithm still surfaces ErrChecksumMismatch, not just for the SHA-256
+// default path.
+func TestVerifyChecksum_Mismatch(t *testing.T) {
+	err := VerifyChecksum([]byte("actual data"), "blake3:0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+// TestRegisterHasher_AddsAPluggableAlgorithm verifies a caller can plug in
+// an algorithm the package doesn't ship, without modifying this package.
+func TestRegisterHasher_AddsAPluggableAlgorithm(t *testing.T) {
+	RegisterHasher("fnv32a", func() hash.Hash { return fnv.New32a() })
+
+	data := []byte("fnv round trip")
+	checksum, err := HashWith("fnv32a", data)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyChecksum(data, checksum))
+}
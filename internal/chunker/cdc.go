@@ -0,0 +1,169 @@
+package chunker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+const (
+	// DefaultMinCDCChunkSize is the minimum chunk size content-defined
+	// chunking will cut, below which only a forced MaxSize cut applies.
+	DefaultMinCDCChunkSize = 2 * 1024 * 1024 // 2 MiB
+	// DefaultAvgCDCChunkSize is the target average chunk size; the gear
+	// masks are sized relative to it.
+	DefaultAvgCDCChunkSize = 8 * 1024 * 1024 // 8 MiB
+	// DefaultMaxCDCChunkSize is the hard cap on chunk size; a cut is forced
+	// here even if the rolling fingerprint never matches a mask.
+	DefaultMaxCDCChunkSize = 16 * 1024 * 1024 // 16 MiB
+)
+
+// ErrInvalidCDCParams is returned when min/avg/max chunk sizes aren't a
+// valid, strictly increasing range.
+var ErrInvalidCDCParams = errors.New("invalid content-defined chunking parameters")
+
+// gearTable is FastCDC's per-byte rolling-fingerprint table. It's derived
+// once, deterministically, from a fixed seed (via splitmix64) rather than
+// crypto/rand, so every node computes identical cut points for identical
+// bytes - the same input always chunks the same way, which is what makes
+// the resulting chunk hashes usable as a cross-upload dedup key.
+var gearTable = generateGearTable()
+
+func generateGearTable() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x9E3779B97F4A7C15) // golden ratio constant, used only as a fixed seed
+	for i := range table {
+		state = splitmix64(state)
+		table[i] = state
+	}
+	return table
+}
+
+// splitmix64 is a small, fast, well-distributed PRNG step, used only to
+// derive gearTable deterministically.
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	z := x
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// cdcMasks derives FastCDC's two cut-point bitmasks from avgSize: maskS
+// (applied once a chunk reaches minSize, log2(avg)-2 low bits set, so a cut
+// matches about once every avg/4 bytes on the way to avgSize) and maskL
+// (applied once a chunk reaches avgSize, log2(avg)+2 low bits set, so a
+// cut matches only about once every avg*4 bytes past average, leaving
+// maxSize's forced cut to end chunks that find no match).
+func cdcMasks(avgSize int) (maskS, maskL uint64) {
+	log2Avg := bits.Len(uint(avgSize)) - 1
+	sBits := log2Avg - 2
+	if sBits < 0 {
+		sBits = 0
+	}
+	lBits := log2Avg + 2
+
+	return (uint64(1) << uint(sBits)) - 1, (uint64(1) << uint(lBits)) - 1
+}
+
+func validateCDCParams(minSize, avgSize, maxSize int) error {
+	if minSize <= 0 || avgSize <= 0 || maxSize <= 0 {
+		return ErrInvalidCDCParams
+	}
+	if !(minSize < avgSize && avgSize < maxSize) {
+		return ErrInvalidCDCParams
+	}
+	return nil
+}
+
+// StreamCDCChunks reads r to EOF and invokes onChunk, in order, once per
+// content-defined chunk as soon as FastCDC cuts it - so a caller (e.g.
+// UploadFile) can upload each chunk as it's produced instead of waiting for
+// the whole file to be cut first. onChunk's data slice is only valid for the
+// duration of the call; it is reused internally after onChunk returns.
+//
+// Cut points follow FastCDC: a 64-bit rolling fingerprint fp is updated per
+// byte as fp = (fp << 1) + gearTable[b]. A cut happens when the current
+// chunk has reached minSize and fp&maskS == 0, or has reached avgSize and
+// fp&maskL == 0, or has reached maxSize (forced).
+func StreamCDCChunks(r io.Reader, minSize, avgSize, maxSize int, onChunk func(info ChunkInfo, data []byte) error) error {
+	if err := validateCDCParams(minSize, avgSize, maxSize); err != nil {
+		return err
+	}
+	maskS, maskL := cdcMasks(avgSize)
+
+	br := bufio.NewReaderSize(r, uploadBufferSize)
+	buf := make([]byte, 0, maxSize)
+	var fp uint64
+	var offset int64
+	number := 0
+
+	emit := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		info := ChunkInfo{Number: number, Offset: offset, Size: int64(len(buf))}
+		if err := onChunk(info, buf); err != nil {
+			return err
+		}
+		offset += int64(len(buf))
+		number++
+		buf = buf[:0]
+		fp = 0
+		return nil
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read content-defined chunk data: %w", err)
+		}
+
+		buf = append(buf, b)
+		fp = (fp << 1) + gearTable[b]
+
+		size := len(buf)
+		var cut bool
+		switch {
+		case size >= maxSize:
+			cut = true
+		case size >= avgSize:
+			cut = fp&maskL == 0
+		case size >= minSize:
+			cut = fp&maskS == 0
+		}
+
+		if cut {
+			if err := emit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return emit()
+}
+
+// uploadBufferSize is the buffered read size StreamCDCChunks uses when
+// pulling bytes from r.
+const uploadBufferSize = 64 * 1024
+
+// CalculateCDCBoundaries reads r to EOF and returns the content-defined
+// chunk boundaries FastCDC would cut it into, without retaining chunk data -
+// the non-streaming counterpart to StreamCDCChunks for callers that only
+// need offsets/sizes (e.g. to preview how a file would chunk).
+func CalculateCDCBoundaries(r io.Reader, minSize, avgSize, maxSize int) ([]ChunkInfo, error) {
+	var chunks []ChunkInfo
+	err := StreamCDCChunks(r, minSize, avgSize, maxSize, func(info ChunkInfo, _ []byte) error {
+		chunks = append(chunks, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
@@ -0,0 +1,142 @@
+package chunker
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalculateCDCBoundaries_RespectsMinAndMax verifies every cut chunk
+// falls within [minSize, maxSize] and that the boundaries cover the whole
+// input with no gaps or overlaps.
+func TestCalculateCDCBoundaries_RespectsMinAndMax(t *testing.T) {
+	data := make([]byte, 10*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	minSize, avgSize, maxSize := 256*1024, 1*MiB, 2*MiB
+
+	chunks, err := CalculateCDCBoundaries(bytes.NewReader(data), minSize, avgSize, maxSize)
+	require.NoError(t, err)
+	require.NotEmpty(t, chunks)
+
+	var total int64
+	for i, chunk := range chunks {
+		assert.Equal(t, i, chunk.Number)
+		assert.Equal(t, total, chunk.Offset)
+		assert.LessOrEqual(t, chunk.Size, int64(maxSize))
+		if i < len(chunks)-1 {
+			assert.GreaterOrEqual(t, chunk.Size, int64(minSize))
+		}
+		total += chunk.Size
+	}
+	assert.Equal(t, int64(len(data)), total)
+}
+
+// TestCalculateCDCBoundaries_Deterministic verifies identical input always
+// cuts identically, since dedup depends on that.
+func TestCalculateCDCBoundaries_Deterministic(t *testing.T) {
+	data := make([]byte, 5*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	a, err := CalculateCDCBoundaries(bytes.NewReader(data), DefaultMinCDCChunkSize/4, DefaultAvgCDCChunkSize/4, DefaultMaxCDCChunkSize/4)
+	require.NoError(t, err)
+	b, err := CalculateCDCBoundaries(bytes.NewReader(data), DefaultMinCDCChunkSize/4, DefaultAvgCDCChunkSize/4, DefaultMaxCDCChunkSize/4)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+// TestCalculateCDCBoundaries_ShiftedInsertionRealignsQuickly verifies the
+// core motivation for CDC over fixed-size chunking: inserting a few bytes
+// near the start of a file only disturbs the chunk(s) immediately around
+// the insertion, not every chunk after it.
+func TestCalculateCDCBoundaries_ShiftedInsertionRealignsQuickly(t *testing.T) {
+	data := make([]byte, 8*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	minSize, avgSize, maxSize := 128*1024, 512*1024, 1*MiB
+
+	original, err := CalculateCDCBoundaries(bytes.NewReader(data), minSize, avgSize, maxSize)
+	require.NoError(t, err)
+
+	shifted := append(append([]byte{}, data[:1*MiB]...), append([]byte{0xAB, 0xCD, 0xEF}, data[1*MiB:]...)...)
+	shiftedChunks, err := CalculateCDCBoundaries(bytes.NewReader(shifted), minSize, avgSize, maxSize)
+	require.NoError(t, err)
+
+	originalHashes := make(map[string]bool, len(original))
+	for _, c := range original {
+		originalHashes[CalculateChecksum(data[c.Offset:c.Offset+c.Size])] = true
+	}
+
+	var reused int
+	for _, c := range shiftedChunks {
+		if originalHashes[CalculateChecksum(shifted[c.Offset:c.Offset+c.Size])] {
+			reused++
+		}
+	}
+
+	// Most chunks should be untouched by a 3-byte insertion near the start;
+	// a fixed-count split would reuse essentially none of them.
+	assert.Greater(t, reused, len(original)/2, "most chunks should survive an insertion unchanged")
+}
+
+// TestStreamCDCChunks_MatchesCalculateCDCBoundaries verifies the streaming
+// and non-streaming entry points agree on the same input.
+func TestStreamCDCChunks_MatchesCalculateCDCBoundaries(t *testing.T) {
+	data := make([]byte, 4*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	minSize, avgSize, maxSize := 128*1024, 512*1024, 1*MiB
+
+	expected, err := CalculateCDCBoundaries(bytes.NewReader(data), minSize, avgSize, maxSize)
+	require.NoError(t, err)
+
+	var got []ChunkInfo
+	var totalBytes int
+	err = StreamCDCChunks(bytes.NewReader(data), minSize, avgSize, maxSize, func(info ChunkInfo, chunkData []byte) error {
+		assert.Equal(t, int(info.Size), len(chunkData))
+		got = append(got, info)
+		totalBytes += len(chunkData)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, got)
+	assert.Equal(t, len(data), totalBytes)
+}
+
+// TestStreamCDCChunks_PropagatesCallbackError verifies a callback error
+// aborts iteration instead of being swallowed.
+func TestStreamCDCChunks_PropagatesCallbackError(t *testing.T) {
+	data := make([]byte, 2*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	boom := io.ErrClosedPipe
+	calls := 0
+	err = StreamCDCChunks(bytes.NewReader(data), 64*1024, 256*1024, 512*1024, func(info ChunkInfo, chunkData []byte) error {
+		calls++
+		return boom
+	})
+
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+}
+
+// TestCalculateCDCBoundaries_InvalidParams verifies non-increasing size
+// parameters are rejected.
+func TestCalculateCDCBoundaries_InvalidParams(t *testing.T) {
+	_, err := CalculateCDCBoundaries(bytes.NewReader(nil), 0, 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidCDCParams)
+
+	_, err = CalculateCDCBoundaries(bytes.NewReader(nil), 1024, 512, 2048)
+	assert.ErrorIs(t, err, ErrInvalidCDCParams)
+}
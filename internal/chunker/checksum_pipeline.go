@@ -0,0 +1,128 @@
+package chunker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// ChecksumPipeline computes a whole-file SHA-256 digest and a per-chunk
+// SHA-256 digest for each of chunks in a single streaming pass, so a caller
+// that already has to read the file once to upload it doesn't need a second
+// pass (or a seek back into each chunk's byte range) just to checksum it.
+// It implements io.Writer, so it can sit behind an io.MultiWriter alongside
+// whatever actually consumes the bytes (e.g. the per-chunk upload stream).
+type ChecksumPipeline struct {
+	chunks       []ChunkInfo
+	fileHash     hash.Hash
+	chunkHashers []hash.Hash
+	offset       int64
+	chunkIdx     int
+}
+
+// NewChecksumPipeline returns a ChecksumPipeline that expects to be written
+// exactly the bytes of chunks, in order, with no gaps - i.e. chunks as
+// produced by CalculateChunkBoundaries, CalculateCDCBoundaries, or
+// CalculateRabinBoundaries.
+func NewChecksumPipeline(chunks []ChunkInfo) *ChecksumPipeline {
+	chunkHashers := make([]hash.Hash, len(chunks))
+	for i := range chunkHashers {
+		chunkHashers[i] = sha256.New()
+	}
+	return &ChecksumPipeline{
+		chunks:       chunks,
+		fileHash:     sha256.New(),
+		chunkHashers: chunkHashers,
+	}
+}
+
+// Write feeds p to the whole-file hasher and to whichever per-chunk
+// hasher(s) own the byte range at the pipeline's current offset, splitting p
+// across a chunk boundary if it straddles one. Bytes must arrive in offset
+// order; Write returns an error if they run past the last chunk's end.
+func (p *ChecksumPipeline) Write(b []byte) (int, error) {
+	n := len(b)
+	p.fileHash.Write(b)
+
+	for len(b) > 0 {
+		if p.chunkIdx >= len(p.chunks) {
+			return 0, fmt.Errorf("checksum pipeline: write at offset %d is past the last chunk boundary", p.offset)
+		}
+
+		chunk := p.chunks[p.chunkIdx]
+		chunkEnd := chunk.Offset + chunk.Size
+		remaining := chunkEnd - p.offset
+		if remaining <= 0 {
+			p.chunkIdx++
+			continue
+		}
+
+		take := int64(len(b))
+		if take > remaining {
+			take = remaining
+		}
+
+		p.chunkHashers[p.chunkIdx].Write(b[:take])
+		p.offset += take
+		b = b[take:]
+
+		if p.offset == chunkEnd {
+			p.chunkIdx++
+		}
+	}
+
+	return n, nil
+}
+
+// Finalize returns the whole-file digest, one digest per chunk in chunks
+// order, and their Merkle root, computed bottom-up by hashing adjacent pairs
+// of chunk digests with SHA-256 and duplicating the last node at any level
+// with an odd number of nodes. It's an error to call Finalize before every
+// chunk has been fully written.
+func (p *ChecksumPipeline) Finalize() (fileSum string, chunkSums []string, merkleRootHex string, err error) {
+	if p.chunkIdx != len(p.chunks) {
+		return "", nil, "", fmt.Errorf("checksum pipeline: only %d/%d chunks were fully written", p.chunkIdx, len(p.chunks))
+	}
+
+	chunkSums = make([]string, len(p.chunkHashers))
+	level := make([][]byte, len(p.chunkHashers))
+	for i, h := range p.chunkHashers {
+		sum := h.Sum(nil)
+		chunkSums[i] = hex.EncodeToString(sum)
+		level[i] = sum
+	}
+
+	fileSum = hex.EncodeToString(p.fileHash.Sum(nil))
+	merkleRootHex = hex.EncodeToString(merkleRoot(level))
+	return fileSum, chunkSums, merkleRootHex, nil
+}
+
+// merkleRoot reduces level - one SHA-256 digest per leaf - to its root,
+// duplicating the final node at any level with an odd count so every level
+// above it pairs up evenly.
+func merkleRoot(level [][]byte) []byte {
+	if len(level) == 0 {
+		return nil
+	}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, hashPair(level[i], level[i+1]))
+			} else {
+				next = append(next, hashPair(level[i], level[i]))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// hashPair returns SHA-256(a || b).
+func hashPair(a, b []byte) []byte {
+	h := sha256.New()
+	h.Write(a)
+	h.Write(b)
+	return h.Sum(nil)
+}
@@ -0,0 +1,109 @@
+package chunker
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecksumPipeline_MatchesIndependentHashing verifies the file digest
+// and each chunk digest produced in a single streaming pass match what
+// hashing the same byte ranges independently would produce.
+func TestChecksumPipeline_MatchesIndependentHashing(t *testing.T) {
+	data := make([]byte, 5*MiB+137) // not an even multiple of any chunk size
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	chunks, err := CalculateChunkBoundaries(int64(len(data)), 4)
+	require.NoError(t, err)
+
+	pipeline := NewChecksumPipeline(chunks)
+	_, err = pipeline.Write(data)
+	require.NoError(t, err)
+
+	fileSum, chunkSums, merkleRoot, err := pipeline.Finalize()
+	require.NoError(t, err)
+
+	wantFileSum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(wantFileSum[:]), fileSum)
+
+	require.Len(t, chunkSums, len(chunks))
+	for i, chunk := range chunks {
+		want := sha256.Sum256(data[chunk.Offset : chunk.Offset+chunk.Size])
+		assert.Equal(t, hex.EncodeToString(want[:]), chunkSums[i])
+	}
+
+	assert.NotEmpty(t, merkleRoot)
+}
+
+// TestChecksumPipeline_SplitWritesAcrossBoundaries verifies Write correctly
+// splits a single call that straddles a chunk boundary, not just writes that
+// happen to line up with one.
+func TestChecksumPipeline_SplitWritesAcrossBoundaries(t *testing.T) {
+	data := make([]byte, 1024)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	chunks, err := CalculateChunkBoundaries(int64(len(data)), 4)
+	require.NoError(t, err)
+
+	pipeline := NewChecksumPipeline(chunks)
+	// Write in small, boundary-straddling pieces instead of one shot.
+	for i := 0; i < len(data); i += 37 {
+		end := i + 37
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err := pipeline.Write(data[i:end])
+		require.NoError(t, err)
+	}
+
+	_, chunkSums, _, err := pipeline.Finalize()
+	require.NoError(t, err)
+
+	for i, chunk := range chunks {
+		want := sha256.Sum256(data[chunk.Offset : chunk.Offset+chunk.Size])
+		assert.Equal(t, hex.EncodeToString(want[:]), chunkSums[i])
+	}
+}
+
+// TestChecksumPipeline_MerkleRootDuplicatesOddLevels verifies the Merkle
+// root of a 3-chunk tree matches hand-computing it with the
+// duplicate-the-last-node rule.
+func TestChecksumPipeline_MerkleRootDuplicatesOddLevels(t *testing.T) {
+	leaves := [][]byte{
+		sha256Sum([]byte("a")),
+		sha256Sum([]byte("b")),
+		sha256Sum([]byte("c")),
+	}
+
+	level1 := [][]byte{
+		hashPair(leaves[0], leaves[1]),
+		hashPair(leaves[2], leaves[2]), // odd node duplicated
+	}
+	want := hashPair(level1[0], level1[1])
+
+	assert.Equal(t, want, merkleRoot(leaves))
+}
+
+// TestChecksumPipeline_FinalizeBeforeFullyWrittenErrors verifies Finalize
+// refuses to return a result for a chunk that was never completed.
+func TestChecksumPipeline_FinalizeBeforeFullyWrittenErrors(t *testing.T) {
+	chunks := []ChunkInfo{{Number: 0, Offset: 0, Size: 10}, {Number: 1, Offset: 10, Size: 10}}
+
+	pipeline := NewChecksumPipeline(chunks)
+	_, err := pipeline.Write(make([]byte, 10))
+	require.NoError(t, err)
+
+	_, _, _, err = pipeline.Finalize()
+	assert.Error(t, err)
+}
+
+func sha256Sum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
@@ -36,6 +36,10 @@ type ChunkMetadata struct {
 	Size        int64
 	Offset      int64
 	Checksum    string
+	// Algorithm names the hasher Checksum's digest was computed with (see
+	// RegisterHasher). Empty means DefaultAlgorithm (SHA-256), for chunks
+	// predating the Hasher registry.
+	Algorithm string
 }
 
 // CalculateChunkBoundaries calculates the boundaries for splitting a file into chunks
@@ -80,18 +84,34 @@ func CalculateChunkBoundaries(fileSize int64, numChunks int) ([]ChunkInfo, error
 	return chunks, nil
 }
 
-// CalculateChecksum calculates SHA-256 checksum for data
+// CalculateChecksum calculates data's SHA-256 checksum as bare hex, with no
+// "algo:" prefix. This is the format the storage node's gRPC PutChunk
+// handler compares an upload's checksum against, so it's kept stable even
+// though VerifyChecksum and HashWith also support prefixed, pluggable-
+// algorithm checksums - see algorithm.go.
 func CalculateChecksum(data []byte) string {
 	hash := sha256.Sum256(data)
 	return fmt.Sprintf("%x", hash)
 }
 
-// VerifyChecksum verifies that data matches the expected checksum
+// VerifyChecksum verifies that data matches expectedChecksum, dispatching to
+// whichever algorithm expectedChecksum names - see ParseChecksum. A
+// checksum with no "algo:" prefix is verified as plain SHA-256, matching
+// every checksum CalculateChecksum has ever produced.
 func VerifyChecksum(data []byte, expectedChecksum string) error {
-	actualChecksum := CalculateChecksum(data)
-	if actualChecksum != expectedChecksum {
-		return fmt.Errorf("%w: expected %s, got %s",
-			ErrChecksumMismatch, expectedChecksum, actualChecksum)
+	algorithm, digest := ParseChecksum(expectedChecksum)
+
+	factory, ok := lookupHasher(algorithm)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algorithm)
+	}
+
+	h := factory()
+	h.Write(data)
+	actual := fmt.Sprintf("%x", h.Sum(nil))
+
+	if actual != digest {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, digest, actual)
 	}
 	return nil
 }
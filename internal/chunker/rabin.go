@@ -0,0 +1,314 @@
+package chunker
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+const (
+	// DefaultMinRabinChunkSize is the minimum chunk size RabinCDC will cut,
+	// below which only a forced MaxSize cut applies.
+	DefaultMinRabinChunkSize = 512 * 1024 // 512 KiB
+	// DefaultAvgRabinChunkSize is the target average chunk size; the cut
+	// mask is sized relative to it.
+	DefaultAvgRabinChunkSize = 1024 * 1024 // 1 MiB
+	// DefaultMaxRabinChunkSize is the hard cap on chunk size; a cut is
+	// forced here even if the rolling fingerprint never matches the mask.
+	DefaultMaxRabinChunkSize = 8 * 1024 * 1024 // 8 MiB
+
+	// rabinWindowSize is the width, in bytes, of the sliding window the
+	// rolling fingerprint is computed over.
+	rabinWindowSize = 64
+	// rabinPolDegree is the degree of polynomial RandomPolynomial searches
+	// for and DefaultRabinPol is fixed at.
+	rabinPolDegree = 53
+)
+
+// DefaultRabinPol is a fixed, precomputed irreducible degree-53 polynomial,
+// used as RabinCDC's default fingerprint modulus. Like gearTable, it's
+// derived once ahead of time rather than drawn fresh via RandomPolynomial
+// per process, so every node cuts identical input the same way - which is
+// what makes the resulting chunk hashes usable as a cross-upload dedup key.
+const DefaultRabinPol Pol = 0x3DA3358B4DC173
+
+// Pol is a polynomial over GF(2): bit i is the coefficient of x^i. Rabin
+// fingerprinting reduces the rolling polynomial formed from a file's bytes
+// modulo a fixed irreducible Pol, the same way a CRC reduces modulo its
+// generator polynomial.
+type Pol uint64
+
+// deg returns p's degree, or -1 for the zero polynomial.
+func (p Pol) deg() int {
+	if p == 0 {
+		return -1
+	}
+	return bits.Len64(uint64(p)) - 1
+}
+
+// mod returns p reduced modulo m, using repeated shift-and-xor: at each step
+// p's leading term is cancelled by xoring in m shifted up to the same
+// degree, which is subtraction in GF(2).
+func (p Pol) mod(m Pol) Pol {
+	dm := m.deg()
+	for p.deg() >= dm {
+		p ^= m << uint(p.deg()-dm)
+	}
+	return p
+}
+
+// polMulMod returns (a*b) mod m. It multiplies one bit of b into the result
+// at a time, left-shifting a and reducing it modulo m whenever a's degree
+// would otherwise reach m's - so the running value of a never needs more
+// than 64 bits to represent exactly, even though a plain a*b could overflow
+// that width for degree-53 operands.
+func polMulMod(a, b, m Pol) Pol {
+	var result Pol
+	dm := m.deg()
+	for i := 0; i <= b.deg(); i++ {
+		if b&(1<<uint(i)) != 0 {
+			result ^= a
+		}
+		a <<= 1
+		if a.deg() == dm {
+			a ^= m
+		}
+	}
+	return result
+}
+
+// polGCD returns the GCD of a and b over GF(2), via the standard Euclidean
+// algorithm with polMod in place of integer remainder.
+func polGCD(a, b Pol) Pol {
+	for b != 0 {
+		a, b = b, a.mod(b)
+	}
+	return a
+}
+
+// polPowX2Mod returns x^(2^k) mod m, computed by repeated squaring starting
+// from x - the building block irreducible uses to test Fermat's little
+// theorem for polynomials.
+func polPowX2Mod(k int, m Pol) Pol {
+	result := Pol(2) // x
+	for i := 0; i < k; i++ {
+		result = polMulMod(result, result, m)
+	}
+	return result
+}
+
+// primeFactors returns the distinct prime factors of n, found by trial
+// division - n is always a small polynomial degree (<= 64) here, so this
+// never needs to be fast.
+func primeFactors(n int) []int {
+	var factors []int
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			factors = append(factors, d)
+			for n%d == 0 {
+				n /= d
+			}
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// irreducible reports whether p is irreducible over GF(2), using Ben-Or's
+// test: a degree-n polynomial p is irreducible iff x^(2^n) == x (mod p), and
+// gcd(x^(2^(n/d)) - x, p) == 1 for every prime d dividing n.
+func (p Pol) irreducible() bool {
+	n := p.deg()
+	if n <= 0 {
+		return false
+	}
+	for _, d := range primeFactors(n) {
+		h := polPowX2Mod(n/d, p) ^ Pol(2) // subtract x, i.e. xor it in GF(2)
+		if polGCD(p, h) != 1 {
+			return false
+		}
+	}
+	return polPowX2Mod(n, p)^Pol(2) == 0
+}
+
+// RandomPolynomial returns a random irreducible polynomial of degree
+// rabinPolDegree, suitable for use as RabinChunkerConfig.Pol in place of
+// DefaultRabinPol. Candidates are drawn from crypto/rand and tested with
+// irreducible until one passes; a crypto/rand failure is returned rather
+// than falling back to a weaker source, since a predictable modulus would
+// let an adversary choose input that always cuts at the same offsets.
+func RandomPolynomial() (Pol, error) {
+	for {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, fmt.Errorf("failed to read random bytes for candidate polynomial: %w", err)
+		}
+		candidate := Pol(binary.LittleEndian.Uint64(buf[:]))
+		candidate |= Pol(1) << rabinPolDegree
+		candidate |= 1
+		if candidate.deg() != rabinPolDegree {
+			continue
+		}
+		if candidate.irreducible() {
+			return candidate, nil
+		}
+	}
+}
+
+// RabinChunkerConfig bundles the size bounds StreamRabinChunks enforces
+// along with the irreducible polynomial used as its fingerprint modulus.
+type RabinChunkerConfig struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+	// Pol is the fingerprint modulus. Zero means DefaultRabinPol.
+	Pol Pol
+}
+
+// DefaultRabinChunkerConfig returns the default size bounds (512 KiB / 1 MiB
+// / 8 MiB) and DefaultRabinPol as the fingerprint modulus.
+func DefaultRabinChunkerConfig() RabinChunkerConfig {
+	return RabinChunkerConfig{
+		MinSize: DefaultMinRabinChunkSize,
+		AvgSize: DefaultAvgRabinChunkSize,
+		MaxSize: DefaultMaxRabinChunkSize,
+		Pol:     DefaultRabinPol,
+	}
+}
+
+// rabinMask derives the single cut-point bitmask from avgSize: a cut is
+// considered once a chunk has reached MinSize and the rolling fingerprint's
+// low bits, masked by this value, are all zero.
+func rabinMask(avgSize int) uint64 {
+	log2Avg := bits.Len(uint(avgSize)) - 1
+	return (uint64(1) << uint(log2Avg)) - 1
+}
+
+// rabinOutTable precomputes, for every possible byte value, that byte's
+// contribution to the rolling fingerprint rabinWindowSize bytes after it
+// entered the window: out[b] = (b * x^(rabinWindowSize*8)) mod mod. Xoring
+// this in removes a byte's contribution from h without recomputing the
+// fingerprint over the whole window.
+func rabinOutTable(mod Pol) [256]Pol {
+	xPow := Pol(1)
+	shiftPol := Pol(2) // x
+	for exp := rabinWindowSize * 8; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			xPow = polMulMod(xPow, shiftPol, mod)
+		}
+		shiftPol = polMulMod(shiftPol, shiftPol, mod)
+	}
+
+	var out [256]Pol
+	for b := 0; b < 256; b++ {
+		out[b] = polMulMod(Pol(b), xPow, mod)
+	}
+	return out
+}
+
+// StreamRabinChunks reads r to EOF and invokes onChunk, in order, once per
+// content-defined chunk as soon as a cut is made - the same streaming
+// contract as StreamCDCChunks, but using a Rabin polynomial rolling
+// fingerprint over a rabinWindowSize-byte sliding window instead of
+// FastCDC's gear hash. onChunk's data slice is only valid for the duration
+// of the call; it is reused internally after onChunk returns.
+//
+// The rolling fingerprint h is updated per byte as
+// h = ((h << 8) | b_in) mod Pol, with the byte leaving the window removed
+// via h ^= out[b_out]. A cut happens once the current chunk has reached
+// MinSize and h&mask == 0, or has reached MaxSize (forced).
+func StreamRabinChunks(r io.Reader, cfg RabinChunkerConfig, onChunk func(info ChunkInfo, data []byte) error) error {
+	if err := validateCDCParams(cfg.MinSize, cfg.AvgSize, cfg.MaxSize); err != nil {
+		return err
+	}
+	mod := cfg.Pol
+	if mod == 0 {
+		mod = DefaultRabinPol
+	}
+	mask := rabinMask(cfg.AvgSize)
+	out := rabinOutTable(mod)
+
+	br := bufio.NewReaderSize(r, uploadBufferSize)
+	buf := make([]byte, 0, cfg.MaxSize)
+	var window [rabinWindowSize]byte
+	var windowPos, windowFilled int
+	var h Pol
+	var offset int64
+	number := 0
+
+	emit := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		info := ChunkInfo{Number: number, Offset: offset, Size: int64(len(buf))}
+		if err := onChunk(info, buf); err != nil {
+			return err
+		}
+		offset += int64(len(buf))
+		number++
+		buf = buf[:0]
+		h = 0
+		windowPos = 0
+		windowFilled = 0
+		return nil
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read content-defined chunk data: %w", err)
+		}
+
+		buf = append(buf, b)
+
+		h = ((h << 8) | Pol(b)).mod(mod)
+		if windowFilled == rabinWindowSize {
+			h ^= out[window[windowPos]]
+		} else {
+			windowFilled++
+		}
+		window[windowPos] = b
+		windowPos = (windowPos + 1) % rabinWindowSize
+
+		size := len(buf)
+		var cut bool
+		switch {
+		case size >= cfg.MaxSize:
+			cut = true
+		case size >= cfg.MinSize:
+			cut = uint64(h)&mask == 0
+		}
+
+		if cut {
+			if err := emit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return emit()
+}
+
+// CalculateRabinBoundaries reads r to EOF and returns the content-defined
+// chunk boundaries StreamRabinChunks would cut it into, without retaining
+// chunk data - the non-streaming counterpart for callers that only need
+// offsets/sizes.
+func CalculateRabinBoundaries(r io.Reader, cfg RabinChunkerConfig) ([]ChunkInfo, error) {
+	var chunks []ChunkInfo
+	err := StreamRabinChunks(r, cfg, func(info ChunkInfo, _ []byte) error {
+		chunks = append(chunks, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
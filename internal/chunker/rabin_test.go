@@ -0,0 +1,109 @@
+package chunker
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultRabinPol_Irreducible verifies the fixed modulus every node uses
+// is actually irreducible - an reducible modulus would let the rolling
+// fingerprint factor, biasing cut points toward repeating byte patterns.
+func TestDefaultRabinPol_Irreducible(t *testing.T) {
+	assert.True(t, DefaultRabinPol.irreducible())
+}
+
+// TestRandomPolynomial_ReturnsIrreducibleDegree53 verifies RandomPolynomial
+// never hands back a composite candidate or one of the wrong degree.
+func TestRandomPolynomial_ReturnsIrreducibleDegree53(t *testing.T) {
+	pol, err := RandomPolynomial()
+	require.NoError(t, err)
+	assert.Equal(t, rabinPolDegree, pol.deg())
+	assert.True(t, pol.irreducible())
+}
+
+// TestCalculateRabinBoundaries_RespectsMinAndMax mirrors
+// TestCalculateCDCBoundaries_RespectsMinAndMax for the Rabin-fingerprint
+// chunker: every chunk falls within [MinSize, MaxSize] and the boundaries
+// cover the whole input with no gaps or overlaps.
+func TestCalculateRabinBoundaries_RespectsMinAndMax(t *testing.T) {
+	data := make([]byte, 10*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	cfg := RabinChunkerConfig{MinSize: 256 * 1024, AvgSize: 1 * MiB, MaxSize: 2 * MiB}
+
+	chunks, err := CalculateRabinBoundaries(bytes.NewReader(data), cfg)
+	require.NoError(t, err)
+	require.NotEmpty(t, chunks)
+
+	var total int64
+	for i, chunk := range chunks {
+		assert.Equal(t, i, chunk.Number)
+		assert.Equal(t, total, chunk.Offset)
+		assert.LessOrEqual(t, chunk.Size, int64(cfg.MaxSize))
+		if i < len(chunks)-1 {
+			assert.GreaterOrEqual(t, chunk.Size, int64(cfg.MinSize))
+		}
+		total += chunk.Size
+	}
+	assert.Equal(t, int64(len(data)), total)
+}
+
+// TestCalculateRabinBoundaries_Deterministic verifies identical input always
+// cuts identically, since dedup depends on that.
+func TestCalculateRabinBoundaries_Deterministic(t *testing.T) {
+	data := make([]byte, 5*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	cfg := DefaultRabinChunkerConfig()
+	a, err := CalculateRabinBoundaries(bytes.NewReader(data), cfg)
+	require.NoError(t, err)
+	b, err := CalculateRabinBoundaries(bytes.NewReader(data), cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+// TestStreamRabinChunks_MatchesCalculateRabinBoundaries verifies the
+// streaming and boundary-only APIs agree, the way
+// TestStreamCDCChunks_MatchesCalculateCDCBoundaries does for the gear-hash
+// chunker.
+func TestStreamRabinChunks_MatchesCalculateRabinBoundaries(t *testing.T) {
+	data := make([]byte, 4*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	cfg := RabinChunkerConfig{MinSize: 128 * 1024, AvgSize: 512 * 1024, MaxSize: 1 * MiB}
+
+	boundaries, err := CalculateRabinBoundaries(bytes.NewReader(data), cfg)
+	require.NoError(t, err)
+
+	var streamed []ChunkInfo
+	var totalData int
+	err = StreamRabinChunks(bytes.NewReader(data), cfg, func(info ChunkInfo, chunkData []byte) error {
+		assert.Equal(t, int(info.Size), len(chunkData))
+		streamed = append(streamed, info)
+		totalData += len(chunkData)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, boundaries, streamed)
+	assert.Equal(t, len(data), totalData)
+}
+
+// TestCalculateRabinBoundaries_InvalidParams verifies out-of-order or
+// non-positive size bounds are rejected up front rather than producing
+// nonsensical chunks.
+func TestCalculateRabinBoundaries_InvalidParams(t *testing.T) {
+	_, err := CalculateRabinBoundaries(bytes.NewReader(nil), RabinChunkerConfig{MinSize: 0, AvgSize: 10, MaxSize: 20})
+	assert.ErrorIs(t, err, ErrInvalidCDCParams)
+
+	_, err = CalculateRabinBoundaries(bytes.NewReader(nil), RabinChunkerConfig{MinSize: 20, AvgSize: 10, MaxSize: 5})
+	assert.ErrorIs(t, err, ErrInvalidCDCParams)
+}
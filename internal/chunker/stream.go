@@ -0,0 +1,155 @@
+package chunker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ChunkPayload is one chunk's bytes, delivered by StreamChunks. Data is
+// borrowed from the bufPool StreamChunks was given and is only valid until
+// Release is called; callers must call Release exactly once, after they're
+// done with Data (e.g. once it's hashed and handed off to an upload), to
+// return the buffer to the pool.
+type ChunkPayload struct {
+	ChunkInfo
+	Data    []byte
+	Release func()
+}
+
+// NewChunkBufPool returns a *sync.Pool of reusable buffers, each maxChunkSize
+// bytes, for use with StreamChunks. maxChunkSize is the knob that bounds a
+// StreamChunks call's peak memory at roughly workers*maxChunkSize bytes,
+// regardless of how many chunks the file has - callers reading
+// DefaultMaxCDCChunkSize-bounded CDC chunks, or the even-split mode's
+// largest possible chunk, should size it accordingly.
+func NewChunkBufPool(maxChunkSize int) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, maxChunkSize)
+			return &buf
+		},
+	}
+}
+
+// StreamChunks fans the reads for chunks out across workers goroutines,
+// each borrowing its buffer from bufPool (see NewChunkBufPool) rather than
+// allocating fresh per chunk, so the call's in-flight memory stays bounded
+// at roughly workers*maxChunkSize bytes no matter how large the source file
+// is - the same maxConcurrentBlobs/freeBuf bounded-pool pattern restic's
+// archiver uses to stream arbitrarily large files without buffering them
+// whole. Completed reads are reordered back into ascending ChunkInfo.Number
+// order before being emitted, so a consumer (e.g. the S3 uploader) can
+// stream them sequentially - e.g. into a single running file hash - even
+// though the reads themselves happen out of order.
+//
+// Both returned channels are closed once every chunk has been emitted or a
+// read fails; callers must keep draining the payload channel until it
+// closes even after receiving an error, so no worker blocks forever trying
+// to send a result. Every emitted ChunkPayload's Release must be called to
+// return its buffer to bufPool.
+func StreamChunks(ctx context.Context, r io.ReaderAt, chunks []ChunkInfo, workers int, bufPool *sync.Pool) (<-chan ChunkPayload, <-chan error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan ChunkPayload, workers)
+	errCh := make(chan error, 1)
+
+	jobs := make(chan int, workers)
+	results := make(chan ChunkPayload, workers)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				chunk := chunks[idx]
+				bufPtr := bufPool.Get().(*[]byte)
+				buf := (*bufPtr)[:chunk.Size]
+				n, err := r.ReadAt(buf, chunk.Offset)
+				if err == io.EOF && n == len(buf) {
+					err = nil
+				}
+				if err != nil {
+					bufPool.Put(bufPtr)
+					select {
+					case errCh <- fmt.Errorf("failed to read chunk %d: %w", chunk.Number, err):
+					default:
+					}
+					cancel()
+					return
+				}
+
+				payload := ChunkPayload{
+					ChunkInfo: chunk,
+					Data:      buf,
+					Release: func() {
+						bufPool.Put(bufPtr)
+					},
+				}
+				select {
+				case results <- payload:
+				case <-ctx.Done():
+					bufPool.Put(bufPtr)
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range chunks {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+		close(errCh)
+	}()
+
+	go func() {
+		reorderChunkPayloads(ctx, results, out)
+		cancel()
+	}()
+
+	return out, errCh
+}
+
+// reorderChunkPayloads buffers payloads that arrive ahead of their turn,
+// emits them on out in ascending ChunkInfo.Number order starting at 0, and
+// closes out once results is drained and closed.
+func reorderChunkPayloads(ctx context.Context, results <-chan ChunkPayload, out chan<- ChunkPayload) {
+	defer close(out)
+
+	pending := make(map[int]ChunkPayload)
+	next := 0
+
+	for payload := range results {
+		pending[payload.Number] = payload
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			select {
+			case out <- ready:
+			case <-ctx.Done():
+				return
+			}
+			next++
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func drainStreamChunks(t *testing.T, out <-chan ChunkPayload, errCh <-chan error) ([]ChunkPayload, error) {
+	t.Helper()
+	var payloads []ChunkPayload
+	for out != nil || errCh != nil {
+		select {
+		case payload, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			data := append([]byte(nil), payload.Data...)
+			payload.Release()
+			payload.Data = data
+			payloads = append(payloads, payload)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if err != nil {
+				return payloads, err
+			}
+		}
+	}
+	return payloads, nil
+}
+
+// TestStreamChunks_EmitsInOrderAndMatchesSource verifies StreamChunks
+// reassembles the exact bytes CalculateChunkBoundaries split, in ascending
+// chunk-number order, even though reads happen across multiple workers.
+func TestStreamChunks_EmitsInOrderAndMatchesSource(t *testing.T) {
+	data := make([]byte, 5*MiB+123)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	boundaries, err := CalculateChunkBoundaries(int64(len(data)), 7)
+	require.NoError(t, err)
+
+	bufPool := NewChunkBufPool(1 * MiB)
+	out, errCh := StreamChunks(context.Background(), bytes.NewReader(data), boundaries, 4, bufPool)
+
+	payloads, err := drainStreamChunks(t, out, errCh)
+	require.NoError(t, err)
+	require.Len(t, payloads, len(boundaries))
+
+	for i, payload := range payloads {
+		assert.Equal(t, i, payload.Number, "chunk %d out of order", i)
+		want := data[payload.Offset : payload.Offset+payload.Size]
+		assert.Equal(t, want, payload.Data)
+	}
+}
+
+// TestStreamChunks_SingleWorker verifies StreamChunks works with the
+// degenerate single-worker case the same way the parallel case does.
+func TestStreamChunks_SingleWorker(t *testing.T) {
+	data := make([]byte, 2*MiB)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	boundaries, err := CalculateChunkBoundaries(int64(len(data)), 4)
+	require.NoError(t, err)
+
+	bufPool := NewChunkBufPool(1 * MiB)
+	out, errCh := StreamChunks(context.Background(), bytes.NewReader(data), boundaries, 1, bufPool)
+
+	payloads, err := drainStreamChunks(t, out, errCh)
+	require.NoError(t, err)
+	require.Len(t, payloads, len(boundaries))
+}
+
+// TestStreamChunks_ReadErrorIsReported verifies a read failure surfaces on
+// the error channel instead of hanging or panicking.
+func TestStreamChunks_ReadErrorIsReported(t *testing.T) {
+	boundaries := []ChunkInfo{{Number: 0, Offset: 0, Size: 16}}
+	bufPool := NewChunkBufPool(16)
+	out, errCh := StreamChunks(context.Background(), bytes.NewReader(make([]byte, 4)), boundaries, 2, bufPool)
+
+	_, err := drainStreamChunks(t, out, errCh)
+	assert.Error(t, err)
+}
+
+// zeroReaderAt satisfies io.ReaderAt over an effectively unlimited run of
+// zero bytes without allocating a backing array, so benchmarks can exercise
+// a 10 GiB "source" without actually holding 10 GiB in memory.
+type zeroReaderAt struct{ size int64 }
+
+func (z zeroReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= z.size {
+		return 0, nil
+	}
+	n := len(p)
+	if off+int64(n) > z.size {
+		n = int(z.size - off)
+	}
+	for i := range p[:n] {
+		p[i] = 0
+	}
+	return n, nil
+}
+
+// BenchmarkStreamChunks compares single-worker throughput to an N-worker
+// pool reading the same 10 GiB source, the max-file-size case
+// TestSplitFile_MaxSize exercises for CalculateChunkBoundaries.
+func BenchmarkStreamChunks(b *testing.B) {
+	const fileSize = 10 * GiB
+	const numChunks = 64
+
+	boundaries, err := CalculateChunkBoundaries(fileSize, numChunks)
+	require.NoError(b, err)
+	maxChunkSize := 0
+	for _, c := range boundaries {
+		if int(c.Size) > maxChunkSize {
+			maxChunkSize = int(c.Size)
+		}
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			src := zeroReaderAt{size: fileSize}
+			bufPool := NewChunkBufPool(maxChunkSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				out, errCh := StreamChunks(context.Background(), src, boundaries, workers, bufPool)
+				for out != nil || errCh != nil {
+					select {
+					case payload, ok := <-out:
+						if !ok {
+							out = nil
+							continue
+						}
+						payload.Release()
+					case _, ok := <-errCh:
+						if !ok {
+							errCh = nil
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
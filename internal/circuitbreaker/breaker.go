@@ -37,34 +37,126 @@ func (s State) String() string {
 	}
 }
 
+// outcome is one call's result, as recorded in a CircuitBreaker's ring
+// buffer: ok, or failed, or slow (took longer than Config.SlowCallDuration).
+// A call can be both failed and slow; each counts toward both rates.
+type outcome struct {
+	at      time.Time
+	failed  bool
+	slow    bool
+	counted bool // false for unused/expired ring buffer slots
+}
+
 // Config holds circuit breaker configuration
 type Config struct {
-	// MaxFailures is the number of consecutive failures before opening
+	// MaxFailures is kept for backward compatibility: if set (non-zero)
+	// alongside WindowSize/WindowDuration both zero, the breaker falls back
+	// to the original consecutive-failure behavior instead of rate-based
+	// evaluation. New callers should prefer WindowSize/WindowDuration.
 	MaxFailures int
 	// OpenTimeout is how long to wait before transitioning to half-open
 	OpenTimeout time.Duration
-	// HalfOpenMaxRequests is the number of requests to allow in half-open state
+	// HalfOpenMaxRequests is kept for backward compatibility; new callers
+	// should set PermittedCallsInHalfOpen instead. If PermittedCallsInHalfOpen
+	// is 0, HalfOpenMaxRequests is used as its default.
 	HalfOpenMaxRequests int
+
+	// WindowSize is the number of most recent calls to evaluate the
+	// failure/slow-call rate over (a count-based sliding window); 0 means
+	// 20. If WindowDuration is also set, WindowSize still caps how many of
+	// the most recent calls the time window can hold.
+	WindowSize int
+	// WindowDuration is the span of time to evaluate the failure/slow-call
+	// rate over (a time-based sliding window), evicting outcomes older than
+	// now-WindowDuration before each evaluation. Takes precedence over
+	// WindowSize if both are set.
+	WindowDuration time.Duration
+	// FailureRateThreshold opens the circuit when the fraction of failed
+	// calls in the window exceeds this value (0.0-1.0), once MinimumCalls
+	// have been observed.
+	FailureRateThreshold float64
+	// SlowCallThreshold opens the circuit when the fraction of slow calls
+	// in the window exceeds this value (0.0-1.0), once MinimumCalls have
+	// been observed.
+	SlowCallThreshold float64
+	// SlowCallDuration is the Execute call latency above which a call
+	// counts as "slow" for SlowCallThreshold.
+	SlowCallDuration time.Duration
+	// MinimumCalls is the number of calls that must have landed in the
+	// window before failure/slow-call rates are evaluated at all, so a
+	// single failure right after the window empties can't trip the
+	// breaker on a 100% sample of one.
+	MinimumCalls int
+	// PermittedCallsInHalfOpen is the number of probe calls allowed through
+	// while half-open; the circuit closes if enough of them succeed (see
+	// onSuccess) and reopens on the first failure.
+	PermittedCallsInHalfOpen int
 }
 
-// DefaultConfig returns default circuit breaker configuration
+// DefaultConfig returns default circuit breaker configuration: a 20-call
+// sliding window, opening at a 50% failure rate or a 50% slow-call rate
+// (calls over 5s) once at least 10 calls have landed, with 3 half-open
+// probes.
 func DefaultConfig() *Config {
 	return &Config{
-		MaxFailures:         5,
-		OpenTimeout:         30 * time.Second,
-		HalfOpenMaxRequests: 3,
+		OpenTimeout:              30 * time.Second,
+		WindowSize:               20,
+		FailureRateThreshold:     0.5,
+		SlowCallThreshold:        0.5,
+		SlowCallDuration:         5 * time.Second,
+		MinimumCalls:             10,
+		PermittedCallsInHalfOpen: 3,
+	}
+}
+
+// usesLegacyConsecutiveFailures reports whether this Config describes the
+// original consecutive-failure breaker instead of the rate-based one: true
+// when MaxFailures is set and neither windowing knob is.
+func (c *Config) usesLegacyConsecutiveFailures() bool {
+	return c.MaxFailures > 0 && c.WindowSize == 0 && c.WindowDuration == 0
+}
+
+// halfOpenBudget returns the configured number of half-open probes,
+// preferring PermittedCallsInHalfOpen and falling back to the legacy
+// HalfOpenMaxRequests field.
+func (c *Config) halfOpenBudget() int {
+	if c.PermittedCallsInHalfOpen > 0 {
+		return c.PermittedCallsInHalfOpen
 	}
+	return c.HalfOpenMaxRequests
 }
 
-// CircuitBreaker implements the circuit breaker pattern
+// CircuitBreaker implements the circuit breaker pattern over a sliding
+// window of call outcomes: it opens when the window's failure rate or
+// slow-call rate crosses its configured threshold (or, in legacy
+// MaxFailures mode, on that many consecutive failures), and half-opens
+// after OpenTimeout to probe whether the downstream has recovered.
 type CircuitBreaker struct {
-	config           *Config
-	state            State
-	failures         int
-	successes        int
-	lastFailureTime  time.Time
-	halfOpenRequests int
-	mu               sync.RWMutex
+	config *Config
+	mu     sync.RWMutex
+
+	state           State
+	lastFailureTime time.Time
+
+	// ring is the sliding window's backing store. head is the index the
+	// next outcome is written to; count is how many of ring's slots hold a
+	// live (non-expired, for WindowDuration mode) outcome.
+	ring  []outcome
+	head  int
+	count int
+
+	// Aggregates maintained incrementally alongside ring/head/count so
+	// GetState/evaluate don't need to rescan the whole window: each append
+	// adds to these, and each eviction (of an expired or overwritten slot)
+	// subtracts from them, giving O(1) summary maintenance.
+	failedCount int
+	slowCount   int
+
+	// consecutiveFailures backs the legacy MaxFailures mode only.
+	consecutiveFailures int
+
+	halfOpenProbes    int
+	halfOpenSuccesses int
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -73,24 +165,32 @@ func NewCircuitBreaker(config *Config) *CircuitBreaker {
 		config = DefaultConfig()
 	}
 
+	size := config.WindowSize
+	if size <= 0 {
+		size = 20
+	}
+
 	return &CircuitBreaker{
 		config: config,
 		state:  StateClosed,
+		ring:   make([]outcome, size),
 	}
 }
 
-// Execute executes a function with circuit breaker protection
+// Execute executes a function with circuit breaker protection, measuring
+// its latency so Config.SlowCallDuration/SlowCallThreshold can factor it
+// into the breaker's decisions.
 func (cb *CircuitBreaker) Execute(fn func() error) error {
 	// Check if we can execute
 	if err := cb.beforeRequest(); err != nil {
 		return err
 	}
 
-	// Execute function
+	start := time.Now()
 	err := fn()
+	latency := time.Since(start)
 
-	// Record result
-	cb.afterRequest(err)
+	cb.afterRequest(err, latency)
 
 	return err
 }
@@ -109,17 +209,19 @@ func (cb *CircuitBreaker) beforeRequest() error {
 		// Check if we should transition to half-open
 		if time.Since(cb.lastFailureTime) > cb.config.OpenTimeout {
 			cb.state = StateHalfOpen
-			cb.halfOpenRequests = 0
+			// This call is the first probe.
+			cb.halfOpenProbes = 1
+			cb.halfOpenSuccesses = 0
 			return nil
 		}
 		return ErrCircuitOpen
 
 	case StateHalfOpen:
 		// Allow limited requests in half-open state
-		if cb.halfOpenRequests >= cb.config.HalfOpenMaxRequests {
+		if cb.halfOpenProbes >= cb.config.halfOpenBudget() {
 			return ErrCircuitOpen
 		}
-		cb.halfOpenRequests++
+		cb.halfOpenProbes++
 		return nil
 
 	default:
@@ -127,53 +229,139 @@ func (cb *CircuitBreaker) beforeRequest() error {
 	}
 }
 
-// afterRequest records the result of a request
-func (cb *CircuitBreaker) afterRequest(err error) {
+// afterRequest records the result (and latency) of a request
+func (cb *CircuitBreaker) afterRequest(err error, latency time.Duration) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	if err != nil {
-		cb.onFailure()
+		cb.onFailure(latency)
 	} else {
-		cb.onSuccess()
+		cb.onSuccess(latency)
+	}
+}
+
+// recordOutcome appends an outcome to the ring buffer, evicting whatever
+// slot it overwrites (and, in WindowDuration mode, anything older than the
+// window) from the running failedCount/slowCount aggregates first.
+func (cb *CircuitBreaker) recordOutcome(failed, slow bool) {
+	now := time.Now()
+	cb.evictExpired(now)
+
+	evicted := cb.ring[cb.head]
+	if evicted.counted {
+		cb.subtract(evicted)
+	} else {
+		cb.count++
+	}
+
+	cb.ring[cb.head] = outcome{at: now, failed: failed, slow: slow, counted: true}
+	cb.add(cb.ring[cb.head])
+	cb.head = (cb.head + 1) % len(cb.ring)
+}
+
+// evictExpired drops ring entries older than now-WindowDuration, for
+// time-window mode. It's a no-op when WindowDuration isn't configured.
+func (cb *CircuitBreaker) evictExpired(now time.Time) {
+	if cb.config.WindowDuration <= 0 {
+		return
+	}
+	cutoff := now.Add(-cb.config.WindowDuration)
+	for i := range cb.ring {
+		o := cb.ring[i]
+		if o.counted && o.at.Before(cutoff) {
+			cb.subtract(o)
+			cb.ring[i].counted = false
+			cb.count--
+		}
+	}
+}
+
+func (cb *CircuitBreaker) add(o outcome) {
+	if o.failed {
+		cb.failedCount++
+	}
+	if o.slow {
+		cb.slowCount++
+	}
+}
+
+func (cb *CircuitBreaker) subtract(o outcome) {
+	if o.failed {
+		cb.failedCount--
+	}
+	if o.slow {
+		cb.slowCount--
 	}
 }
 
+// shouldOpen reports whether the current window's failure rate or
+// slow-call rate has crossed its configured threshold, given at least
+// MinimumCalls samples.
+func (cb *CircuitBreaker) shouldOpen() bool {
+	if cb.count < cb.config.MinimumCalls {
+		return false
+	}
+	if cb.config.FailureRateThreshold > 0 && float64(cb.failedCount)/float64(cb.count) > cb.config.FailureRateThreshold {
+		return true
+	}
+	if cb.config.SlowCallThreshold > 0 && float64(cb.slowCount)/float64(cb.count) > cb.config.SlowCallThreshold {
+		return true
+	}
+	return false
+}
+
 // onFailure handles a failed request
-func (cb *CircuitBreaker) onFailure() {
-	cb.failures++
+func (cb *CircuitBreaker) onFailure(latency time.Duration) {
 	cb.lastFailureTime = time.Now()
-	cb.successes = 0
+	cb.consecutiveFailures++
+
+	if cb.config.usesLegacyConsecutiveFailures() {
+		if cb.state == StateClosed && cb.consecutiveFailures >= cb.config.MaxFailures {
+			cb.state = StateOpen
+		} else if cb.state == StateHalfOpen {
+			cb.state = StateOpen
+			cb.halfOpenProbes = 0
+		}
+		return
+	}
+
+	slow := cb.config.SlowCallDuration > 0 && latency > cb.config.SlowCallDuration
+	cb.recordOutcome(true, slow)
 
 	switch cb.state {
 	case StateClosed:
-		if cb.failures >= cb.config.MaxFailures {
+		if cb.shouldOpen() {
 			cb.state = StateOpen
 		}
-
 	case StateHalfOpen:
-		// Any failure in half-open state reopens the circuit
+		// Any failed probe reopens the circuit.
 		cb.state = StateOpen
-		cb.halfOpenRequests = 0
+		cb.halfOpenProbes = 0
 	}
 }
 
 // onSuccess handles a successful request
-func (cb *CircuitBreaker) onSuccess() {
-	cb.successes++
+func (cb *CircuitBreaker) onSuccess(latency time.Duration) {
+	cb.consecutiveFailures = 0
 
-	switch cb.state {
-	case StateClosed:
-		// Reset failure count on success
-		cb.failures = 0
+	if cb.config.usesLegacyConsecutiveFailures() {
+		if cb.state == StateHalfOpen {
+			cb.halfOpenSuccesses++
+			if cb.halfOpenSuccesses >= cb.config.halfOpenBudget() {
+				cb.resetLocked()
+			}
+		}
+		return
+	}
 
-	case StateHalfOpen:
-		// After enough successes in half-open, close the circuit
-		if cb.successes >= cb.config.HalfOpenMaxRequests {
-			cb.state = StateClosed
-			cb.failures = 0
-			cb.successes = 0
-			cb.halfOpenRequests = 0
+	slow := cb.config.SlowCallDuration > 0 && latency > cb.config.SlowCallDuration
+	cb.recordOutcome(false, slow)
+
+	if cb.state == StateHalfOpen {
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.config.halfOpenBudget() {
+			cb.resetLocked()
 		}
 	}
 }
@@ -185,20 +373,34 @@ func (cb *CircuitBreaker) GetState() State {
 	return cb.state
 }
 
-// GetFailures returns the current failure count
+// GetFailures returns the number of failed calls currently counted in the
+// sliding window (or, in legacy MaxFailures mode, the consecutive failure
+// count).
 func (cb *CircuitBreaker) GetFailures() int {
 	cb.mu.RLock()
 	defer cb.mu.RUnlock()
-	return cb.failures
+	if cb.config.usesLegacyConsecutiveFailures() {
+		return cb.consecutiveFailures
+	}
+	return cb.failedCount
 }
 
-// Reset resets the circuit breaker to closed state
+// Reset resets the circuit breaker to closed state and clears its window.
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	cb.resetLocked()
+}
 
+// resetLocked is Reset's body, for callers that already hold cb.mu.
+func (cb *CircuitBreaker) resetLocked() {
 	cb.state = StateClosed
-	cb.failures = 0
-	cb.successes = 0
-	cb.halfOpenRequests = 0
+	cb.consecutiveFailures = 0
+	cb.halfOpenProbes = 0
+	cb.halfOpenSuccesses = 0
+	cb.ring = make([]outcome, len(cb.ring))
+	cb.head = 0
+	cb.count = 0
+	cb.failedCount = 0
+	cb.slowCount = 0
 }
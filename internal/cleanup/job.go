@@ -2,12 +2,13 @@ package cleanup
 
 import (
 	"context"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/metacache"
 	"github.com/s3storage/internal/storage"
 	"google.golang.org/grpc"
 )
@@ -21,19 +22,27 @@ const (
 
 // CleanupJob handles cleanup of expired upload sessions and orphaned chunks
 type CleanupJob struct {
-	storage        *storage.PostgresStorage
+	// storage is a *metacache.Cache rather than a plain
+	// *storage.PostgresStorage so GetChunksByFileID and DeleteFile benefit
+	// from the same metadata cache UploadFile/DownloadFile use. Every other
+	// call below - notably GetExpiredSessions, which must always see
+	// fresh data - passes through metacache.Cache's embedded
+	// *storage.PostgresStorage untouched.
+	storage        *metacache.Cache
 	storageClients map[uuid.UUID]*grpc.ClientConn
 	clientsMu      *sync.RWMutex
 	interval       time.Duration
 	stopChan       chan struct{}
 	wg             sync.WaitGroup
+	logger         logr.Logger
 }
 
 // NewCleanupJob creates a new cleanup job
 func NewCleanupJob(
-	storage *storage.PostgresStorage,
+	storage *metacache.Cache,
 	storageClients map[uuid.UUID]*grpc.ClientConn,
 	clientsMu *sync.RWMutex,
+	logger logr.Logger,
 ) *CleanupJob {
 	return &CleanupJob{
 		storage:        storage,
@@ -41,15 +50,17 @@ func NewCleanupJob(
 		clientsMu:      clientsMu,
 		interval:       DefaultCleanupInterval,
 		stopChan:       make(chan struct{}),
+		logger:         logger,
 	}
 }
 
 // NewCleanupJobWithInterval creates a new cleanup job with custom interval
 func NewCleanupJobWithInterval(
-	storage *storage.PostgresStorage,
+	storage *metacache.Cache,
 	storageClients map[uuid.UUID]*grpc.ClientConn,
 	clientsMu *sync.RWMutex,
 	interval time.Duration,
+	logger logr.Logger,
 ) *CleanupJob {
 	return &CleanupJob{
 		storage:        storage,
@@ -57,6 +68,7 @@ func NewCleanupJobWithInterval(
 		clientsMu:      clientsMu,
 		interval:       interval,
 		stopChan:       make(chan struct{}),
+		logger:         logger,
 	}
 }
 
@@ -64,14 +76,14 @@ func NewCleanupJobWithInterval(
 func (j *CleanupJob) Start(ctx context.Context) {
 	j.wg.Add(1)
 	go j.run(ctx)
-	log.Printf("Cleanup job started (interval: %v)", j.interval)
+	j.logger.Info("cleanup job started", "interval", j.interval)
 }
 
 // Stop stops the cleanup job
 func (j *CleanupJob) Stop() {
 	close(j.stopChan)
 	j.wg.Wait()
-	log.Println("Cleanup job stopped")
+	j.logger.Info("cleanup job stopped")
 }
 
 // run is the main cleanup loop
@@ -83,14 +95,14 @@ func (j *CleanupJob) run(ctx context.Context) {
 
 	// Run cleanup immediately on start
 	if err := j.cleanupExpiredSessions(ctx); err != nil {
-		log.Printf("Error during initial cleanup: %v", err)
+		j.logger.Error(err, "error during initial cleanup")
 	}
 
 	for {
 		select {
 		case <-ticker.C:
 			if err := j.cleanupExpiredSessions(ctx); err != nil {
-				log.Printf("Error during cleanup: %v", err)
+				j.logger.Error(err, "error during cleanup")
 			}
 		case <-j.stopChan:
 			return
@@ -109,31 +121,32 @@ func (j *CleanupJob) cleanupExpiredSessions(ctx context.Context) error {
 	}
 
 	if len(sessions) == 0 {
-		log.Println("No expired sessions to clean up")
+		j.logger.Info("no expired sessions to clean up")
 		return nil
 	}
 
-	log.Printf("Found %d expired sessions to clean up", len(sessions))
+	j.logger.Info("found expired sessions to clean up", "count", len(sessions))
 
 	cleanedCount := 0
 	errorCount := 0
 
 	for _, session := range sessions {
 		if err := j.cleanupSession(ctx, session); err != nil {
-			log.Printf("Error cleaning up session %s: %v", session.SessionID, err)
+			j.logger.Error(err, "error cleaning up session", "session_id", session.SessionID)
 			errorCount++
 		} else {
 			cleanedCount++
 		}
 	}
 
-	log.Printf("Cleanup completed: %d sessions cleaned, %d errors", cleanedCount, errorCount)
+	j.logger.Info("cleanup completed", "cleaned_count", cleanedCount, "error_count", errorCount)
 	return nil
 }
 
 // cleanupSession cleans up a single expired session
 func (j *CleanupJob) cleanupSession(ctx context.Context, session *storage.UploadSession) error {
-	log.Printf("Cleaning up expired session %s for file %s", session.SessionID, session.FileID)
+	logger := j.logger.WithValues("session_id", session.SessionID, "file_id", session.FileID)
+	logger.Info("cleaning up expired session")
 
 	// Get chunks associated with this file
 	chunks, err := j.storage.GetChunksByFileID(ctx, session.FileID)
@@ -145,24 +158,23 @@ func (j *CleanupJob) cleanupSession(ctx context.Context, session *storage.Upload
 	deletedChunks := 0
 	for _, chunk := range chunks {
 		if err := j.deleteChunkFromServer(ctx, chunk); err != nil {
-			log.Printf("Warning: failed to delete chunk %s from server %s: %v",
-				chunk.ChunkID, chunk.StorageServerID, err)
+			logger.Error(err, "failed to delete chunk", "chunk_id", chunk.ChunkID, "server_id", chunk.StorageServerID)
 			// Continue with other chunks even if one fails
 		} else {
 			deletedChunks++
 		}
 	}
 
-	log.Printf("Deleted %d/%d chunks for session %s", deletedChunks, len(chunks), session.SessionID)
+	logger.Info("deleted chunks for session", "deleted_chunks", deletedChunks, "total_chunks", len(chunks))
 
 	// Delete file record (this will cascade delete chunks via foreign key)
 	if err := j.storage.DeleteFile(ctx, session.FileID); err != nil {
-		log.Printf("Warning: failed to delete file record %s: %v", session.FileID, err)
+		logger.Error(err, "failed to delete file record")
 	}
 
 	// Delete session record
 	if err := j.storage.DeleteUploadSession(ctx, session.SessionID); err != nil {
-		log.Printf("Warning: failed to delete session record %s: %v", session.SessionID, err)
+		logger.Error(err, "failed to delete session record")
 	}
 
 	return nil
@@ -177,8 +189,8 @@ func (j *CleanupJob) deleteChunkFromServer(ctx context.Context, chunk *storage.C
 
 	if !exists {
 		// Server might be offline, log and continue
-		log.Printf("Storage server %s not available for chunk %s deletion",
-			chunk.StorageServerID, chunk.ChunkID)
+		j.logger.Info("storage server not available for chunk deletion",
+			"server_id", chunk.StorageServerID, "chunk_id", chunk.ChunkID)
 		return nil
 	}
 
@@ -199,8 +211,8 @@ func (j *CleanupJob) deleteChunkFromServer(ctx context.Context, chunk *storage.C
 	}
 
 	if !resp.Success {
-		log.Printf("Failed to delete chunk %s from server %s",
-			chunk.ChunkID, chunk.StorageServerID)
+		j.logger.Info("failed to delete chunk from server",
+			"chunk_id", chunk.ChunkID, "server_id", chunk.StorageServerID)
 	}
 
 	return nil
@@ -0,0 +1,148 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/storage"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultCompactorInterval is the default interval between pending
+	// chunk delete retry sweeps.
+	DefaultCompactorInterval = 5 * time.Minute
+	// MaxPendingChunkDeleteAttempts is how many times the compactor retries
+	// a pending chunk delete before giving up and leaving it for manual
+	// investigation.
+	MaxPendingChunkDeleteAttempts = 5
+)
+
+// VersionCompactor retries DeleteChunk RPCs for chunks that were orphaned
+// by a permanent object-version deletion whose initial delete attempt
+// failed (e.g. the owning storage server was briefly unreachable), so disk
+// space from permanently-deleted versions doesn't leak.
+type VersionCompactor struct {
+	storage        *storage.PostgresStorage
+	storageClients map[uuid.UUID]*grpc.ClientConn
+	clientsMu      *sync.RWMutex
+	interval       time.Duration
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+	logger         logr.Logger
+}
+
+// NewVersionCompactor creates a VersionCompactor with the default interval.
+func NewVersionCompactor(
+	storage *storage.PostgresStorage,
+	storageClients map[uuid.UUID]*grpc.ClientConn,
+	clientsMu *sync.RWMutex,
+	logger logr.Logger,
+) *VersionCompactor {
+	return &VersionCompactor{
+		storage:        storage,
+		storageClients: storageClients,
+		clientsMu:      clientsMu,
+		interval:       DefaultCompactorInterval,
+		stopChan:       make(chan struct{}),
+		logger:         logger,
+	}
+}
+
+// Start starts the compactor's background loop.
+func (v *VersionCompactor) Start(ctx context.Context) {
+	v.wg.Add(1)
+	go v.run(ctx)
+	v.logger.Info("version compactor started", "interval", v.interval)
+}
+
+// Stop stops the compactor's background loop.
+func (v *VersionCompactor) Stop() {
+	close(v.stopChan)
+	v.wg.Wait()
+	v.logger.Info("version compactor stopped")
+}
+
+func (v *VersionCompactor) run(ctx context.Context) {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	if err := v.compact(ctx); err != nil {
+		v.logger.Error(err, "error during initial sweep")
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := v.compact(ctx); err != nil {
+				v.logger.Error(err, "error during sweep")
+			}
+		case <-v.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// compact retries every queued pending chunk delete, giving up (but leaving
+// the row in place for manual investigation) once it has been retried
+// MaxPendingChunkDeleteAttempts times.
+func (v *VersionCompactor) compact(ctx context.Context) error {
+	pending, err := v.storage.GetPendingChunkDeletes(ctx, 100)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if p.Attempts >= MaxPendingChunkDeleteAttempts {
+			continue
+		}
+
+		if err := v.retryDelete(ctx, p); err != nil {
+			v.logger.Error(err, "retry failed for chunk",
+				"attempt", p.Attempts+1, "max_attempts", MaxPendingChunkDeleteAttempts, "chunk_id", p.ChunkID)
+			if incErr := v.storage.IncrementPendingChunkDeleteAttempts(ctx, p.ID); incErr != nil {
+				v.logger.Error(incErr, "failed to record retry attempt", "pending_delete_id", p.ID)
+			}
+			continue
+		}
+
+		if err := v.storage.DeletePendingChunkDelete(ctx, p.ID); err != nil {
+			v.logger.Error(err, "failed to clear pending delete", "pending_delete_id", p.ID)
+		}
+	}
+
+	return nil
+}
+
+func (v *VersionCompactor) retryDelete(ctx context.Context, p *storage.PendingChunkDelete) error {
+	v.clientsMu.RLock()
+	conn, exists := v.storageClients[p.ServerID]
+	v.clientsMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("storage server %s not available", p.ServerID)
+	}
+
+	client := pb.NewStorageServiceClient(conn)
+
+	deleteCtx, cancel := context.WithTimeout(ctx, ChunkDeleteTimeout)
+	defer cancel()
+
+	resp, err := client.DeleteChunk(deleteCtx, &pb.DeleteChunkRequest{ChunkId: p.ChunkID.String()})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("storage server %s reported failure deleting chunk %s", p.ServerID, p.ChunkID)
+	}
+
+	return nil
+}
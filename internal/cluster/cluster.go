@@ -0,0 +1,259 @@
+// Package cluster provides gossip-based storage-server membership on top of
+// hashicorp/memberlist, so ring topology converges across the fleet in
+// sub-second time instead of waiting on the gateway's DB-polling refresh
+// loop (see api.APIGateway.RefreshHashRing). Each storage server joins the
+// cluster on startup and gossips its own identity and capacity; the gateway
+// joins as a member too and reacts to NotifyJoin/NotifyLeave/NotifyUpdate by
+// updating its hasher.PlacementRing directly, with the DB-polling loop kept
+// running as a slower fallback reconciliation path.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/memberlist"
+)
+
+// Member describes a storage server as gossiped across the cluster.
+type Member struct {
+	ServerID       uuid.UUID `json:"server_id"`
+	GRPCAddress    string    `json:"grpc_address"`
+	AvailableBytes int64     `json:"available_bytes"`
+	UsedBytes      int64     `json:"used_bytes"`
+	// Draining is set by SetDraining while a member finishes in-flight
+	// streams before shutting down, so peers can stop selecting it for new
+	// writes well before its gRPC server actually stops.
+	Draining bool `json:"draining"`
+}
+
+// Config configures a Cluster. ServerID/GRPCAddress/capacity fields describe
+// the local node's metadata, gossiped to peers on join and whenever
+// UpdateLocalMetadata is called.
+type Config struct {
+	ServerID       uuid.UUID
+	GRPCAddress    string
+	AvailableBytes int64
+	UsedBytes      int64
+
+	// BindAddr/BindPort is the memberlist gossip listener, distinct from
+	// GRPCAddress (the chunk-transfer endpoint peers learn about).
+	BindAddr string
+	BindPort int
+
+	// Seeds are existing cluster members' gossip addresses (host:port) to
+	// contact on Join. Empty means start a new single-node cluster, which
+	// is only useful for the first node or local testing.
+	Seeds []string
+
+	// HealthProbe, if non-nil, is called with a peer's GRPCAddress before
+	// NotifyLeave is treated as authoritative. This absorbs transient
+	// network hiccups that would otherwise cause ring churn: a suspect
+	// member that still answers a direct health check is not reported to
+	// OnLeave. nil disables the probe, trusting memberlist's own SWIM
+	// failure detection outright.
+	HealthProbe func(ctx context.Context, grpcAddress string) error
+	// HealthProbeTimeout bounds a single HealthProbe call. Defaults to 3s.
+	HealthProbeTimeout time.Duration
+
+	// OnJoin/OnLeave/OnUpdate, if set, are called for every member event
+	// other than the local node's own. They're the hook a caller uses to
+	// keep a hasher.PlacementRing (or anything else) in sync with cluster
+	// membership without this package depending on the hasher package.
+	OnJoin   func(Member)
+	OnLeave  func(Member)
+	OnUpdate func(Member)
+}
+
+// Cluster wraps a memberlist.Memberlist, gossiping the local Member and
+// forwarding membership change events to the callbacks in Config.
+type Cluster struct {
+	ml     *memberlist.Memberlist
+	cfg    Config
+	local  Member
+	events *eventDelegate
+}
+
+// New creates a Cluster for the local member described by cfg but does not
+// join the gossip ring yet; call Join for that.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.HealthProbeTimeout <= 0 {
+		cfg.HealthProbeTimeout = 3 * time.Second
+	}
+
+	local := Member{
+		ServerID:       cfg.ServerID,
+		GRPCAddress:    cfg.GRPCAddress,
+		AvailableBytes: cfg.AvailableBytes,
+		UsedBytes:      cfg.UsedBytes,
+	}
+
+	c := &Cluster{cfg: cfg, local: local}
+
+	mlConfig := memberlist.DefaultLANConfig()
+	mlConfig.Name = cfg.ServerID.String()
+	if cfg.BindAddr != "" {
+		mlConfig.BindAddr = cfg.BindAddr
+	}
+	if cfg.BindPort > 0 {
+		mlConfig.BindPort = cfg.BindPort
+		mlConfig.AdvertisePort = cfg.BindPort
+	}
+	mlConfig.Delegate = &metadataDelegate{c: c}
+
+	events := &eventDelegate{c: c}
+	mlConfig.Events = events
+	c.events = events
+
+	ml, err := memberlist.Create(mlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating memberlist: %w", err)
+	}
+	c.ml = ml
+
+	return c, nil
+}
+
+// Join contacts cfg.Seeds and returns how many were successfully reached.
+// An empty Seeds list is not an error - it starts a new single-node
+// cluster, the expected case for the very first member.
+func (c *Cluster) Join() (int, error) {
+	if len(c.cfg.Seeds) == 0 {
+		return 0, nil
+	}
+	n, err := c.ml.Join(c.cfg.Seeds)
+	if err != nil {
+		return n, fmt.Errorf("joining cluster seeds %v: %w", c.cfg.Seeds, err)
+	}
+	return n, nil
+}
+
+// UpdateLocalMetadata changes the gossiped available/used bytes for the
+// local node and broadcasts the update to the rest of the cluster.
+func (c *Cluster) UpdateLocalMetadata(availableBytes, usedBytes int64) error {
+	c.local.AvailableBytes = availableBytes
+	c.local.UsedBytes = usedBytes
+	if err := c.ml.UpdateNode(10 * time.Second); err != nil {
+		return fmt.Errorf("broadcasting local metadata update: %w", err)
+	}
+	return nil
+}
+
+// SetDraining gossips the local node's draining state to the rest of the
+// cluster. A caller sets it true at the start of a graceful shutdown, before
+// the configurable grace period and GracefulStop - see cmd/storage-server's
+// shutdown sequence - so OnUpdate observers (the gateway's HandleMemberUpdate)
+// can pull this node out of new-write placement ahead of it actually
+// refusing connections.
+func (c *Cluster) SetDraining(draining bool) error {
+	c.local.Draining = draining
+	if err := c.ml.UpdateNode(10 * time.Second); err != nil {
+		return fmt.Errorf("broadcasting draining state: %w", err)
+	}
+	return nil
+}
+
+// Members returns every currently live member, including the local node.
+func (c *Cluster) Members() []Member {
+	nodes := c.ml.Members()
+	members := make([]Member, 0, len(nodes))
+	for _, n := range nodes {
+		m, ok := decodeMember(n)
+		if ok {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// Shutdown leaves the cluster gracefully and releases the gossip listener.
+func (c *Cluster) Shutdown() error {
+	if err := c.ml.Leave(5 * time.Second); err != nil {
+		return fmt.Errorf("leaving cluster: %w", err)
+	}
+	return c.ml.Shutdown()
+}
+
+func decodeMember(n *memberlist.Node) (Member, bool) {
+	if len(n.Meta) == 0 {
+		return Member{}, false
+	}
+	var m Member
+	if err := json.Unmarshal(n.Meta, &m); err != nil {
+		return Member{}, false
+	}
+	return m, true
+}
+
+// metadataDelegate implements memberlist.Delegate just enough to attach
+// Member as JSON-encoded node metadata; this cluster has no use for
+// application messages or anti-entropy push/pull state, so those methods
+// are no-ops.
+type metadataDelegate struct {
+	c *Cluster
+}
+
+func (d *metadataDelegate) NodeMeta(limit int) []byte {
+	b, err := json.Marshal(d.c.local)
+	if err != nil || len(b) > limit {
+		return nil
+	}
+	return b
+}
+
+func (d *metadataDelegate) NotifyMsg([]byte)                           {}
+func (d *metadataDelegate) GetBroadcasts(overhead, limit int) [][]byte { return nil }
+func (d *metadataDelegate) LocalState(join bool) []byte                { return nil }
+func (d *metadataDelegate) MergeRemoteState(buf []byte, join bool)     {}
+
+// eventDelegate implements memberlist.EventDelegate, decoding each node's
+// Member metadata and forwarding it to the Config callbacks. NotifyLeave
+// runs the optional health probe first so a suspect node that still
+// responds doesn't get reported to OnLeave.
+type eventDelegate struct {
+	c *Cluster
+}
+
+func (e *eventDelegate) NotifyJoin(n *memberlist.Node) {
+	m, ok := decodeMember(n)
+	if !ok || m.ServerID == e.c.cfg.ServerID {
+		return
+	}
+	if e.c.cfg.OnJoin != nil {
+		e.c.cfg.OnJoin(m)
+	}
+}
+
+func (e *eventDelegate) NotifyLeave(n *memberlist.Node) {
+	m, ok := decodeMember(n)
+	if !ok || m.ServerID == e.c.cfg.ServerID {
+		return
+	}
+
+	if e.c.cfg.HealthProbe != nil {
+		probeCtx, cancel := context.WithTimeout(context.Background(), e.c.cfg.HealthProbeTimeout)
+		defer cancel()
+		if err := e.c.cfg.HealthProbe(probeCtx, m.GRPCAddress); err == nil {
+			// Still answers directly - treat the gossip leave as a
+			// transient flap rather than a real departure.
+			return
+		}
+	}
+
+	if e.c.cfg.OnLeave != nil {
+		e.c.cfg.OnLeave(m)
+	}
+}
+
+func (e *eventDelegate) NotifyUpdate(n *memberlist.Node) {
+	m, ok := decodeMember(n)
+	if !ok || m.ServerID == e.c.cfg.ServerID {
+		return
+	}
+	if e.c.cfg.OnUpdate != nil {
+		e.c.cfg.OnUpdate(m)
+	}
+}
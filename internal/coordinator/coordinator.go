@@ -0,0 +1,26 @@
+// Package coordinator provides leader election for singleton background
+// jobs (cleanup, hash-ring refresh) so an HA deployment of multiple
+// APIGateway instances doesn't do N times the work, or race on the same
+// deletions, by running every job on every node. See PostgresCoordinator
+// for the initial implementation; the Coordinator interface is the seam a
+// future etcd- or Consul-backed implementation would slot into.
+package coordinator
+
+import "context"
+
+// Coordinator elects a single leader among cooperating processes sharing
+// lease_name, and reports leadership changes so callers can start/stop
+// singleton work cleanly. Implementations must be safe for concurrent use.
+type Coordinator interface {
+	// Start begins acquiring and renewing the lease in the background.
+	Start(ctx context.Context) error
+	// Stop stops the background renewal loop and releases the lease if
+	// currently held, blocking until both have completed.
+	Stop()
+	// IsLeader reports whether this process currently holds the lease.
+	IsLeader() bool
+	// LeadershipChanged emits the new IsLeader value every time it flips.
+	// It is never closed while the Coordinator is running; it is closed
+	// once Stop's renewal loop has fully exited.
+	LeadershipChanged() <-chan bool
+}
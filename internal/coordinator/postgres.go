@@ -0,0 +1,186 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultLeaseTTL is how long a lease survives without renewal before
+	// another process may claim it - see migrations/012_leader_election.sql.
+	DefaultLeaseTTL = 10 * time.Second
+	// DefaultRenewInterval is how often the current holder renews its
+	// lease. It must be well under DefaultLeaseTTL so a single missed
+	// renewal (a slow query, a GC pause) doesn't cost leadership.
+	DefaultRenewInterval = 3 * time.Second
+)
+
+// PostgresCoordinator implements Coordinator using a single row per lease
+// in the leader_leases table: acquiring, renewing, and detecting a
+// failed-over lease are all the same atomic upsert, conditioned on either
+// already being the holder or the existing holder's renewal having gone
+// stale past TTL.
+type PostgresCoordinator struct {
+	pool          *pgxpool.Pool
+	leaseName     string
+	holderID      string
+	ttl           time.Duration
+	renewInterval time.Duration
+	logger        logr.Logger
+
+	mu       sync.RWMutex
+	isLeader bool
+
+	changed  chan bool
+	stopChan chan struct{}
+	stopped  chan struct{}
+}
+
+// NewPostgresCoordinator creates a coordinator for leaseName. holderID
+// identifies this process in the leader_leases row; if empty, a random one
+// is generated. Use DefaultLeaseTTL/DefaultRenewInterval unless the caller
+// has a reason to change them.
+func NewPostgresCoordinator(pool *pgxpool.Pool, leaseName, holderID string, logger logr.Logger) *PostgresCoordinator {
+	if holderID == "" {
+		holderID = uuid.New().String()
+	}
+	return &PostgresCoordinator{
+		pool:          pool,
+		leaseName:     leaseName,
+		holderID:      holderID,
+		ttl:           DefaultLeaseTTL,
+		renewInterval: DefaultRenewInterval,
+		logger:        logger,
+		changed:       make(chan bool, 1),
+		stopChan:      make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// Start begins acquiring and renewing the lease in the background.
+func (c *PostgresCoordinator) Start(ctx context.Context) error {
+	go c.run(ctx)
+	return nil
+}
+
+// Stop stops the renewal loop and releases the lease if currently held.
+func (c *PostgresCoordinator) Stop() {
+	close(c.stopChan)
+	<-c.stopped
+}
+
+// IsLeader reports whether this process currently holds the lease.
+func (c *PostgresCoordinator) IsLeader() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isLeader
+}
+
+// LeadershipChanged emits the new IsLeader value every time it flips.
+func (c *PostgresCoordinator) LeadershipChanged() <-chan bool {
+	return c.changed
+}
+
+func (c *PostgresCoordinator) run(ctx context.Context) {
+	defer close(c.stopped)
+	defer close(c.changed)
+
+	ticker := time.NewTicker(c.renewInterval)
+	defer ticker.Stop()
+
+	c.tryAcquireOrRenew(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.tryAcquireOrRenew(ctx)
+		case <-c.stopChan:
+			if c.IsLeader() {
+				c.release(context.Background())
+			}
+			return
+		case <-ctx.Done():
+			if c.IsLeader() {
+				c.release(context.Background())
+			}
+			return
+		}
+	}
+}
+
+func (c *PostgresCoordinator) tryAcquireOrRenew(ctx context.Context) {
+	isLeader, err := c.acquireOrRenew(ctx)
+	if err != nil {
+		c.logger.Error(err, "failed to renew leadership lease", "lease", c.leaseName)
+		// A DB error means we can no longer prove we still hold the
+		// lease; stepping down is safer than risking two leaders.
+		isLeader = false
+	}
+	c.setLeader(isLeader)
+}
+
+func (c *PostgresCoordinator) setLeader(isLeader bool) {
+	c.mu.Lock()
+	changed := c.isLeader != isLeader
+	c.isLeader = isLeader
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	c.logger.Info("leadership changed", "lease", c.leaseName, "holder_id", c.holderID, "is_leader", isLeader)
+
+	// Only the most recent state matters to a consumer that may not have
+	// drained a prior send yet; drop a stale pending value rather than
+	// block the renewal loop on a slow reader.
+	select {
+	case c.changed <- isLeader:
+	default:
+		select {
+		case <-c.changed:
+		default:
+		}
+		c.changed <- isLeader
+	}
+}
+
+// acquireOrRenew atomically claims leaseName for holderID if it's unheld,
+// already held by holderID, or its last renewal is older than ttl.
+func (c *PostgresCoordinator) acquireOrRenew(ctx context.Context) (bool, error) {
+	var holder string
+	err := c.pool.QueryRow(ctx, `
+		INSERT INTO leader_leases (lease_name, holder_id, renewed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (lease_name) DO UPDATE
+		SET holder_id = EXCLUDED.holder_id, renewed_at = NOW()
+		WHERE leader_leases.holder_id = EXCLUDED.holder_id
+		   OR leader_leases.renewed_at < NOW() - $3::interval
+		RETURNING holder_id
+	`, c.leaseName, c.holderID, c.ttl).Scan(&holder)
+
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire/renew lease %q: %w", c.leaseName, err)
+	}
+	return holder == c.holderID, nil
+}
+
+func (c *PostgresCoordinator) release(ctx context.Context) {
+	if _, err := c.pool.Exec(ctx, `
+		DELETE FROM leader_leases WHERE lease_name = $1 AND holder_id = $2
+	`, c.leaseName, c.holderID); err != nil {
+		c.logger.Error(err, "failed to release leadership lease on shutdown", "lease", c.leaseName)
+		return
+	}
+	c.setLeader(false)
+}
@@ -0,0 +1,496 @@
+// Package decommission gracefully drains every chunk off a storage server
+// so it can be retired without downtime, mirroring the server-pool
+// decommissioning operators expect from object stores like MinIO.
+package decommission
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/metacache"
+	"github.com/s3storage/internal/storage"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultBatchSize is how many chunks are paged and moved per round.
+	// The resumable cursor only advances once an entire batch has finished,
+	// so a larger batch means more chunks re-moved after a crash.
+	DefaultBatchSize = 50
+	// DefaultConcurrency bounds how many chunks are moved in parallel.
+	DefaultConcurrency = 4
+	// chunkCopyTimeout bounds each chunk's source-read + target-write gRPC round trip.
+	chunkCopyTimeout = 30 * time.Second
+	// pausePollInterval is how often a paused job rechecks whether it's been resumed or cancelled.
+	pausePollInterval = 2 * time.Second
+)
+
+// boundedLoadRing is satisfied by hasher.HashRing, mirroring
+// internal/api/upload.go's interface of the same name: a chunk moved off
+// its source server should give back the bounded-load share it took on
+// placement, but only backends that actually track load need to implement
+// this.
+type boundedLoadRing interface {
+	DecLoad(serverID string, delta int64)
+}
+
+// Manager runs and tracks decommission jobs, one goroutine per active job.
+type Manager struct {
+	storage        *metacache.Cache
+	ring           *storage.HashRing
+	storageClients map[uuid.UUID]*grpc.ClientConn
+	clientsMu      *sync.RWMutex
+	// hashRing is the gateway's in-memory placement ring (gw.HashRing), used
+	// here only to give back bounded load on the chunk's old server once
+	// it's been moved off - distinct from ring, the DB-backed placement
+	// ring used to pick each chunk's new home.
+	hashRing    hasher.PlacementRing
+	batchSize   int
+	concurrency int
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*runningJob
+}
+
+// runningJob is the in-memory control handle for a job's background
+// goroutine; it doesn't survive a gateway restart, so StartDecommission and
+// Resume both know how to re-launch a worker for a job that's "running" or
+// "paused" in the database but missing from jobs.
+type runningJob struct {
+	cancel context.CancelFunc
+	paused atomic.Bool
+	done   chan struct{}
+}
+
+// NewManager creates a Manager with the default batch size and concurrency.
+// hashRing is the same in-memory placement ring the gateway routes uploads
+// through (gw.HashRing); it may be nil, in which case bounded load is
+// simply not adjusted as chunks move off their source server.
+func NewManager(
+	store *metacache.Cache,
+	ring *storage.HashRing,
+	storageClients map[uuid.UUID]*grpc.ClientConn,
+	clientsMu *sync.RWMutex,
+	hashRing hasher.PlacementRing,
+) *Manager {
+	return &Manager{
+		storage:        store,
+		ring:           ring,
+		storageClients: storageClients,
+		clientsMu:      clientsMu,
+		hashRing:       hashRing,
+		batchSize:      DefaultBatchSize,
+		concurrency:    DefaultConcurrency,
+		jobs:           make(map[uuid.UUID]*runningJob),
+	}
+}
+
+// JobStatus augments a persisted DecommissionJob with progress derived at
+// read time: raw byte/chunk counters alone don't tell an operator how close
+// to done a job is or how long it'll take.
+type JobStatus struct {
+	*storage.DecommissionJob
+	PercentComplete       float64        `json:"percent_complete"`
+	ThroughputBytesPerSec float64        `json:"throughput_bytes_per_sec"`
+	ETA                   *time.Duration `json:"eta,omitempty"`
+}
+
+// StartDecommission begins draining serverID, or resumes its existing
+// running/paused job if one already exists (e.g. after a gateway restart).
+func (m *Manager) StartDecommission(ctx context.Context, serverID uuid.UUID) (*storage.DecommissionJob, error) {
+	existing, err := m.storage.GetActiveDecommissionJobForServer(ctx, serverID)
+	if err == nil {
+		return existing, m.ensureRunning(existing)
+	}
+	if err != storage.ErrNotFound {
+		return nil, fmt.Errorf("checking for an existing decommission job: %w", err)
+	}
+
+	chunksTotal, bytesTotal, err := m.storage.CountChunksByServerID(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("counting chunks on server %s: %w", serverID, err)
+	}
+
+	job := &storage.DecommissionJob{
+		ServerID:    serverID,
+		ChunksTotal: chunksTotal,
+		BytesTotal:  bytesTotal,
+	}
+	if err := m.storage.CreateDecommissionJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	m.launch(job)
+	return job, nil
+}
+
+// GetStatus returns jobID's persisted state plus derived progress metrics.
+func (m *Manager) GetStatus(ctx context.Context, jobID uuid.UUID) (*JobStatus, error) {
+	job, err := m.storage.GetDecommissionJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return newJobStatus(job), nil
+}
+
+// JobStatusFor wraps a raw DecommissionJob (e.g. one just returned by
+// StartDecommission or Resume) with its derived progress metrics, for
+// callers that already have the job and don't need another database round
+// trip via GetStatus.
+func JobStatusFor(job *storage.DecommissionJob) *JobStatus {
+	return newJobStatus(job)
+}
+
+func newJobStatus(job *storage.DecommissionJob) *JobStatus {
+	status := &JobStatus{DecommissionJob: job}
+
+	if job.ChunksTotal > 0 {
+		status.PercentComplete = float64(job.ChunksMoved+job.FailedChunks) / float64(job.ChunksTotal) * 100
+	}
+
+	elapsed := time.Since(job.StartedAt)
+	if job.CompletedAt != nil {
+		elapsed = job.CompletedAt.Sub(job.StartedAt)
+	}
+	if elapsed <= 0 {
+		return status
+	}
+	status.ThroughputBytesPerSec = float64(job.BytesMoved) / elapsed.Seconds()
+
+	if job.Status == "running" && status.ThroughputBytesPerSec > 0 {
+		remaining := job.BytesTotal - job.BytesMoved
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta := time.Duration(float64(remaining)/status.ThroughputBytesPerSec) * time.Second
+		status.ETA = &eta
+	}
+
+	return status
+}
+
+// Cancel stops jobID's worker (if running in this process) and marks it
+// cancelled. Chunks it already moved are left on their new server.
+func (m *Manager) Cancel(ctx context.Context, jobID uuid.UUID) error {
+	m.stopLocal(jobID)
+	return m.storage.SetDecommissionJobStatus(ctx, jobID, "cancelled")
+}
+
+// Pause suspends jobID's worker after its current batch finishes, without
+// losing its progress; Resume continues it from the same cursor.
+func (m *Manager) Pause(ctx context.Context, jobID uuid.UUID) error {
+	m.mu.Lock()
+	rj, ok := m.jobs[jobID]
+	m.mu.Unlock()
+	if ok {
+		rj.paused.Store(true)
+	}
+	return m.storage.SetDecommissionJobStatus(ctx, jobID, "paused")
+}
+
+// Resume continues a paused job from its last cursor, re-launching a worker
+// if this process doesn't already have one running for it (e.g. it was
+// paused before a gateway restart).
+func (m *Manager) Resume(ctx context.Context, jobID uuid.UUID) (*storage.DecommissionJob, error) {
+	job, err := m.storage.GetDecommissionJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != "paused" {
+		return nil, fmt.Errorf("decommission job %s is not paused (status=%s)", jobID, job.Status)
+	}
+
+	if err := m.storage.SetDecommissionJobStatus(ctx, jobID, "running"); err != nil {
+		return nil, err
+	}
+	job.Status = "running"
+
+	m.mu.Lock()
+	rj, inMemory := m.jobs[jobID]
+	m.mu.Unlock()
+	if inMemory {
+		rj.paused.Store(false)
+	} else {
+		m.launch(job)
+	}
+
+	return job, nil
+}
+
+func (m *Manager) ensureRunning(job *storage.DecommissionJob) error {
+	m.mu.Lock()
+	rj, inMemory := m.jobs[job.JobID]
+	m.mu.Unlock()
+
+	if inMemory {
+		rj.paused.Store(job.Status == "paused")
+		return nil
+	}
+
+	m.launch(job)
+	return nil
+}
+
+// StopAll cancels every in-flight job's worker without changing its
+// database status, so it resumes from its last cursor next time a gateway
+// calls StartDecommission (or Resume, if it was paused) for that server.
+// Call this during graceful shutdown, before closing storage client
+// connections.
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	jobs := make([]*runningJob, 0, len(m.jobs))
+	for _, rj := range m.jobs {
+		jobs = append(jobs, rj)
+	}
+	m.mu.Unlock()
+
+	for _, rj := range jobs {
+		rj.cancel()
+		<-rj.done
+	}
+}
+
+func (m *Manager) stopLocal(jobID uuid.UUID) {
+	m.mu.Lock()
+	rj, ok := m.jobs[jobID]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	rj.cancel()
+	<-rj.done
+}
+
+func (m *Manager) launch(job *storage.DecommissionJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rj := &runningJob{cancel: cancel, done: make(chan struct{})}
+	rj.paused.Store(job.Status == "paused")
+
+	m.mu.Lock()
+	m.jobs[job.JobID] = rj
+	m.mu.Unlock()
+
+	go func() {
+		defer close(rj.done)
+		defer func() {
+			m.mu.Lock()
+			delete(m.jobs, job.JobID)
+			m.mu.Unlock()
+		}()
+		m.run(ctx, rj, job)
+	}()
+}
+
+// run pages through serverID's chunks in batches, moving each batch's
+// chunks to a new server (picked via m.ring, excluding serverID) with up to
+// m.concurrency in flight at once, then advances the resumable cursor past
+// the batch. It keeps going until a page comes back empty (server drained)
+// or ctx is cancelled (Cancel was called).
+func (m *Manager) run(ctx context.Context, rj *runningJob, job *storage.DecommissionJob) {
+	log.Printf("Decommission %s: draining server %s (%d chunks, %d bytes)", job.JobID, job.ServerID, job.ChunksTotal, job.BytesTotal)
+
+	cursorNumber := job.CursorChunkNumber
+	cursorID := job.CursorChunkID
+	exclude := map[uuid.UUID]bool{job.ServerID: true}
+
+	for {
+		if ctx.Err() != nil {
+			log.Printf("Decommission %s: stopped: %v", job.JobID, ctx.Err())
+			return
+		}
+
+		for rj.paused.Load() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pausePollInterval):
+			}
+		}
+
+		chunks, err := m.storage.GetChunksByServerIDAfter(ctx, job.ServerID, cursorNumber, cursorID, m.batchSize)
+		if err != nil {
+			log.Printf("Decommission %s: failed to page chunks: %v", job.JobID, err)
+			if serr := m.storage.SetDecommissionJobStatus(context.Background(), job.JobID, "failed"); serr != nil {
+				log.Printf("Decommission %s: failed to mark job failed: %v", job.JobID, serr)
+			}
+			return
+		}
+		if len(chunks) == 0 {
+			if err := m.storage.SetDecommissionJobStatus(context.Background(), job.JobID, "completed"); err != nil {
+				log.Printf("Decommission %s: failed to mark job completed: %v", job.JobID, err)
+			}
+			log.Printf("Decommission %s: drained server %s", job.JobID, job.ServerID)
+			return
+		}
+
+		sem := make(chan struct{}, m.concurrency)
+		var wg sync.WaitGroup
+		for _, chunk := range chunks {
+			chunk := chunk
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				m.moveOne(ctx, job, chunk, exclude)
+			}()
+		}
+		wg.Wait()
+
+		last := chunks[len(chunks)-1]
+		cursorID = &last.ChunkID
+		cursorNumber = last.ChunkNumber
+		if err := m.storage.UpdateDecommissionJobCursor(context.Background(), job.JobID, cursorNumber, *cursorID); err != nil {
+			log.Printf("Decommission %s: failed to advance cursor: %v", job.JobID, err)
+		}
+	}
+}
+
+// moveOne copies one chunk onto a new server, repoints chunks.storage_server_id
+// at it, and deletes the chunk from the draining server. It never fails the
+// job as a whole: a chunk that can't be moved is counted in FailedChunks and
+// left in place for a future decommission attempt to retry.
+func (m *Manager) moveOne(ctx context.Context, job *storage.DecommissionJob, chunk *storage.Chunk, exclude map[uuid.UUID]bool) {
+	target := m.ring.PlaceChunkExcluding(chunk.FileID, chunk.ChunkNumber, exclude)
+	if target == uuid.Nil {
+		log.Printf("Decommission %s: no target server available for chunk %s", job.JobID, chunk.ChunkID)
+		m.recordResult(job.JobID, 0, true)
+		return
+	}
+
+	if err := m.copyChunk(ctx, chunk.ChunkID, chunk.StorageServerID, target); err != nil {
+		log.Printf("Decommission %s: failed to copy chunk %s to %s: %v", job.JobID, chunk.ChunkID, target, err)
+		m.recordResult(job.JobID, 0, true)
+		return
+	}
+
+	if err := m.storage.UpdateChunkStorageServer(ctx, chunk.FileID, chunk.ChunkID, target); err != nil {
+		log.Printf("Decommission %s: failed to reassign chunk %s: %v", job.JobID, chunk.ChunkID, err)
+		m.recordResult(job.JobID, 0, true)
+		return
+	}
+
+	if err := m.deleteFromSource(ctx, chunk.ChunkID, chunk.StorageServerID); err != nil {
+		// The chunk already lives safely on target; leaving a stale copy on
+		// the draining server costs disk space but not correctness.
+		log.Printf("Decommission %s: chunk %s moved to %s but failed to delete from source %s: %v",
+			job.JobID, chunk.ChunkID, target, chunk.StorageServerID, err)
+	} else if boundedRing, ok := m.hashRing.(boundedLoadRing); ok {
+		boundedRing.DecLoad(chunk.StorageServerID.String(), 1)
+	}
+
+	m.recordResult(job.JobID, chunk.ChunkSize, false)
+}
+
+func (m *Manager) recordResult(jobID uuid.UUID, bytesMoved int64, failed bool) {
+	if err := m.storage.RecordDecommissionChunkResult(context.Background(), jobID, bytesMoved, failed); err != nil {
+		log.Printf("Decommission %s: failed to record chunk result: %v", jobID, err)
+	}
+}
+
+func (m *Manager) client(serverID uuid.UUID) (pb.StorageServiceClient, error) {
+	m.clientsMu.RLock()
+	conn, ok := m.storageClients[serverID]
+	m.clientsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage server %s not available", serverID)
+	}
+	return pb.NewStorageServiceClient(conn), nil
+}
+
+// copyChunk streams chunkID's bytes from sourceID to targetID via GetChunk/PutChunk.
+func (m *Manager) copyChunk(ctx context.Context, chunkID, sourceID, targetID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, chunkCopyTimeout)
+	defer cancel()
+
+	source, err := m.client(sourceID)
+	if err != nil {
+		return err
+	}
+	target, err := m.client(targetID)
+	if err != nil {
+		return err
+	}
+
+	data, err := fetchChunk(ctx, source, chunkID.String())
+	if err != nil {
+		return fmt.Errorf("fetching from source: %w", err)
+	}
+
+	if err := pushChunk(ctx, target, chunkID.String(), data); err != nil {
+		return fmt.Errorf("pushing to target: %w", err)
+	}
+
+	return nil
+}
+
+func fetchChunk(ctx context.Context, client pb.StorageServiceClient, chunkID string) ([]byte, error) {
+	stream, err := client.GetChunk(ctx, &pb.GetChunkRequest{ChunkId: chunkID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download stream: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive chunk data: %w", err)
+		}
+		buf.Write(resp.Data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func pushChunk(ctx context.Context, client pb.StorageServiceClient, chunkID string, data []byte) error {
+	stream, err := client.PutChunk(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create upload stream: %w", err)
+	}
+
+	if err := stream.Send(&pb.PutChunkRequest{ChunkId: chunkID, Data: data}); err != nil {
+		return fmt.Errorf("failed to send chunk data: %w", err)
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("failed to close stream: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("target server reported failure")
+	}
+
+	return nil
+}
+
+func (m *Manager) deleteFromSource(ctx context.Context, chunkID, sourceID uuid.UUID) error {
+	client, err := m.client(sourceID)
+	if err != nil {
+		return err
+	}
+
+	deleteCtx, cancel := context.WithTimeout(ctx, chunkCopyTimeout)
+	defer cancel()
+
+	resp, err := client.DeleteChunk(deleteCtx, &pb.DeleteChunkRequest{ChunkId: chunkID.String()})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("source server reported failure deleting chunk")
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package ec wraps Reed-Solomon erasure coding for chunk placement: a chunk
+// can be split into k data shards plus m parity shards instead of being
+// replicated whole, tolerating the loss of any m of the k+m shards.
+package ec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+const (
+	// DefaultDataShards is the default number of data shards (k).
+	DefaultDataShards = 4
+	// DefaultParityShards is the default number of parity shards (m).
+	DefaultParityShards = 2
+)
+
+var (
+	// ErrTooFewShards is returned when fewer than dataShards shards are
+	// available to reconstruct a chunk.
+	ErrTooFewShards = errors.New("too few shards available to reconstruct chunk")
+)
+
+// Config holds the (k, m) erasure coding parameters for a chunk.
+type Config struct {
+	DataShards   int
+	ParityShards int
+}
+
+// TotalShards returns k+m.
+func (c Config) TotalShards() int {
+	return c.DataShards + c.ParityShards
+}
+
+// DefaultConfig returns the package default (4 data, 2 parity) shard split.
+func DefaultConfig() Config {
+	return Config{DataShards: DefaultDataShards, ParityShards: DefaultParityShards}
+}
+
+// Encode splits data into cfg.DataShards data shards and computes
+// cfg.ParityShards parity shards, returning all k+m shards in order (data
+// shards first, then parity shards). The caller is responsible for
+// remembering data's original length, since padding added to fill the last
+// data shard isn't otherwise recoverable.
+func Encode(cfg Config, data []byte) ([][]byte, error) {
+	enc, err := reedsolomon.New(cfg.DataShards, cfg.ParityShards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reed-solomon encoder: %w", err)
+	}
+
+	shards, err := enc.Split(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split chunk into shards: %w", err)
+	}
+
+	if err := enc.Encode(shards); err != nil {
+		return nil, fmt.Errorf("failed to compute parity shards: %w", err)
+	}
+
+	return shards, nil
+}
+
+// Reconstruct rebuilds the original chunk from whatever shards are
+// available. shards must be cfg.TotalShards() long; missing shards are
+// represented by a nil entry at their index. At least cfg.DataShards shards
+// must be present. originalSize trims the trailing zero-padding Encode may
+// have added to the last data shard.
+func Reconstruct(cfg Config, shards [][]byte, originalSize int) ([]byte, error) {
+	present := 0
+	for _, shard := range shards {
+		if shard != nil {
+			present++
+		}
+	}
+	if present < cfg.DataShards {
+		return nil, ErrTooFewShards
+	}
+
+	enc, err := reedsolomon.New(cfg.DataShards, cfg.ParityShards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reed-solomon encoder: %w", err)
+	}
+
+	if err := enc.Reconstruct(shards); err != nil {
+		return nil, fmt.Errorf("failed to reconstruct chunk: %w", err)
+	}
+
+	out := make([]byte, 0, originalSize)
+	for i := 0; i < cfg.DataShards && len(out) < originalSize; i++ {
+		out = append(out, shards[i]...)
+	}
+	if len(out) > originalSize {
+		out = out[:originalSize]
+	}
+
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package ec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncodeReconstruct_NoLoss tests that reconstruction with every shard
+// present returns the original data unchanged
+func TestEncodeReconstruct_NoLoss(t *testing.T) {
+	cfg := Config{DataShards: 4, ParityShards: 2}
+	data := bytes.Repeat([]byte("reed-solomon-test-data"), 1000)
+
+	shards, err := Encode(cfg, data)
+	require.NoError(t, err)
+	assert.Len(t, shards, cfg.TotalShards())
+
+	out, err := Reconstruct(cfg, shards, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+}
+
+// TestEncodeReconstruct_TolerableLoss tests that reconstruction succeeds
+// when up to ParityShards shards are missing
+func TestEncodeReconstruct_TolerableLoss(t *testing.T) {
+	cfg := Config{DataShards: 4, ParityShards: 2}
+	data := bytes.Repeat([]byte("another-test-payload"), 500)
+
+	shards, err := Encode(cfg, data)
+	require.NoError(t, err)
+
+	// Drop 2 shards (one data, one parity) - should still be recoverable
+	lossy := make([][]byte, len(shards))
+	copy(lossy, shards)
+	lossy[0] = nil
+	lossy[4] = nil
+
+	out, err := Reconstruct(cfg, lossy, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+}
+
+// TestReconstruct_TooFewShards tests that reconstruction fails when fewer
+// than DataShards shards are available
+func TestReconstruct_TooFewShards(t *testing.T) {
+	cfg := Config{DataShards: 4, ParityShards: 2}
+	data := []byte("short payload")
+
+	shards, err := Encode(cfg, data)
+	require.NoError(t, err)
+
+	lossy := make([][]byte, len(shards))
+	copy(lossy, shards)
+	lossy[0] = nil
+	lossy[1] = nil
+	lossy[2] = nil
+
+	_, err = Reconstruct(cfg, lossy, len(data))
+	assert.ErrorIs(t, err, ErrTooFewShards)
+}
+
+// TestDefaultConfig tests the package default shard split
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, DefaultDataShards, cfg.DataShards)
+	assert.Equal(t, DefaultParityShards, cfg.ParityShards)
+	assert.Equal(t, DefaultDataShards+DefaultParityShards, cfg.TotalShards())
+}
@@ -0,0 +1,13 @@
+package fetcher
+
+import "errors"
+
+var (
+	// errNoReplicas is returned when a request lists no replicas to fetch from.
+	errNoReplicas = errors.New("no replicas available for chunk")
+	// errReplicaUnavailable is returned when a replica's circuit breaker is
+	// open. It isn't a gRPC status error, so retry.IsRetryable's default
+	// (retryable) applies and the job gets requeued rather than failing the
+	// download.
+	errReplicaUnavailable = errors.New("replica unavailable: circuit open")
+)
@@ -0,0 +1,284 @@
+// Package fetcher implements a priority-queued, hedged-request chunk fetcher
+// for tail-latency-tolerant downloads: chunks near the head of a download are
+// given higher priority, a bounded pool of workers races a primary replica
+// against a delayed hedge replica, and transient failures are requeued with
+// exponential backoff instead of failing the whole download.
+package fetcher
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/retry"
+)
+
+const (
+	// DefaultWorkers bounds how many chunk fetches run concurrently.
+	DefaultWorkers = 32
+	// DefaultHedgeDelay is how long a worker waits for the primary replica
+	// before also racing a backup replica.
+	DefaultHedgeDelay = 50 * time.Millisecond
+)
+
+// Request describes a single chunk to fetch. Replicas lists the storage
+// servers holding the chunk, most-preferred first; Replicas[1] (if present)
+// is raced as a hedge if Replicas[0] hasn't responded within the hedge
+// delay. Priority orders queue service: lower values are served first, so
+// callers should set it to the chunk's position in the download (e.g. its
+// chunk number).
+type Request struct {
+	ChunkID     uuid.UUID
+	ChunkNumber int
+	Priority    int
+	Replicas    []uuid.UUID
+}
+
+// Result is delivered on the channel returned by FetchAll as soon as a
+// chunk's bytes (or final error) are available. Results are not ordered;
+// callers reorder by ChunkNumber.
+type Result struct {
+	ChunkNumber int
+	Data        []byte
+	Err         error
+}
+
+// Source performs the actual chunk transfer for a single replica. Available
+// reports whether a replica's circuit breaker currently allows traffic, so
+// the fetcher can skip tripped servers without paying for a failed RPC.
+type Source interface {
+	Available(serverID uuid.UUID) bool
+	Fetch(ctx context.Context, serverID uuid.UUID, chunkID string) ([]byte, error)
+}
+
+// Fetcher races hedged requests across a bounded worker pool.
+type Fetcher struct {
+	source      Source
+	workers     int
+	hedgeDelay  time.Duration
+	retryConfig *retry.RetryConfig
+}
+
+// NewFetcher creates a Fetcher backed by source. workers bounds concurrent
+// in-flight fetches; hedgeDelay is how long to wait before racing a backup
+// replica; retryConfig controls how many times a transient failure is
+// requeued and with what backoff.
+func NewFetcher(source Source, workers int, hedgeDelay time.Duration, retryConfig *retry.RetryConfig) *Fetcher {
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+	if hedgeDelay <= 0 {
+		hedgeDelay = DefaultHedgeDelay
+	}
+	if retryConfig == nil {
+		retryConfig = retry.DefaultRetryConfig()
+	}
+
+	return &Fetcher{
+		source:      source,
+		workers:     workers,
+		hedgeDelay:  hedgeDelay,
+		retryConfig: retryConfig,
+	}
+}
+
+// scheduler owns the priority queue and tracks how many requests are still
+// outstanding (queued, in flight, or waiting out a retry backoff).
+type scheduler struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	queue       jobQueue
+	outstanding int
+	finished    bool
+}
+
+// FetchAll submits requests to the worker pool and returns a channel that
+// receives one Result per request, in completion order. The channel is
+// closed once every request has produced a Result.
+func (f *Fetcher) FetchAll(ctx context.Context, requests []Request) <-chan Result {
+	results := make(chan Result, len(requests))
+	if len(requests) == 0 {
+		close(results)
+		return results
+	}
+
+	s := &scheduler{outstanding: len(requests)}
+	s.cond = sync.NewCond(&s.mu)
+
+	for i := range requests {
+		heap.Push(&s.queue, &job{request: requests[i]})
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		s.finished = true
+		s.mu.Unlock()
+		s.cond.Broadcast()
+	}()
+
+	workers := f.workers
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			f.runWorker(ctx, s, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func (f *Fetcher) runWorker(ctx context.Context, s *scheduler, results chan<- Result) {
+	for {
+		s.mu.Lock()
+		for s.queue.Len() == 0 && !s.finished {
+			s.cond.Wait()
+		}
+		if s.queue.Len() == 0 {
+			s.mu.Unlock()
+			return
+		}
+		j := heap.Pop(&s.queue).(*job)
+		s.mu.Unlock()
+
+		f.process(ctx, s, j, results)
+	}
+}
+
+// process races the job's primary replica against a hedged backup and
+// either finalizes the job (success or exhausted retries) or requeues it
+// after an exponential backoff.
+func (f *Fetcher) process(ctx context.Context, s *scheduler, j *job, results chan<- Result) {
+	if ctx.Err() != nil {
+		f.finalize(s, results, Result{ChunkNumber: j.request.ChunkNumber, Err: ctx.Err()})
+		return
+	}
+
+	data, err := f.race(ctx, j.request.ChunkID.String(), j.request.Replicas)
+	if err == nil {
+		f.finalize(s, results, Result{ChunkNumber: j.request.ChunkNumber, Data: data})
+		return
+	}
+
+	if !retry.IsRetryable(err) || j.attempt >= f.retryConfig.MaxRetries {
+		f.finalize(s, results, Result{ChunkNumber: j.request.ChunkNumber, Err: err})
+		return
+	}
+
+	j.attempt++
+	backoff := f.retryConfig.InitialBackoff << uint(j.attempt-1)
+	if backoff > f.retryConfig.MaxBackoff || backoff <= 0 {
+		backoff = f.retryConfig.MaxBackoff
+	}
+
+	time.AfterFunc(backoff, func() {
+		if ctx.Err() != nil {
+			f.finalize(s, results, Result{ChunkNumber: j.request.ChunkNumber, Err: ctx.Err()})
+			return
+		}
+		s.mu.Lock()
+		heap.Push(&s.queue, j)
+		s.mu.Unlock()
+		s.cond.Signal()
+	})
+}
+
+// fetchOutcome is the result of racing a single replica.
+type fetchOutcome struct {
+	data []byte
+	err  error
+}
+
+// race fetches from replicas[0], launching replicas[1] as a hedge if the
+// primary hasn't produced bytes within the hedge delay. The first
+// successful response wins; the loser's stream is cancelled.
+func (f *Fetcher) race(ctx context.Context, chunkID string, replicas []uuid.UUID) ([]byte, error) {
+	if len(replicas) == 0 {
+		return nil, errNoReplicas
+	}
+
+	outcomes := make(chan fetchOutcome, 2)
+	launch := func(serverID uuid.UUID, fetchCtx context.Context) {
+		go func() {
+			if !f.source.Available(serverID) {
+				outcomes <- fetchOutcome{err: errReplicaUnavailable}
+				return
+			}
+			data, err := f.source.Fetch(fetchCtx, serverID, chunkID)
+			outcomes <- fetchOutcome{data: data, err: err}
+		}()
+	}
+
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	defer cancelPrimary()
+	launch(replicas[0], primaryCtx)
+
+	var cancelHedge context.CancelFunc
+	hedged := false
+	pending := 1
+	launchHedge := func() {
+		hedged = true
+		var hedgeCtx context.Context
+		hedgeCtx, cancelHedge = context.WithCancel(ctx)
+		launch(replicas[1], hedgeCtx)
+		pending++
+	}
+	timer := time.NewTimer(f.hedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case outcome := <-outcomes:
+			pending--
+			if outcome.err != nil {
+				// A failed fetch doesn't win: start the hedge now, or
+				// wait for it if it's already in flight.
+				if !hedged && len(replicas) > 1 {
+					launchHedge()
+					continue
+				}
+				if pending > 0 {
+					continue
+				}
+			}
+			if cancelHedge != nil {
+				cancelHedge()
+			}
+			return outcome.data, outcome.err
+		case <-timer.C:
+			if !hedged && len(replicas) > 1 {
+				launchHedge()
+			}
+		case <-ctx.Done():
+			if cancelHedge != nil {
+				cancelHedge()
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (f *Fetcher) finalize(s *scheduler, results chan<- Result, r Result) {
+	results <- r
+
+	s.mu.Lock()
+	s.outstanding--
+	if s.outstanding == 0 {
+		s.finished = true
+	}
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
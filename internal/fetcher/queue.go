@@ -0,0 +1,47 @@
+package fetcher
+
+import "container/heap"
+
+// job is a single chunk-fetch request sitting in the priority queue. Lower
+// Priority values are served first (chunks nearer the head of the reader
+// get a lower chunk number and therefore a lower priority value).
+type job struct {
+	request Request
+	attempt int
+	index   int // heap.Interface bookkeeping
+}
+
+// jobQueue is a min-heap of jobs ordered by priority, ties broken by
+// insertion order so retried jobs don't starve the rest of the batch.
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].request.Priority != q[j].request.Priority {
+		return q[i].request.Priority < q[j].request.Priority
+	}
+	return q[i].index < q[j].index
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *jobQueue) Push(x any) {
+	j := x.(*job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+func (q *jobQueue) Pop() any {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return j
+}
+
+var _ heap.Interface = (*jobQueue)(nil)
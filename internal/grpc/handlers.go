@@ -6,11 +6,19 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
+	"github.com/google/uuid"
 	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/notify"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -20,10 +28,19 @@ const (
 	ChunkBufferSize = 64 * 1024 // 64KB
 )
 
+// tracer emits the child spans for PutChunk/GetChunk/DeleteChunk; the
+// traceparent those spans attach to arrives via the server-side
+// otelgrpc interceptor installed in cmd/storage-server.
+var tracer = otel.Tracer("github.com/s3storage/internal/grpc")
+
 // StorageServer implements the gRPC StorageService
 type StorageServer struct {
 	pb.UnimplementedStorageServiceServer
-	dataDir string
+	dataDir     string
+	sweepStop   chan struct{}
+	sweepWG     sync.WaitGroup
+	draining    atomic.Bool
+	notifyQueue *notify.Queue
 }
 
 // NewStorageServer creates a new StorageServer instance
@@ -40,13 +57,22 @@ func NewStorageServer(dataDir string) (*StorageServer, error) {
 
 // PutChunk handles streaming upload of a chunk
 func (s *StorageServer) PutChunk(stream pb.StorageService_PutChunkServer) error {
+	_, span := tracer.Start(stream.Context(), "PutChunk")
+	defer span.End()
+
 	var chunkID string
 	var expectedChecksum string
 	var file *os.File
 	var bytesWritten int64
+	var checksumOK bool
 	hasher := sha256.New()
 
 	defer func() {
+		span.SetAttributes(
+			attribute.String("chunk_id", chunkID),
+			attribute.Int64("bytes", bytesWritten),
+			attribute.Bool("checksum_ok", checksumOK),
+		)
 		if file != nil {
 			file.Close()
 		}
@@ -113,6 +139,9 @@ func (s *StorageServer) PutChunk(stream pb.StorageService_PutChunkServer) error
 			os.Remove(s.getChunkPath(chunkID))
 			return status.Errorf(codes.DataLoss, "checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksum)
 		}
+		checksumOK = true
+	} else {
+		checksumOK = true
 	}
 
 	// Sync to disk
@@ -120,24 +149,61 @@ func (s *StorageServer) PutChunk(stream pb.StorageService_PutChunkServer) error
 		return status.Errorf(codes.Internal, "failed to sync chunk to disk: %v", err)
 	}
 
+	s.publishChunkWritten(chunkID, hex.EncodeToString(hasher.Sum(nil)))
+
 	return stream.SendAndClose(&pb.PutChunkResponse{
 		ChunkId: chunkID,
 		Success: true,
 	})
 }
 
+// publishChunkWritten enqueues a ChunkEvent for chunkID if a notify queue is
+// configured, logging rather than failing the write if the chunk_id isn't a
+// parseable UUID or the queue's own persistence fails - replication fan-out
+// is best-effort and must never block or fail the original upload.
+func (s *StorageServer) publishChunkWritten(chunkID, checksum string) {
+	if s.notifyQueue == nil {
+		return
+	}
+	id, err := uuid.Parse(chunkID)
+	if err != nil {
+		log.Printf("notify: chunk_id %q is not a UUID, skipping replication fan-out", chunkID)
+		return
+	}
+	if err := s.notifyQueue.Enqueue(notify.ChunkEvent{ChunkID: id, Checksum: checksum}); err != nil {
+		log.Printf("notify: failed to enqueue chunk %s for replication: %v", chunkID, err)
+	}
+}
+
 // GetChunk handles streaming download of a chunk
 func (s *StorageServer) GetChunk(req *pb.GetChunkRequest, stream pb.StorageService_GetChunkServer) error {
+	_, span := tracer.Start(stream.Context(), "GetChunk", trace.WithAttributes(attribute.String("chunk_id", req.ChunkId)))
+	defer span.End()
+
+	var bytesSent int64
+	var checksumOK bool
+	defer func() {
+		span.SetAttributes(attribute.Int64("bytes", bytesSent), attribute.Bool("checksum_ok", checksumOK))
+	}()
+
 	if req.ChunkId == "" {
 		return status.Error(codes.InvalidArgument, "chunk_id is required")
 	}
 
+	if req.Offset < 0 || req.Length < 0 {
+		return status.Error(codes.InvalidArgument, "offset and length must not be negative")
+	}
+
 	chunkPath := s.getChunkPath(req.ChunkId)
 
 	// Check if chunk exists
-	if _, err := os.Stat(chunkPath); os.IsNotExist(err) {
+	info, err := os.Stat(chunkPath)
+	if os.IsNotExist(err) {
 		return status.Errorf(codes.NotFound, "chunk not found: %s", req.ChunkId)
 	}
+	if req.Offset > info.Size() {
+		return status.Errorf(codes.OutOfRange, "offset %d is past chunk end (%d bytes)", req.Offset, info.Size())
+	}
 
 	// Open chunk file
 	file, err := os.Open(chunkPath)
@@ -146,21 +212,29 @@ func (s *StorageServer) GetChunk(req *pb.GetChunkRequest, stream pb.StorageServi
 	}
 	defer file.Close()
 
-	// Verify file integrity by computing checksum
+	// Verify file integrity by computing checksum over the whole chunk,
+	// regardless of the requested sub-range, so a range request still
+	// catches bitrot outside its own window.
 	hasher := sha256.New()
 	if _, err := io.Copy(hasher, file); err != nil {
 		return status.Errorf(codes.Internal, "failed to compute checksum: %v", err)
 	}
+	checksumOK = true
 
-	// Reset file pointer to beginning
-	if _, err := file.Seek(0, 0); err != nil {
+	// Seek to the requested offset (0 if unset), and cap reads to the
+	// requested length (the rest of the file if length is 0).
+	if _, err := file.Seek(req.Offset, 0); err != nil {
 		return status.Errorf(codes.Internal, "failed to seek file: %v", err)
 	}
+	var r io.Reader = file
+	if req.Length > 0 {
+		r = io.LimitReader(file, req.Length)
+	}
 
 	// Stream chunk data
 	buffer := make([]byte, ChunkBufferSize)
 	for {
-		n, err := file.Read(buffer)
+		n, err := r.Read(buffer)
 		if err == io.EOF {
 			break
 		}
@@ -173,6 +247,7 @@ func (s *StorageServer) GetChunk(req *pb.GetChunkRequest, stream pb.StorageServi
 		}); err != nil {
 			return status.Errorf(codes.Internal, "failed to send chunk data: %v", err)
 		}
+		bytesSent += int64(n)
 	}
 
 	return nil
@@ -180,6 +255,9 @@ func (s *StorageServer) GetChunk(req *pb.GetChunkRequest, stream pb.StorageServi
 
 // DeleteChunk handles deletion of a chunk
 func (s *StorageServer) DeleteChunk(ctx context.Context, req *pb.DeleteChunkRequest) (*pb.DeleteChunkResponse, error) {
+	_, span := tracer.Start(ctx, "DeleteChunk", trace.WithAttributes(attribute.String("chunk_id", req.ChunkId)))
+	defer span.End()
+
 	if req.ChunkId == "" {
 		return &pb.DeleteChunkResponse{
 			Success:      false,
@@ -210,6 +288,33 @@ func (s *StorageServer) DeleteChunk(ctx context.Context, req *pb.DeleteChunkRequ
 	}, nil
 }
 
+// SetNotifyQueue wires a notify.Queue that PutChunk enqueues a ChunkEvent
+// into on every successful write, for asynchronous fan-out (e.g.
+// replication to secondary servers - see cmd/storage-server's wiring). nil
+// (the default) disables this: PutChunk behaves exactly as before.
+func (s *StorageServer) SetNotifyQueue(q *notify.Queue) {
+	s.notifyQueue = q
+}
+
+// ReadChunk opens a previously stored chunk for reading, by ID. Used by the
+// replication handler subscribed to the notify queue to read the local
+// copy it just wrote without going through the GetChunk RPC.
+func (s *StorageServer) ReadChunk(chunkID string) (*os.File, error) {
+	f, err := os.Open(s.getChunkPath(chunkID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chunk %s: %w", chunkID, err)
+	}
+	return f, nil
+}
+
+// SetDraining marks the server as draining (or clears it), so subsequent
+// HealthCheck calls report "draining" instead of "healthy" - the grpc-level
+// counterpart to the DB status row and gossiped Draining flag a caller
+// updates alongside it during a graceful shutdown.
+func (s *StorageServer) SetDraining(draining bool) {
+	s.draining.Store(draining)
+}
+
 // HealthCheck returns the health status of the storage server
 func (s *StorageServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
 	// Get disk space information
@@ -225,8 +330,13 @@ func (s *StorageServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRequ
 	availableSpace := int64(stat.Bavail * uint64(stat.Bsize))
 	usedSpace := totalSpace - availableSpace
 
+	healthStatus := "healthy"
+	if s.draining.Load() {
+		healthStatus = "draining"
+	}
+
 	return &pb.HealthCheckResponse{
-		Status:         "healthy",
+		Status:         healthStatus,
 		AvailableSpace: availableSpace,
 		UsedSpace:      usedSpace,
 		TotalSpace:     totalSpace,
@@ -0,0 +1,347 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultSessionTTL is how long an upload session may sit idle before the
+	// sweeper reclaims it.
+	DefaultSessionTTL = 24 * time.Hour
+	// DefaultSweepInterval is how often the sweeper scans for expired sessions.
+	DefaultSweepInterval = 15 * time.Minute
+)
+
+// uploadSession tracks the on-disk state of an in-progress resumable upload.
+// It is serialized to a ".session" sidecar file next to the partial chunk
+// data so the committed offset and rolling checksum survive a restart.
+type uploadSession struct {
+	SessionID       string    `json:"session_id"`
+	ChunkID         string    `json:"chunk_id"`
+	TotalSize       int64     `json:"total_size"`
+	Checksum        string    `json:"checksum"`
+	CommittedOffset int64     `json:"committed_offset"`
+	HasherState     []byte    `json:"hasher_state"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Finalized       bool      `json:"finalized"`
+}
+
+// sessionPaths returns the sidecar and data file paths for a session ID.
+func (s *StorageServer) sessionPaths(sessionID string) (dataPath, sidecarPath string) {
+	subdir := sessionID
+	if len(sessionID) >= 2 {
+		subdir = sessionID[:2]
+	}
+	dir := filepath.Join(s.dataDir, "uploads", subdir)
+	return filepath.Join(dir, sessionID), filepath.Join(dir, sessionID+".session")
+}
+
+// CreateUploadSession starts a resumable upload for a single chunk.
+func (s *StorageServer) CreateUploadSession(ctx context.Context, req *pb.CreateUploadSessionRequest) (*pb.CreateUploadSessionResponse, error) {
+	if req.ChunkId == "" {
+		return nil, status.Error(codes.InvalidArgument, "chunk_id is required")
+	}
+	if req.TotalSize <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "total_size must be positive")
+	}
+
+	sessionID := uuid.New().String()
+	dataPath, sidecarPath := s.sessionPaths(sessionID)
+
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0755); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create session directory: %v", err)
+	}
+
+	file, err := os.Create(dataPath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create session data file: %v", err)
+	}
+	file.Close()
+
+	session := &uploadSession{
+		SessionID: sessionID,
+		ChunkID:   req.ChunkId,
+		TotalSize: req.TotalSize,
+		Checksum:  req.Checksum,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := writeSessionSidecar(sidecarPath, session); err != nil {
+		os.Remove(dataPath)
+		return nil, status.Errorf(codes.Internal, "failed to persist session: %v", err)
+	}
+
+	return &pb.CreateUploadSessionResponse{
+		SessionId:       sessionID,
+		CommittedOffset: 0,
+	}, nil
+}
+
+// AppendUpload streams chunk bytes for an existing session, resuming from the
+// offset the server last committed.
+func (s *StorageServer) AppendUpload(stream pb.StorageService_AppendUploadServer) error {
+	var session *uploadSession
+	var dataPath, sidecarPath string
+	var file *os.File
+	var rollingHash hash.Hash
+	finalize := false
+
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to receive upload data: %v", err)
+		}
+
+		if session == nil {
+			if req.SessionId == "" {
+				return status.Error(codes.InvalidArgument, "session_id is required")
+			}
+
+			dataPath, sidecarPath = s.sessionPaths(req.SessionId)
+			session, err = readSessionSidecar(sidecarPath)
+			if err != nil {
+				return status.Errorf(codes.NotFound, "upload session not found: %v", err)
+			}
+			if session.Finalized {
+				return status.Error(codes.FailedPrecondition, "upload session already finalized")
+			}
+
+			rollingHash, err = restoreHasher(session.HasherState)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to restore checksum state: %v", err)
+			}
+
+			file, err = os.OpenFile(dataPath, os.O_WRONLY, 0644)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to open session data file: %v", err)
+			}
+		}
+
+		if req.Offset != session.CommittedOffset {
+			detail := &pb.UploadOffsetDetail{CommittedOffset: session.CommittedOffset}
+			st, stErr := status.New(codes.FailedPrecondition, "offset does not match committed offset").
+				WithDetails(detail)
+			if stErr != nil {
+				return status.Errorf(codes.FailedPrecondition, "offset mismatch: committed offset is %d", session.CommittedOffset)
+			}
+			return st.Err()
+		}
+
+		if len(req.Data) > 0 {
+			if _, err := file.WriteAt(req.Data, req.Offset); err != nil {
+				if isOutOfSpace(err) {
+					return status.Error(codes.ResourceExhausted, "disk full")
+				}
+				return status.Errorf(codes.Internal, "failed to write upload data: %v", err)
+			}
+			rollingHash.Write(req.Data)
+			session.CommittedOffset += int64(len(req.Data))
+		}
+
+		session.UpdatedAt = time.Now()
+		marshaled, err := marshalHasher(rollingHash)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to snapshot checksum state: %v", err)
+		}
+		session.HasherState = marshaled
+
+		if err := writeSessionSidecar(sidecarPath, session); err != nil {
+			return status.Errorf(codes.Internal, "failed to persist session progress: %v", err)
+		}
+
+		if req.IsLast {
+			finalize = true
+			break
+		}
+	}
+
+	if session == nil {
+		return status.Error(codes.InvalidArgument, "no data received")
+	}
+
+	if !finalize {
+		return stream.SendAndClose(&pb.AppendUploadResponse{
+			SessionId:       session.SessionID,
+			CommittedOffset: session.CommittedOffset,
+		})
+	}
+
+	if err := file.Sync(); err != nil {
+		return status.Errorf(codes.Internal, "failed to sync session data: %v", err)
+	}
+	file.Close()
+	file = nil
+
+	if session.Checksum != "" {
+		actual := hex.EncodeToString(rollingHash.Sum(nil))
+		if actual != session.Checksum {
+			return status.Errorf(codes.DataLoss, "checksum mismatch: expected %s, got %s", session.Checksum, actual)
+		}
+	}
+
+	chunkPath := s.getChunkPath(session.ChunkID)
+	if err := os.MkdirAll(filepath.Dir(chunkPath), 0755); err != nil {
+		return status.Errorf(codes.Internal, "failed to create chunk directory: %v", err)
+	}
+	if err := os.Rename(dataPath, chunkPath); err != nil {
+		return status.Errorf(codes.Internal, "failed to finalize chunk: %v", err)
+	}
+
+	session.Finalized = true
+	writeSessionSidecar(sidecarPath, session)
+
+	return stream.SendAndClose(&pb.AppendUploadResponse{
+		SessionId:       session.SessionID,
+		CommittedOffset: session.CommittedOffset,
+		Finalized:       true,
+		ChunkId:         session.ChunkID,
+	})
+}
+
+// GetUploadOffset returns the committed offset for an in-progress session.
+func (s *StorageServer) GetUploadOffset(ctx context.Context, req *pb.GetUploadOffsetRequest) (*pb.GetUploadOffsetResponse, error) {
+	if req.SessionId == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+
+	_, sidecarPath := s.sessionPaths(req.SessionId)
+	session, err := readSessionSidecar(sidecarPath)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "upload session not found: %v", err)
+	}
+
+	return &pb.GetUploadOffsetResponse{
+		CommittedOffset: session.CommittedOffset,
+		Finalized:       session.Finalized,
+	}, nil
+}
+
+// StartSessionSweeper starts a background goroutine that garbage-collects
+// upload sessions whose sidecar has not been updated within ttl.
+func (s *StorageServer) StartSessionSweeper(ttl, interval time.Duration) {
+	s.sweepStop = make(chan struct{})
+	s.sweepWG.Add(1)
+
+	go func() {
+		defer s.sweepWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweepExpiredSessions(ttl)
+			case <-s.sweepStop:
+				return
+			}
+		}
+	}()
+}
+
+// StopSessionSweeper stops the session sweeper started by StartSessionSweeper.
+func (s *StorageServer) StopSessionSweeper() {
+	if s.sweepStop != nil {
+		close(s.sweepStop)
+		s.sweepWG.Wait()
+	}
+}
+
+// sweepExpiredSessions removes session sidecars and partial data files older
+// than ttl.
+func (s *StorageServer) sweepExpiredSessions(ttl time.Duration) {
+	uploadsDir := filepath.Join(s.dataDir, "uploads")
+	cutoff := time.Now().Add(-ttl)
+
+	filepath.Walk(uploadsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".session" {
+			return nil
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		dataPath := path[:len(path)-len(".session")]
+		os.Remove(path)
+		os.Remove(dataPath)
+		return nil
+	})
+}
+
+func writeSessionSidecar(path string, session *uploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session sidecar: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func readSessionSidecar(path string) (*uploadSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	session := &uploadSession{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return session, nil
+}
+
+// marshalHasher snapshots a sha256 hasher's internal state so it can be
+// restored across process restarts or connection drops.
+func marshalHasher(h hash.Hash) ([]byte, error) {
+	marshaler, ok := h.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, errors.New("hasher does not support state snapshotting")
+	}
+	return marshaler.MarshalBinary()
+}
+
+// restoreHasher rebuilds a sha256 hasher from a snapshot produced by
+// marshalHasher, or returns a fresh hasher if state is empty.
+func restoreHasher(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+	if len(state) == 0 {
+		return h, nil
+	}
+
+	unmarshaler, ok := h.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return nil, errors.New("hasher does not support state restoration")
+	}
+	if err := unmarshaler.UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("failed to restore hasher state: %w", err)
+	}
+	return h, nil
+}
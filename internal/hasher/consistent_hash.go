@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
+	"github.com/s3storage/internal/telemetry"
 )
 
 const (
@@ -31,6 +33,9 @@ type HashNode struct {
 type Server struct {
 	ID      string
 	Address string
+	// Weight scales this server's share of virtual nodes relative to its
+	// peers; 1.0 is the default a plain AddServer registers.
+	Weight float64
 }
 
 // HashRing implements consistent hashing with virtual nodes
@@ -39,6 +44,12 @@ type HashRing struct {
 	servers      map[string]*Server
 	virtualNodes int
 	mu           sync.RWMutex
+	// loads holds each server's self-reported load (chunk count, bytes,
+	// whatever unit the caller chooses to track consistently), updated via
+	// IncLoad/DecLoad and consulted by GetServerBoundedTracked. Callers that
+	// already maintain their own load accounting can use GetServerBounded's
+	// callback form instead and ignore this map entirely.
+	loads map[string]int64
 }
 
 // NewHashRing creates a new hash ring with default virtual nodes
@@ -47,6 +58,7 @@ func NewHashRing() *HashRing {
 		nodes:        make([]HashNode, 0),
 		servers:      make(map[string]*Server),
 		virtualNodes: DefaultVirtualNodes,
+		loads:        make(map[string]int64),
 	}
 }
 
@@ -56,22 +68,41 @@ func NewHashRingWithVirtualNodes(virtualNodes int) *HashRing {
 		nodes:        make([]HashNode, 0),
 		servers:      make(map[string]*Server),
 		virtualNodes: virtualNodes,
+		loads:        make(map[string]int64),
 	}
 }
 
 // AddServer adds a server to the hash ring with virtual nodes
 func (hr *HashRing) AddServer(serverID, address string) error {
+	return hr.AddServerWithWeight(serverID, address, 1.0)
+}
+
+// AddServerWithWeight adds a server to the hash ring with weight times the
+// ring's default virtual node count, so a server with twice the disk (or
+// twice the capacity) of its peers receives proportionally more keys
+// instead of the same 1/N share every server gets under plain AddServer.
+// weight <= 0 is treated as 1.0.
+func (hr *HashRing) AddServerWithWeight(serverID, address string, weight float64) error {
 	hr.mu.Lock()
 	defer hr.mu.Unlock()
 
+	if weight <= 0 {
+		weight = 1.0
+	}
+
 	// Register server
 	hr.servers[serverID] = &Server{
 		ID:      serverID,
 		Address: address,
+		Weight:  weight,
 	}
 
-	// Create virtual nodes
-	for i := 0; i < hr.virtualNodes; i++ {
+	// Create virtual nodes, scaled by weight
+	numVirtualNodes := int(float64(hr.virtualNodes) * weight)
+	if numVirtualNodes < 1 {
+		numVirtualNodes = 1
+	}
+	for i := 0; i < numVirtualNodes; i++ {
 		virtualKey := fmt.Sprintf("%s#%d", serverID, i)
 		hashValue := xxHash([]byte(virtualKey))
 
@@ -116,6 +147,9 @@ func (hr *HashRing) RemoveServer(serverID string) error {
 
 // GetServer returns the server ID for a given key using consistent hashing
 func (hr *HashRing) GetServer(key string) (string, error) {
+	start := time.Now()
+	defer func() { telemetry.HashRingLookupDuration.Observe(time.Since(start).Seconds()) }()
+
 	hr.mu.RLock()
 	defer hr.mu.RUnlock()
 
@@ -136,7 +170,176 @@ func (hr *HashRing) GetServer(key string) (string, error) {
 		idx = 0
 	}
 
-	return hr.nodes[idx].ServerID, nil
+	serverID := hr.nodes[idx].ServerID
+	telemetry.HashRingKeysPerServer.WithLabelValues(serverID).Inc()
+	return serverID, nil
+}
+
+// GetServersForShards returns up to count distinct server IDs for key by
+// walking the ring clockwise from key's hash position, the way GetServer
+// picks a single owner. It's used to place an erasure-coded chunk's k+m
+// shards on k+m different servers. If fewer than count distinct servers are
+// registered, all registered servers are returned.
+//
+// This is a thin alias for GetNServers, kept so existing erasure-coding
+// callers don't need to change; new call sites needing "the next N distinct
+// servers for a key" (replication, EC placement, anything else) should
+// prefer GetNServers directly.
+func (hr *HashRing) GetServersForShards(key string, count int) ([]string, error) {
+	return hr.GetNServers(key, count)
+}
+
+// GetServers is GetNServers under the name required by PlacementRing, so
+// HashRing and RendezvousRing can be swapped behind the same interface.
+func (hr *HashRing) GetServers(key string, n int) ([]string, error) {
+	return hr.GetNServers(key, n)
+}
+
+// GetNServers returns up to n distinct server IDs for key by walking the
+// ring clockwise from key's hash position, the way GetServer picks a single
+// owner - the shared primitive behind both single-replica placement
+// (GetServer) and multi-replica placement (replication, erasure coding). If
+// fewer than n distinct servers are registered, all registered servers are
+// returned.
+func (hr *HashRing) GetNServers(key string, n int) ([]string, error) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	if len(hr.nodes) == 0 {
+		return nil, ErrNoServersAvailable
+	}
+
+	keyHash := xxHash([]byte(key))
+	start := sort.Search(len(hr.nodes), func(i int) bool {
+		return hr.nodes[i].HashValue >= keyHash
+	})
+	if start >= len(hr.nodes) {
+		start = 0
+	}
+
+	if n > len(hr.servers) {
+		n = len(hr.servers)
+	}
+
+	seen := make(map[string]bool, n)
+	servers := make([]string, 0, n)
+	for i := 0; i < len(hr.nodes) && len(servers) < n; i++ {
+		node := hr.nodes[(start+i)%len(hr.nodes)]
+		if seen[node.ServerID] {
+			continue
+		}
+		seen[node.ServerID] = true
+		servers = append(servers, node.ServerID)
+	}
+
+	return servers, nil
+}
+
+// GetServerBounded returns a server for key using consistent-hashing-with-
+// bounded-loads: it walks the ring clockwise from key's primary position,
+// as GetServer does, but skips any server whose load (reported by the load
+// callback) already exceeds avgLoad*cap, where avgLoad is the mean load
+// across every registered server. cap is typically ~1.25 (Google's SRE
+// default): it lets the primary handle the hot-key case but caps how far
+// any one server can be overloaded relative to its peers. Returns
+// ErrNoServersAvailable if no server satisfies the bound.
+func (hr *HashRing) GetServerBounded(key string, load func(serverID string) float64, cap float64) (string, error) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	if len(hr.nodes) == 0 || len(hr.servers) == 0 {
+		return "", ErrNoServersAvailable
+	}
+
+	var totalLoad float64
+	for id := range hr.servers {
+		totalLoad += load(id)
+	}
+	avgLoad := totalLoad / float64(len(hr.servers))
+	threshold := avgLoad * cap
+
+	keyHash := xxHash([]byte(key))
+	start := sort.Search(len(hr.nodes), func(i int) bool {
+		return hr.nodes[i].HashValue >= keyHash
+	})
+	if start >= len(hr.nodes) {
+		start = 0
+	}
+
+	seen := make(map[string]bool, len(hr.servers))
+	for i := 0; i < len(hr.nodes); i++ {
+		node := hr.nodes[(start+i)%len(hr.nodes)]
+		if seen[node.ServerID] {
+			continue
+		}
+		seen[node.ServerID] = true
+
+		if load(node.ServerID) <= threshold {
+			return node.ServerID, nil
+		}
+	}
+
+	return "", ErrNoServersAvailable
+}
+
+// IncLoad adds delta to serverID's tracked load, for callers that want the
+// ring itself to maintain load accounting rather than tracking their own
+// map to pass into GetServerBounded. Typically called as a chunk is placed
+// on serverID, paired with a later DecLoad as it's removed.
+func (hr *HashRing) IncLoad(serverID string, delta int64) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.loads[serverID] += delta
+}
+
+// DecLoad subtracts delta from serverID's tracked load. See IncLoad.
+func (hr *HashRing) DecLoad(serverID string, delta int64) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.loads[serverID] -= delta
+}
+
+// GetServerBoundedTracked is GetServerBounded using the ring's own
+// IncLoad/DecLoad-maintained load map instead of a caller-supplied
+// callback, for callers that don't already track per-server load
+// themselves.
+func (hr *HashRing) GetServerBoundedTracked(key string, cap float64) (string, error) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	if len(hr.nodes) == 0 || len(hr.servers) == 0 {
+		return "", ErrNoServersAvailable
+	}
+
+	var totalLoad int64
+	for id := range hr.servers {
+		totalLoad += hr.loads[id]
+	}
+	avgLoad := float64(totalLoad) / float64(len(hr.servers))
+	threshold := avgLoad * cap
+
+	keyHash := xxHash([]byte(key))
+	start := sort.Search(len(hr.nodes), func(i int) bool {
+		return hr.nodes[i].HashValue >= keyHash
+	})
+	if start >= len(hr.nodes) {
+		start = 0
+	}
+
+	seen := make(map[string]bool, len(hr.servers))
+	for i := 0; i < len(hr.nodes); i++ {
+		node := hr.nodes[(start+i)%len(hr.nodes)]
+		if seen[node.ServerID] {
+			continue
+		}
+		seen[node.ServerID] = true
+
+		if float64(hr.loads[node.ServerID]) <= threshold {
+			return node.ServerID, nil
+		}
+	}
+
+	return "", ErrNoServersAvailable
 }
 
 // GetServerInfo returns the server information for a given server ID
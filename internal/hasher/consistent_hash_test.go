@@ -481,6 +481,191 @@ func TestConcurrentAccess(t *testing.T) {
 		resultCount, numGoroutines)
 }
 
+// TestGetServersForShards_DistinctServers tests that shard placement returns
+// the requested number of distinct servers
+func TestGetServersForShards_DistinctServers(t *testing.T) {
+	ring := NewHashRing()
+	numServers := 6
+
+	for i := 1; i <= numServers; i++ {
+		serverID := fmt.Sprintf("storage-%d", i)
+		address := fmt.Sprintf("localhost:900%d", i)
+		err := ring.AddServer(serverID, address)
+		require.NoError(t, err)
+	}
+
+	servers, err := ring.GetServersForShards("chunk-123", 4)
+	require.NoError(t, err)
+	assert.Len(t, servers, 4, "Should return exactly 4 distinct servers")
+
+	seen := make(map[string]bool)
+	for _, s := range servers {
+		assert.False(t, seen[s], "Server %s should not be returned twice", s)
+		seen[s] = true
+	}
+}
+
+// TestGetServersForShards_FewerServersThanRequested tests that requesting
+// more shards than registered servers returns all registered servers
+func TestGetServersForShards_FewerServersThanRequested(t *testing.T) {
+	ring := NewHashRing()
+
+	for i := 1; i <= 3; i++ {
+		serverID := fmt.Sprintf("storage-%d", i)
+		address := fmt.Sprintf("localhost:900%d", i)
+		err := ring.AddServer(serverID, address)
+		require.NoError(t, err)
+	}
+
+	servers, err := ring.GetServersForShards("chunk-456", 6)
+	require.NoError(t, err)
+	assert.Len(t, servers, 3, "Should return all 3 registered servers when fewer than requested")
+}
+
+// TestGetServersForShards_NoServers tests that an empty ring returns an error
+func TestGetServersForShards_NoServers(t *testing.T) {
+	ring := NewHashRing()
+
+	_, err := ring.GetServersForShards("chunk-789", 4)
+	assert.Error(t, err, "GetServersForShards should return error when no servers available")
+}
+
+// TestAddServerWithWeight_ScalesVirtualNodes tests that weight proportionally
+// scales a server's virtual node count relative to the ring default.
+func TestAddServerWithWeight_ScalesVirtualNodes(t *testing.T) {
+	ring := NewHashRing()
+
+	require.NoError(t, ring.AddServerWithWeight("storage-1", "localhost:9001", 1.0))
+	require.NoError(t, ring.AddServerWithWeight("storage-2", "localhost:9002", 2.0))
+
+	var count1, count2 int
+	for _, node := range ring.nodes {
+		switch node.ServerID {
+		case "storage-1":
+			count1++
+		case "storage-2":
+			count2++
+		}
+	}
+
+	assert.Equal(t, DefaultVirtualNodes, count1)
+	assert.Equal(t, DefaultVirtualNodes*2, count2)
+}
+
+// TestAddServerWithWeight_NonPositiveDefaultsToOne tests that a zero or
+// negative weight is treated as the default weight of 1.0.
+func TestAddServerWithWeight_NonPositiveDefaultsToOne(t *testing.T) {
+	ring := NewHashRing()
+
+	require.NoError(t, ring.AddServerWithWeight("storage-1", "localhost:9001", 0))
+	assert.Equal(t, DefaultVirtualNodes, len(ring.nodes))
+}
+
+// TestGetNServers_MatchesGetServersForShards tests that GetNServers and the
+// GetServersForShards alias agree for the same key and count.
+func TestGetNServers_MatchesGetServersForShards(t *testing.T) {
+	ring := NewHashRing()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	viaAlias, err := ring.GetServersForShards("chunk-1", 3)
+	require.NoError(t, err)
+
+	viaNServers, err := ring.GetNServers("chunk-1", 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, viaAlias, viaNServers)
+}
+
+// TestGetServerBounded_SkipsOverloadedPrimary tests that an overloaded
+// primary is skipped in favor of the next server under the load cap.
+func TestGetServerBounded_SkipsOverloadedPrimary(t *testing.T) {
+	ring := NewHashRing()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	primary, err := ring.GetServer("hot-key")
+	require.NoError(t, err)
+
+	load := map[string]float64{"storage-1": 0, "storage-2": 0, "storage-3": 0}
+	load[primary] = 1000 // massively overloaded
+
+	chosen, err := ring.GetServerBounded("hot-key", func(id string) float64 { return load[id] }, 1.25)
+	require.NoError(t, err)
+	assert.NotEqual(t, primary, chosen, "bounded-load lookup should skip the overloaded primary")
+}
+
+// TestGetServerBounded_AllEquallyLoadedReturnsPrimary tests that when every
+// server is under the load cap, the primary (first candidate) is returned.
+func TestGetServerBounded_AllEquallyLoadedReturnsPrimary(t *testing.T) {
+	ring := NewHashRing()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	primary, err := ring.GetServer("even-key")
+	require.NoError(t, err)
+
+	chosen, err := ring.GetServerBounded("even-key", func(id string) float64 { return 1 }, 1.25)
+	require.NoError(t, err)
+	assert.Equal(t, primary, chosen)
+}
+
+// TestGetServerBounded_NoServers tests that an empty ring returns an error.
+func TestGetServerBounded_NoServers(t *testing.T) {
+	ring := NewHashRing()
+
+	_, err := ring.GetServerBounded("any-key", func(string) float64 { return 0 }, 1.25)
+	assert.Error(t, err)
+}
+
+// TestGetServerBoundedTracked_SkipsServerWithMostOfTheLoad tests that when
+// one server has been assigned ~90% of the tracked load, new keys that
+// would otherwise route to it are placed elsewhere instead.
+func TestGetServerBoundedTracked_SkipsServerWithMostOfTheLoad(t *testing.T) {
+	ring := NewHashRing()
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	ring.IncLoad("storage-1", 900)
+	ring.IncLoad("storage-2", 33)
+	ring.IncLoad("storage-3", 33)
+	ring.IncLoad("storage-4", 34)
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("chunk-%d", i)
+		primary, err := ring.GetServer(key)
+		require.NoError(t, err)
+		if primary != "storage-1" {
+			continue // only the keys that would otherwise land on storage-1 are interesting here
+		}
+
+		chosen, err := ring.GetServerBoundedTracked(key, 1.25)
+		require.NoError(t, err)
+		assert.NotEqual(t, "storage-1", chosen, "overloaded storage-1 should be skipped for key %q", key)
+	}
+}
+
+// TestIncLoadDecLoad_RoundTrip tests that DecLoad undoes a prior IncLoad,
+// so a deleted chunk's load doesn't linger and bias future placement.
+func TestIncLoadDecLoad_RoundTrip(t *testing.T) {
+	ring := NewHashRing()
+	require.NoError(t, ring.AddServer("storage-1", "localhost:9001"))
+	require.NoError(t, ring.AddServer("storage-2", "localhost:9002"))
+
+	ring.IncLoad("storage-1", 10)
+	ring.DecLoad("storage-1", 10)
+
+	chosen, err := ring.GetServerBoundedTracked("any-key", 1.25)
+	require.NoError(t, err)
+	primary, err := ring.GetServer("any-key")
+	require.NoError(t, err)
+	assert.Equal(t, primary, chosen, "equal load on both servers should return the primary")
+}
+
 // Benchmark for GetServer performance
 func BenchmarkGetServer(b *testing.B) {
 	ring := NewHashRing()
@@ -0,0 +1,158 @@
+package hasher
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// RendezvousRing implements PlacementRing using Highest Random Weight (HRW)
+// hashing: a key's server is whichever registered server scores highest on
+// xxHash(serverID + "|" + key), rather than walking a sorted ring of
+// virtual nodes. This gives uniform distribution without tuning a virtual
+// node count (HashRing needs ~150 per server to approximate 1/N shares)
+// and its scoring is independent per server, so GetServers can return the
+// top-N distinct servers directly instead of walking clockwise and
+// deduplicating.
+type RendezvousRing struct {
+	mu      sync.RWMutex
+	servers map[string]*Server
+}
+
+// NewRendezvousRing creates an empty rendezvous-hashing placement ring.
+func NewRendezvousRing() *RendezvousRing {
+	return &RendezvousRing{
+		servers: make(map[string]*Server),
+	}
+}
+
+// AddServer registers a server with default weight 1.0.
+func (r *RendezvousRing) AddServer(serverID, address string) error {
+	return r.AddServerWithWeight(serverID, address, 1.0)
+}
+
+// AddServerWithWeight registers a server whose HRW score is scaled by
+// weight, so a server with twice the capacity of its peers wins rendezvous
+// comparisons proportionally more often. weight <= 0 is treated as 1.0.
+func (r *RendezvousRing) AddServerWithWeight(serverID, address string, weight float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if weight <= 0 {
+		weight = 1.0
+	}
+
+	r.servers[serverID] = &Server{
+		ID:      serverID,
+		Address: address,
+		Weight:  weight,
+	}
+
+	return nil
+}
+
+// RemoveServer removes a previously registered server.
+func (r *RendezvousRing) RemoveServer(serverID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.servers[serverID]; !exists {
+		return ErrServerNotFound
+	}
+	delete(r.servers, serverID)
+	return nil
+}
+
+// score computes key's HRW weight for serverID: a higher value means that
+// server is more preferred for key. The hash is mapped to u in (0, 1) and
+// scored as -weight/ln(u), so each server's chance of scoring highest is
+// proportional to its weight; with equal weights servers rank in raw-hash
+// order.
+func (r *RendezvousRing) score(serverID, key string, weight float64) float64 {
+	h := xxHash([]byte(serverID + "|" + key))
+	u := (float64(h>>11) + 0.5) / (1 << 53)
+	return -weight / math.Log(u)
+}
+
+// GetServer returns the highest-scoring server for key.
+func (r *RendezvousRing) GetServer(key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.servers) == 0 {
+		return "", ErrNoServersAvailable
+	}
+
+	var best string
+	var bestScore float64
+	first := true
+	for id, server := range r.servers {
+		s := r.score(id, key, server.Weight)
+		if first || s > bestScore {
+			best = id
+			bestScore = s
+			first = false
+		}
+	}
+
+	return best, nil
+}
+
+// GetServers returns up to n distinct servers for key, highest-scoring
+// first, for multi-replica placement. If fewer than n servers are
+// registered, all registered servers are returned.
+func (r *RendezvousRing) GetServers(key string, n int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.servers) == 0 {
+		return nil, ErrNoServersAvailable
+	}
+
+	if n > len(r.servers) {
+		n = len(r.servers)
+	}
+
+	type scored struct {
+		id    string
+		score float64
+	}
+	candidates := make([]scored, 0, len(r.servers))
+	for id, server := range r.servers {
+		candidates = append(candidates, scored{id: id, score: r.score(id, key, server.Weight)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	servers := make([]string, n)
+	for i := 0; i < n; i++ {
+		servers[i] = candidates[i].id
+	}
+	return servers, nil
+}
+
+// GetServerInfo returns the registered server's metadata.
+func (r *RendezvousRing) GetServerInfo(serverID string) (*Server, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	server, exists := r.servers[serverID]
+	if !exists {
+		return nil, ErrServerNotFound
+	}
+	return server, nil
+}
+
+// GetAllServers returns every registered server.
+func (r *RendezvousRing) GetAllServers() []*Server {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	servers := make([]*Server, 0, len(r.servers))
+	for _, server := range r.servers {
+		servers = append(servers, server)
+	}
+	return servers
+}
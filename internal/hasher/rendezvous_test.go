@@ -0,0 +1,216 @@
+package hasher
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRendezvous_GetServer_Distribution mirrors TestGetServer_Distribution:
+// HRW hashing should distribute keys roughly uniformly across servers
+// without any virtual-node tuning.
+func TestRendezvous_GetServer_Distribution(t *testing.T) {
+	ring := NewRendezvousRing()
+	numServers := 6
+
+	for i := 1; i <= numServers; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	numKeys := 10000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("file-%d-chunk-%d", i/6, i%6)
+		server, err := ring.GetServer(key)
+		require.NoError(t, err)
+		distribution[server]++
+	}
+
+	expectedPerServer := float64(numKeys) / float64(numServers)
+	var sumSquaredDiff float64
+	for i := 1; i <= numServers; i++ {
+		count := distribution[fmt.Sprintf("storage-%d", i)]
+		diff := float64(count) - expectedPerServer
+		sumSquaredDiff += diff * diff
+	}
+	variance := sumSquaredDiff / float64(numServers)
+	coefficientOfVariation := math.Sqrt(variance) / expectedPerServer
+
+	assert.Less(t, coefficientOfVariation, 0.15,
+		"Coefficient of variation should be < 0.15 for good distribution")
+
+	for i := 1; i <= numServers; i++ {
+		serverID := fmt.Sprintf("storage-%d", i)
+		percentage := float64(distribution[serverID]) / float64(numKeys)
+		assert.GreaterOrEqual(t, percentage, 0.10, "Server %s should get at least 10%% of keys", serverID)
+		assert.LessOrEqual(t, percentage, 0.25, "Server %s should get at most 25%% of keys", serverID)
+	}
+}
+
+// TestRendezvous_GetServer_Deterministic mirrors TestGetServer_Deterministic.
+func TestRendezvous_GetServer_Deterministic(t *testing.T) {
+	ring := NewRendezvousRing()
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	testKey := "test-file-chunk-0"
+	firstServer, err := ring.GetServer(testKey)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		server, err := ring.GetServer(testKey)
+		require.NoError(t, err)
+		assert.Equal(t, firstServer, server)
+	}
+}
+
+// TestRendezvous_RemoveServer_Redistribution mirrors
+// TestRemoveServer_Redistribution: removing one of N servers should only
+// redistribute keys, not churn the whole keyspace - HRW's defining property.
+func TestRendezvous_RemoveServer_Redistribution(t *testing.T) {
+	ring := NewRendezvousRing()
+	numServers := 6
+	for i := 1; i <= numServers; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	numKeys := 1000
+	originalMapping := make(map[string]string)
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		server, err := ring.GetServer(key)
+		require.NoError(t, err)
+		originalMapping[key] = server
+	}
+
+	removedServer := "storage-3"
+	require.NoError(t, ring.RemoveServer(removedServer))
+
+	redistributed := 0
+	for key, originalServer := range originalMapping {
+		newServer, err := ring.GetServer(key)
+		require.NoError(t, err)
+
+		if originalServer == removedServer {
+			assert.NotEqual(t, removedServer, newServer)
+			redistributed++
+		} else if newServer != originalServer {
+			redistributed++
+		}
+	}
+
+	redistributionRate := float64(redistributed) / float64(numKeys)
+	expectedRate := 1.0 / float64(numServers)
+
+	assert.InDelta(t, expectedRate, redistributionRate, 0.05,
+		"Redistribution rate should be close to %.2f%% (1/N)", expectedRate*100)
+}
+
+// TestRendezvous_AddServer_MinimalRedistribution mirrors
+// TestAddServer_MinimalRedistribution: adding a server should only steal
+// ~1/(N+1) of the keyspace from the existing servers.
+func TestRendezvous_AddServer_MinimalRedistribution(t *testing.T) {
+	ring := NewRendezvousRing()
+	numServers := 6
+	for i := 1; i <= numServers; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	numKeys := 1000
+	originalMapping := make(map[string]string)
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		server, err := ring.GetServer(key)
+		require.NoError(t, err)
+		originalMapping[key] = server
+	}
+
+	require.NoError(t, ring.AddServer("storage-7", "localhost:9007"))
+
+	movedToNew := 0
+	for key, originalServer := range originalMapping {
+		newServer, err := ring.GetServer(key)
+		require.NoError(t, err)
+		if newServer != originalServer {
+			assert.Equal(t, "storage-7", newServer, "keys should only move to the newly added server")
+			movedToNew++
+		}
+	}
+
+	movedRate := float64(movedToNew) / float64(numKeys)
+	expectedRate := 1.0 / float64(numServers+1)
+
+	assert.InDelta(t, expectedRate, movedRate, 0.05,
+		"Redistribution rate should be close to %.2f%% (1/(N+1))", expectedRate*100)
+}
+
+// TestRendezvous_GetServers_ReturnsDistinctTopN tests that GetServers
+// returns n distinct servers, matching GetServer's top pick as the first
+// element.
+func TestRendezvous_GetServers_ReturnsDistinctTopN(t *testing.T) {
+	ring := NewRendezvousRing()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	primary, err := ring.GetServer("replica-key")
+	require.NoError(t, err)
+
+	servers, err := ring.GetServers("replica-key", 3)
+	require.NoError(t, err)
+	require.Len(t, servers, 3)
+	assert.Equal(t, primary, servers[0])
+
+	seen := make(map[string]bool)
+	for _, id := range servers {
+		assert.False(t, seen[id], "GetServers should return distinct servers")
+		seen[id] = true
+	}
+}
+
+// TestRendezvous_GetServers_CapsAtRegisteredCount tests that requesting
+// more servers than are registered returns all of them rather than erroring.
+func TestRendezvous_GetServers_CapsAtRegisteredCount(t *testing.T) {
+	ring := NewRendezvousRing()
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, ring.AddServer(fmt.Sprintf("storage-%d", i), fmt.Sprintf("localhost:900%d", i)))
+	}
+
+	servers, err := ring.GetServers("some-key", 5)
+	require.NoError(t, err)
+	assert.Len(t, servers, 2)
+}
+
+// TestRendezvous_NoServers tests that an empty ring returns errors rather
+// than panicking.
+func TestRendezvous_NoServers(t *testing.T) {
+	ring := NewRendezvousRing()
+
+	_, err := ring.GetServer("any-key")
+	assert.ErrorIs(t, err, ErrNoServersAvailable)
+
+	_, err = ring.GetServers("any-key", 3)
+	assert.ErrorIs(t, err, ErrNoServersAvailable)
+}
+
+// TestRendezvous_RemoveServer_NotFound tests that removing an unregistered
+// server returns ErrServerNotFound.
+func TestRendezvous_RemoveServer_NotFound(t *testing.T) {
+	ring := NewRendezvousRing()
+	require.NoError(t, ring.AddServer("storage-1", "localhost:9001"))
+
+	err := ring.RemoveServer("storage-99")
+	assert.ErrorIs(t, err, ErrServerNotFound)
+}
+
+// TestRendezvous_SatisfiesPlacementRing ensures *RendezvousRing implements
+// hasher.PlacementRing at compile time, so it stays swappable with
+// *HashRing behind the gateway's HashRing field.
+func TestRendezvous_SatisfiesPlacementRing(t *testing.T) {
+	var _ PlacementRing = NewRendezvousRing()
+	var _ PlacementRing = NewHashRing()
+}
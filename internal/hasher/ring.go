@@ -0,0 +1,29 @@
+package hasher
+
+// PlacementRing is the placement surface both HashRing (virtual-node
+// consistent hashing) and RendezvousRing (HRW hashing) implement, letting
+// the gateway select a strategy via cmd/api-gateway's PLACEMENT_STRATEGY
+// env var without the rest of the codebase caring which one is in use.
+// Bounded-load placement (GetServerBoundedTracked/IncLoad/DecLoad) is
+// intentionally not part of this interface: it's a consistent-hashing-
+// specific refinement that HashRing supports and RendezvousRing doesn't
+// need, since HRW's independent per-server scoring doesn't concentrate
+// load on ring neighbors the way virtual nodes can. Callers that want
+// bounded-load placement when available type-assert for it instead (see
+// api.uploadOneChunk).
+type PlacementRing interface {
+	// AddServer registers a server with its default weight.
+	AddServer(serverID, address string) error
+	// RemoveServer removes a previously registered server.
+	RemoveServer(serverID string) error
+	// GetServer returns the server a single key maps to.
+	GetServer(key string) (string, error)
+	// GetServers returns up to n distinct servers for key, ordered by
+	// preference, for multi-replica placement (replication, erasure
+	// coding).
+	GetServers(key string, n int) ([]string, error)
+	// GetServerInfo returns the registered server's metadata.
+	GetServerInfo(serverID string) (*Server, error)
+	// GetAllServers returns every registered server.
+	GetAllServers() []*Server
+}
@@ -0,0 +1,35 @@
+// Package logctx threads a per-request logr.Logger through
+// context.Context, the way internal/storage's ContextWithLogFields threads
+// session/upload IDs for its own pgx tracing. A logger stashed by
+// WithLogger - typically enriched with WithValues("request_id", ...) by
+// the Gin request-logging middleware, and further enriched with
+// WithValues("file_id", ...) once a handler knows it - is picked up by
+// FromContext anywhere down the call chain, so a log line doesn't need
+// those fields threaded through every function signature to stay
+// correlated with the request that produced it.
+package logctx
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+// loggerKey is the context key WithLogger stores a logr.Logger under.
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable with
+// FromContext.
+func WithLogger(ctx context.Context, logger logr.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logr.Logger stored in ctx by WithLogger, or
+// logr.Discard() if none was attached (e.g. in a test or a background job
+// that doesn't originate from a request).
+func FromContext(ctx context.Context) logr.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(logr.Logger); ok {
+		return logger
+	}
+	return logr.Discard()
+}
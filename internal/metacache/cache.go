@@ -0,0 +1,220 @@
+// Package metacache is an optional Redis-backed read cache in front of
+// storage.PostgresStorage's hottest metadata lookups: GetFileByID and
+// GetChunksByFileID, the two queries UploadFile, DownloadFile, and the
+// cleanup job each issue at least once per file and which become the
+// bottleneck once chunk I/O itself is spread across the hash ring.
+//
+// Cache embeds *storage.PostgresStorage, so every method it doesn't
+// override - including GetExpiredSessions, which the cleanup job must
+// always see fresh - is available unchanged through the embedded pointer
+// and talks to Postgres directly, bypassing Redis entirely.
+package metacache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/s3storage/internal/storage"
+)
+
+// DefaultTTL is how long a cached file or chunk-list entry is served
+// before the next lookup falls through to Postgres.
+const DefaultTTL = 60 * time.Second
+
+const (
+	fileKeyPrefix   = "meta:file:"
+	chunksKeyPrefix = "meta:chunks:"
+)
+
+// Cache wraps *storage.PostgresStorage with an optional Redis read-through
+// cache for GetFileByID and GetChunksByFileID. The zero value is not
+// usable; construct one with New.
+type Cache struct {
+	*storage.PostgresStorage
+
+	redis   *redis.Client
+	ttl     time.Duration
+	metrics *Metrics
+}
+
+// New creates a Cache backed by store, optionally fronted by Redis at
+// redisURL. redisURL == "" makes the cache a no-op passthrough: every
+// overridden method calls straight through to store and Redis is never
+// contacted, so wiring this in has no effect until it's configured. ttl <=
+// 0 uses DefaultTTL.
+func New(store *storage.PostgresStorage, redisURL string, ttl time.Duration) (*Cache, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	c := &Cache{PostgresStorage: store, ttl: ttl, metrics: newMetrics()}
+	if redisURL == "" {
+		return c, nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metadata cache redis url: %w", err)
+	}
+	c.redis = redis.NewClient(opts)
+
+	return c, nil
+}
+
+// enabled reports whether this Cache is actually backed by Redis.
+func (c *Cache) enabled() bool {
+	return c.redis != nil
+}
+
+// GetFileByID returns fileID's metadata, serving it from Redis when
+// present and falling back to (and repopulating from) Postgres on a miss.
+func (c *Cache) GetFileByID(ctx context.Context, fileID uuid.UUID) (*storage.File, error) {
+	if !c.enabled() {
+		return c.PostgresStorage.GetFileByID(ctx, fileID)
+	}
+
+	key := fileKeyPrefix + fileID.String()
+	if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+		var file storage.File
+		if err := json.Unmarshal([]byte(cached), &file); err == nil {
+			c.metrics.Hits.Inc()
+			return &file, nil
+		}
+	}
+	c.metrics.Misses.Inc()
+
+	file, err := c.PostgresStorage.GetFileByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, file)
+	return file, nil
+}
+
+// GetChunksByFileID returns fileID's chunks, serving them from Redis when
+// present and falling back to (and repopulating from) Postgres on a miss.
+func (c *Cache) GetChunksByFileID(ctx context.Context, fileID uuid.UUID) ([]*storage.Chunk, error) {
+	if !c.enabled() {
+		return c.PostgresStorage.GetChunksByFileID(ctx, fileID)
+	}
+
+	key := chunksKeyPrefix + fileID.String()
+	if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+		var chunks []*storage.Chunk
+		if err := json.Unmarshal([]byte(cached), &chunks); err == nil {
+			c.metrics.Hits.Inc()
+			return chunks, nil
+		}
+	}
+	c.metrics.Misses.Inc()
+
+	chunks, err := c.PostgresStorage.GetChunksByFileID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, chunks)
+	return chunks, nil
+}
+
+// CreateFile creates file the normal way, then clears any cached entry for
+// its ID - there shouldn't be one yet, but a retried request could have
+// cached a not-found lookup before this call succeeded.
+func (c *Cache) CreateFile(ctx context.Context, file *storage.File) error {
+	if err := c.PostgresStorage.CreateFile(ctx, file); err != nil {
+		return err
+	}
+	c.invalidate(ctx, fileKeyPrefix+file.FileID.String())
+	return nil
+}
+
+// CreateChunksBatch saves chunks the normal way, then invalidates the
+// cached chunk list for each file they belong to.
+func (c *Cache) CreateChunksBatch(ctx context.Context, chunks []*storage.Chunk) error {
+	if err := c.PostgresStorage.CreateChunksBatch(ctx, chunks); err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(chunks))
+	for _, chunk := range chunks {
+		if seen[chunk.FileID] {
+			continue
+		}
+		seen[chunk.FileID] = true
+		c.invalidate(ctx, chunksKeyPrefix+chunk.FileID.String())
+	}
+	return nil
+}
+
+// UpdateFileStatus updates the status the normal way, then invalidates the
+// cached file record so the next GetFileByID sees the new status instead
+// of a stale "pending"/"failed".
+func (c *Cache) UpdateFileStatus(ctx context.Context, fileID uuid.UUID, status string) error {
+	if err := c.PostgresStorage.UpdateFileStatus(ctx, fileID, status); err != nil {
+		return err
+	}
+	c.invalidate(ctx, fileKeyPrefix+fileID.String())
+	return nil
+}
+
+// DeleteFile deletes the file the normal way, then invalidates both its
+// cached record and its cached chunk list so a subsequent lookup gets
+// ErrNotFound from Postgres instead of a stale hit.
+func (c *Cache) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
+	if err := c.PostgresStorage.DeleteFile(ctx, fileID); err != nil {
+		return err
+	}
+	c.invalidate(ctx, fileKeyPrefix+fileID.String())
+	c.invalidate(ctx, chunksKeyPrefix+fileID.String())
+	return nil
+}
+
+// UpdateChunkStorageServer repoints chunkID (one of fileID's chunks) at
+// serverID the normal way, then invalidates fileID's cached file record.
+// GetFileByID embeds each chunk's StorageServerID inline, so skipping this
+// would let a cached entry serve the old, now-wrong placement to downloads
+// for up to the cache's TTL after the reassignment - see internal/decommission,
+// which repoints a chunk after moving its data off a draining server.
+func (c *Cache) UpdateChunkStorageServer(ctx context.Context, fileID, chunkID, serverID uuid.UUID) error {
+	if err := c.PostgresStorage.UpdateChunkStorageServer(ctx, chunkID, serverID); err != nil {
+		return err
+	}
+	c.invalidate(ctx, fileKeyPrefix+fileID.String())
+	return nil
+}
+
+// UpdateChunkStorageServerForReplay is UpdateChunkStorageServer's
+// counterpart for internal/spool's replayer, which repoints a chunk after
+// delivering it to a server other than its original hash-ring target - see
+// PostgresStorage.UpdateChunkStorageServerForReplay.
+func (c *Cache) UpdateChunkStorageServerForReplay(ctx context.Context, fileID, chunkID, serverID uuid.UUID) error {
+	if err := c.PostgresStorage.UpdateChunkStorageServerForReplay(ctx, chunkID, serverID); err != nil {
+		return err
+	}
+	c.invalidate(ctx, fileKeyPrefix+fileID.String())
+	return nil
+}
+
+// set populates key with value's JSON encoding. A marshal or Redis failure
+// is swallowed: the worst case is the next read falling through to
+// Postgres again, not an error worth surfacing to the caller.
+func (c *Cache) set(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	c.redis.Set(ctx, key, data, c.ttl)
+}
+
+// invalidate deletes key from Redis, a no-op if the cache is disabled
+// since a disabled cache never had the key to begin with.
+func (c *Cache) invalidate(ctx context.Context, key string) {
+	if !c.enabled() {
+		return
+	}
+	c.redis.Del(ctx, key)
+	c.metrics.Invalidations.Inc()
+}
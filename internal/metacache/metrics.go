@@ -0,0 +1,43 @@
+package metacache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the Prometheus counters exported by a Cache, so operators
+// can see its hit rate under real traffic and size (or decide whether to
+// enable) Redis accordingly.
+type Metrics struct {
+	Hits          prometheus.Counter
+	Misses        prometheus.Counter
+	Invalidations prometheus.Counter
+}
+
+// newMetrics creates and registers a Cache's counters. A duplicate
+// registration (e.g. a second cache registering against the same default
+// registry) is ignored, mirroring how duplicate-registration is usually
+// tolerated for singleton caches; any other registration error panics.
+func newMetrics() *Metrics {
+	m := &Metrics{
+		Hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "metacache_hits_total",
+			Help: "Number of metadata lookups served from the Redis cache.",
+		}),
+		Misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "metacache_misses_total",
+			Help: "Number of metadata lookups that missed the Redis cache.",
+		}),
+		Invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "metacache_invalidations_total",
+			Help: "Number of cache entries invalidated by a write.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.Hits, m.Misses, m.Invalidations} {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+	}
+
+	return m
+}
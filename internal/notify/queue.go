@@ -0,0 +1,268 @@
+// Package notify implements a disk-backed, at-least-once notification queue
+// for fanning chunk-write side effects (replication to secondary storage
+// servers, today) out of the request path. PutChunk enqueues a ChunkEvent
+// once its own write has been acked; Queue.Run drains it in the background
+// and retries failed deliveries via the retry package, so a slow or
+// temporarily unreachable replica never adds latency to the original
+// upload. Events are persisted under dataDir the same way internal/spool
+// persists spooled uploads, so a crash between ack and fan-out doesn't lose
+// the obligation - Run replays whatever is still on disk on startup.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/retry"
+	"github.com/s3storage/internal/telemetry"
+)
+
+// DefaultQueueCapacity bounds how many events Run buffers in memory between
+// Enqueue and a subscriber picking them up, before Enqueue starts blocking
+// the caller (PutChunk's own goroutine, once it returns to the client - see
+// cmd/storage-server's wiring).
+const DefaultQueueCapacity = 256
+
+// ChunkEvent describes a chunk-write side effect to fan out asynchronously.
+// ChunkID and Checksum are everything a replication handler needs to locate
+// and verify the chunk without the original PutChunk request still being in
+// memory.
+type ChunkEvent struct {
+	ChunkID   uuid.UUID `json:"chunk_id"`
+	Checksum  string    `json:"checksum"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscription is returned by Subscribe and lets a caller stop receiving
+// events.
+type Subscription struct {
+	q  *Queue
+	id int
+}
+
+// Unsubscribe removes this subscription's handler. Safe to call once; a
+// second call is a no-op.
+func (s Subscription) Unsubscribe() {
+	s.q.mu.Lock()
+	defer s.q.mu.Unlock()
+	delete(s.q.handlers, s.id)
+}
+
+// Queue is a disk-backed, bounded, at-least-once notification queue. It is
+// safe for concurrent use.
+type Queue struct {
+	dir         string
+	ch          chan ChunkEvent
+	retryConfig *retry.RetryConfig
+
+	mu       sync.Mutex
+	handlers map[int]func(ChunkEvent) error
+	nextID   int
+}
+
+// NewQueue creates a Queue persisting events under dir (created if
+// necessary) with the given in-memory channel capacity. Events already on
+// disk - left over from a process that enqueued an event but crashed before
+// Run delivered it - are replayed by Run itself once its consumer loop is
+// live, not here, since more pending events than capacity would otherwise
+// deadlock the constructor.
+func NewQueue(dir string, capacity int) (*Queue, error) {
+	if capacity <= 0 {
+		capacity = DefaultQueueCapacity
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create notify queue directory: %w", err)
+	}
+
+	q := &Queue{
+		dir:      dir,
+		ch:       make(chan ChunkEvent, capacity),
+		handlers: make(map[int]func(ChunkEvent) error),
+		retryConfig: &retry.RetryConfig{
+			MaxRetries:     retry.DefaultMaxRetries,
+			InitialBackoff: retry.DefaultInitialBackoff,
+			MaxBackoff:     retry.DefaultMaxBackoff,
+			Jitter:         retry.JitterFull,
+		},
+	}
+
+	return q, nil
+}
+
+// Subscribe registers handler to be called for every event Run delivers.
+// Returns a Subscription the caller can use to stop receiving events.
+func (q *Queue) Subscribe(handler func(ChunkEvent) error) Subscription {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	id := q.nextID
+	q.nextID++
+	q.handlers[id] = handler
+	return Subscription{q: q, id: id}
+}
+
+// Enqueue durably persists ev and pushes it onto the delivery channel,
+// blocking if the channel is full - backpressure here means the queue is
+// falling behind, which should surface as the caller (PutChunk) slowing
+// down rather than silently dropping replication obligations.
+func (q *Queue) Enqueue(ev ChunkEvent) error {
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	if err := q.persist(ev); err != nil {
+		return fmt.Errorf("failed to persist notify event: %w", err)
+	}
+
+	q.ch <- ev
+	telemetry.NotifyQueueDepth.Set(float64(len(q.ch)))
+	return nil
+}
+
+// Run drains the queue until ctx is cancelled, delivering each event to
+// every subscribed handler and retrying a failing handler via the retry
+// package's jittered backoff before giving up and leaving the event on disk
+// for the next process's startup replay to retry again. On entry, Run also
+// replays any events left on disk by a prior process that enqueued an event
+// but crashed before delivering it; replay runs in its own goroutine so a
+// backlog larger than the channel's capacity can still drain instead of
+// deadlocking before the consumer loop below starts picking events up.
+func (q *Queue) Run(ctx context.Context) {
+	go q.replayPending(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-q.ch:
+			telemetry.NotifyQueueDepth.Set(float64(len(q.ch)))
+			q.deliver(ctx, ev)
+		}
+	}
+}
+
+// replayPending pushes every event still on disk onto q.ch, blocking as
+// needed until the consumer loop in Run has room - it must not be called
+// before Run's loop has started, or it can deadlock on a large backlog.
+func (q *Queue) replayPending(ctx context.Context) {
+	pending, err := q.loadPending()
+	if err != nil {
+		log.Printf("notify: failed to replay pending events: %v", err)
+		return
+	}
+	for _, ev := range pending {
+		select {
+		case q.ch <- ev:
+			telemetry.NotifyQueueDepth.Set(float64(len(q.ch)))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, ev ChunkEvent) {
+	q.mu.Lock()
+	handlers := make([]func(ChunkEvent) error, 0, len(q.handlers))
+	for _, h := range q.handlers {
+		handlers = append(handlers, h)
+	}
+	q.mu.Unlock()
+
+	ok := true
+	for _, handler := range handlers {
+		err := retry.Do(ctx, q.retryConfig, func(context.Context) error { return handler(ev) })
+		if err != nil {
+			log.Printf("notify: handler failed for chunk %s after retries: %v", ev.ChunkID, err)
+			ok = false
+		}
+	}
+
+	if ok {
+		if err := q.remove(ev.ChunkID); err != nil {
+			log.Printf("notify: failed to remove delivered event for chunk %s: %v", ev.ChunkID, err)
+		}
+	}
+}
+
+func (q *Queue) eventPath(chunkID uuid.UUID) string {
+	return filepath.Join(q.dir, chunkID.String()+".json")
+}
+
+func (q *Queue) persist(ev ChunkEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return writeFileSynced(q.eventPath(ev.ChunkID), b)
+}
+
+func (q *Queue) remove(chunkID uuid.UUID) error {
+	if err := os.Remove(q.eventPath(chunkID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (q *Queue) loadPending() ([]ChunkEvent, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ChunkEvent
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(q.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var ev ChunkEvent
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// writeFileSynced writes data to a temp file in path's directory, fsyncs
+// it, then renames it into place, mirroring internal/spool's write path so
+// a crash never leaves a partially written event file under its final name.
+func writeFileSynced(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
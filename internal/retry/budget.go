@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryBudget is a token-bucket shared across calls to Do/DoWithResult that
+// bounds aggregate retry traffic. Without it, many concurrent requests that
+// all start retrying during an incident (e.g. a storage server restart) can
+// multiply load on the very peer that's already struggling. A RetryBudget
+// is safe for concurrent use and is typically constructed once and shared
+// across a RetryConfig used by many goroutines.
+type RetryBudget struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+// NewRetryBudget creates a RetryBudget allowing up to maxTokens retries in
+// a burst, replenished at refillPerSecond tokens/sec. It starts full.
+func NewRetryBudget(maxTokens float64, refillPerSecond float64) *RetryBudget {
+	return &RetryBudget{
+		tokens:     maxTokens,
+		maxTokens:  maxTokens,
+		refillRate: refillPerSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+// Take consumes one token and reports whether a retry may proceed. When the
+// budget is exhausted it returns false, and the caller should treat the
+// triggering error as terminal rather than sleep-and-retry.
+func (b *RetryBudget) Take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -2,10 +2,23 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// tracer emits one child span per attempt, carrying retry.attempt,
+// retry.backoff_ms, and retry.retryable attributes so a trace shows exactly
+// how many times and how long an operation retried.
+var tracer = otel.Tracer("github.com/s3storage/internal/retry")
+
 const (
 	// DefaultMaxRetries is the default maximum number of retries
 	DefaultMaxRetries = 3
@@ -15,11 +28,54 @@ const (
 	DefaultMaxBackoff = 8 * time.Second
 )
 
+// JitterStrategy selects how RetryConfig randomizes the sleep between
+// attempts, to avoid many clients that failed at the same moment (e.g. a
+// storage server blip) retrying in lockstep and re-overloading it.
+type JitterStrategy int
+
+const (
+	// JitterNone sleeps for exactly the computed exponential backoff, with
+	// no randomization. This is the original, pre-jitter behavior.
+	JitterNone JitterStrategy = iota
+	// JitterFull samples the sleep uniformly from [0, backoff), per the
+	// "Full Jitter" algorithm in the AWS Architecture Blog's "Exponential
+	// Backoff And Jitter" post. Spreads retries the most.
+	JitterFull
+	// JitterDecorrelated samples the sleep uniformly from [InitialBackoff,
+	// prevSleep*3), capped at MaxBackoff, per the same post's
+	// "Decorrelated Jitter" algorithm. Grows less aggressively than full
+	// jitter while still decorrelating retries across clients.
+	JitterDecorrelated
+)
+
 // RetryConfig holds retry configuration
 type RetryConfig struct {
 	MaxRetries     int
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
+
+	// Jitter selects how the sleep between attempts is randomized.
+	// JitterNone (the zero value) preserves the original deterministic
+	// doubling behavior.
+	Jitter JitterStrategy
+
+	// PerAttemptTimeout, if set, wraps each call to fn in its own
+	// context.WithTimeout so a single hung attempt can't consume the
+	// entire retry budget. 0 leaves ctx as the caller passed it.
+	PerAttemptTimeout time.Duration
+
+	// Budget, if set, is consulted before every retry sleep (not the
+	// first attempt): a retry is only taken if Budget.Take() allows it,
+	// letting callers cap aggregate retry traffic across requests during
+	// an incident. nil means unbounded retries, as before.
+	Budget *RetryBudget
+
+	// OnRetry, if set, is called once per retry attempt (i.e. after a
+	// retryable failure, before the backoff sleep) with the 1-indexed
+	// attempt number and the error that triggered it. It lets callers
+	// observe retry behavior (e.g. exporting a metric) without this
+	// package depending on anything beyond the standard library.
+	OnRetry func(attempt int, err error)
 }
 
 // DefaultRetryConfig returns default retry configuration
@@ -28,78 +84,152 @@ func DefaultRetryConfig() *RetryConfig {
 		MaxRetries:     DefaultMaxRetries,
 		InitialBackoff: DefaultInitialBackoff,
 		MaxBackoff:     DefaultMaxBackoff,
+		Jitter:         JitterFull,
 	}
 }
 
-// IsRetryable determines if an error is retryable
+// IsRetryable determines if an error is retryable. It unwraps err looking
+// for a gRPC status and treats Unavailable, DeadlineExceeded,
+// ResourceExhausted, and Aborted as retryable - these are the codes a
+// client-side blip, an overloaded peer, or a contended transaction can
+// plausibly produce, as opposed to InvalidArgument, NotFound,
+// PermissionDenied, Unauthenticated, and FailedPrecondition, which mean the
+// request itself won't succeed no matter how many times it's retried. A
+// non-gRPC error (e.g. a plain context.DeadlineExceeded from a
+// PerAttemptTimeout) is treated as retryable, matching this package's
+// previous default of retrying anything that looked like a timeout.
 func IsRetryable(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	// Check for common retryable errors
-	errStr := err.Error()
-
-	// Network errors
-	if contains(errStr, "connection refused") ||
-		contains(errStr, "connection reset") ||
-		contains(errStr, "broken pipe") ||
-		contains(errStr, "timeout") ||
-		contains(errStr, "deadline exceeded") ||
-		contains(errStr, "temporary failure") ||
-		contains(errStr, "unavailable") {
-		return true
+	if errors.Is(err, context.Canceled) {
+		return false
 	}
 
-	return false
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			return true
+		case codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition:
+			return false
+		}
+	}
+
+	// Not a gRPC status error (e.g. a plain deadline/context error from a
+	// PerAttemptTimeout, or a lower-level network error status.FromError
+	// couldn't classify) - default to retryable, as this package always has.
+	return true
+}
+
+// nextBackoff advances backoff for the next attempt following config's
+// Jitter strategy, returning the sleep duration to use now. prevSleep is
+// the sleep actually used last time (0 on the first retry), needed by
+// JitterDecorrelated.
+func nextBackoff(config *RetryConfig, backoff, prevSleep time.Duration) (sleep, newBackoff time.Duration) {
+	switch config.Jitter {
+	case JitterFull:
+		sleep = time.Duration(rand.Int63n(int64(backoff) + 1))
+	case JitterDecorrelated:
+		base := prevSleep
+		if base == 0 {
+			base = config.InitialBackoff
+		}
+		upper := int64(base) * 3
+		if upper <= int64(config.InitialBackoff) {
+			upper = int64(config.InitialBackoff) + 1
+		}
+		sleep = config.InitialBackoff + time.Duration(rand.Int63n(upper))
+		if sleep > config.MaxBackoff {
+			sleep = config.MaxBackoff
+		}
+	default: // JitterNone
+		sleep = backoff
+	}
+
+	newBackoff = backoff * 2
+	if newBackoff > config.MaxBackoff {
+		newBackoff = config.MaxBackoff
+	}
+	return sleep, newBackoff
 }
 
-// Do executes a function with exponential backoff retry logic
-func Do(ctx context.Context, config *RetryConfig, fn func() error) error {
+// callWithTimeout runs fn under a per-attempt deadline when config.
+// PerAttemptTimeout is set, otherwise under ctx as-is.
+func callWithTimeout(ctx context.Context, config *RetryConfig, fn func(context.Context) error) error {
+	if config.PerAttemptTimeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, config.PerAttemptTimeout)
+	defer cancel()
+	return fn(attemptCtx)
+}
+
+// Do executes a function with exponential backoff retry logic. fn receives
+// the per-attempt context (bounded by config.PerAttemptTimeout when set) so
+// a hung attempt can be cancelled without consuming the entire retry budget.
+func Do(ctx context.Context, config *RetryConfig, fn func(context.Context) error) error {
 	if config == nil {
 		config = DefaultRetryConfig()
 	}
 
 	var lastErr error
 	backoff := config.InitialBackoff
+	var prevSleep time.Duration
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
-		// Execute function
-		err := fn()
+		sleep, newBackoff := nextBackoff(config, backoff, prevSleep)
+
+		attemptCtx, span := tracer.Start(ctx, "retry.attempt", trace.WithAttributes(
+			attribute.Int("retry.attempt", attempt),
+			attribute.Int64("retry.backoff_ms", sleep.Milliseconds()),
+		))
+		err := callWithTimeout(attemptCtx, config, fn)
+		retryable := err != nil && IsRetryable(err)
+		span.SetAttributes(attribute.Bool("retry.retryable", retryable))
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+
 		if err == nil {
 			return nil
 		}
 
 		lastErr = err
 
-		// Check if error is retryable
-		if !IsRetryable(err) {
+		if !retryable {
 			return fmt.Errorf("non-retryable error: %w", err)
 		}
 
-		// Don't sleep after last attempt
 		if attempt == config.MaxRetries {
 			break
 		}
 
-		// Check context cancellation
+		if config.Budget != nil && !config.Budget.Take() {
+			return fmt.Errorf("retry budget exhausted: %w", lastErr)
+		}
+
+		if config.OnRetry != nil {
+			config.OnRetry(attempt+1, err)
+		}
+
+		backoff = newBackoff
+		prevSleep = sleep
+
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("retry cancelled: %w", ctx.Err())
-		case <-time.After(backoff):
-			// Exponential backoff with cap
-			backoff *= 2
-			if backoff > config.MaxBackoff {
-				backoff = config.MaxBackoff
-			}
+		case <-time.After(sleep):
 		}
 	}
 
 	return fmt.Errorf("max retries (%d) exceeded: %w", config.MaxRetries, lastErr)
 }
 
-// DoWithResult executes a function with retry logic and returns a result
-func DoWithResult[T any](ctx context.Context, config *RetryConfig, fn func() (T, error)) (T, error) {
+// DoWithResult executes a function with retry logic and returns a result. fn
+// receives the per-attempt context, as Do's fn does.
+func DoWithResult[T any](ctx context.Context, config *RetryConfig, fn func(context.Context) (T, error)) (T, error) {
 	if config == nil {
 		config = DefaultRetryConfig()
 	}
@@ -107,54 +237,59 @@ func DoWithResult[T any](ctx context.Context, config *RetryConfig, fn func() (T,
 	var result T
 	var lastErr error
 	backoff := config.InitialBackoff
+	var prevSleep time.Duration
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
-		// Execute function
-		res, err := fn()
+		sleep, newBackoff := nextBackoff(config, backoff, prevSleep)
+
+		attemptCtx, span := tracer.Start(ctx, "retry.attempt", trace.WithAttributes(
+			attribute.Int("retry.attempt", attempt),
+			attribute.Int64("retry.backoff_ms", sleep.Milliseconds()),
+		))
+		var res T
+		err := callWithTimeout(attemptCtx, config, func(c context.Context) error {
+			var fnErr error
+			res, fnErr = fn(c)
+			return fnErr
+		})
+		retryable := err != nil && IsRetryable(err)
+		span.SetAttributes(attribute.Bool("retry.retryable", retryable))
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+
 		if err == nil {
 			return res, nil
 		}
 
 		lastErr = err
 
-		// Check if error is retryable
-		if !IsRetryable(err) {
+		if !retryable {
 			return result, fmt.Errorf("non-retryable error: %w", err)
 		}
 
-		// Don't sleep after last attempt
 		if attempt == config.MaxRetries {
 			break
 		}
 
-		// Check context cancellation
+		if config.Budget != nil && !config.Budget.Take() {
+			return result, fmt.Errorf("retry budget exhausted: %w", lastErr)
+		}
+
+		if config.OnRetry != nil {
+			config.OnRetry(attempt+1, err)
+		}
+
+		backoff = newBackoff
+		prevSleep = sleep
+
 		select {
 		case <-ctx.Done():
 			return result, fmt.Errorf("retry cancelled: %w", ctx.Err())
-		case <-time.After(backoff):
-			// Exponential backoff with cap
-			backoff *= 2
-			if backoff > config.MaxBackoff {
-				backoff = config.MaxBackoff
-			}
+		case <-time.After(sleep):
 		}
 	}
 
 	return result, fmt.Errorf("max retries (%d) exceeded: %w", config.MaxRetries, lastErr)
 }
-
-// contains checks if a string contains a substring (case-insensitive)
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
-		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-			containsMiddle(s, substr)))
-}
-
-func containsMiddle(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
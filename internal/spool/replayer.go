@@ -0,0 +1,219 @@
+package spool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	pb "github.com/s3storage/api/proto"
+	"github.com/s3storage/internal/hasher"
+	"github.com/s3storage/internal/metacache"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultReplayInterval is how often SpoolReplayer retries whatever is
+	// currently queued.
+	DefaultReplayInterval = 15 * time.Second
+	// UploadTimeout bounds a single replay delivery attempt.
+	UploadTimeout = 30 * time.Second
+	// uploadBufferSize mirrors internal/api's chunk upload streaming
+	// buffer size.
+	uploadBufferSize = 1 << 20
+)
+
+// Replayer periodically retries delivering spooled chunks to their
+// hash-ring-assigned storage server, re-hashing to a new owner if the
+// original target has left the ring. A storage server's PutChunk is
+// idempotent on chunk_id (it truncates and rewrites the file), so a replay
+// racing a since-succeeded delivery is harmless.
+type Replayer struct {
+	spool          *Spool
+	storage        *metacache.Cache
+	ring           hasher.PlacementRing
+	storageClients map[uuid.UUID]*grpc.ClientConn
+	clientsMu      *sync.RWMutex
+	interval       time.Duration
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+	logger         logr.Logger
+}
+
+// NewReplayer creates a Replayer using DefaultReplayInterval.
+func NewReplayer(
+	spool *Spool,
+	storage *metacache.Cache,
+	ring hasher.PlacementRing,
+	storageClients map[uuid.UUID]*grpc.ClientConn,
+	clientsMu *sync.RWMutex,
+	logger logr.Logger,
+) *Replayer {
+	return &Replayer{
+		spool:          spool,
+		storage:        storage,
+		ring:           ring,
+		storageClients: storageClients,
+		clientsMu:      clientsMu,
+		interval:       DefaultReplayInterval,
+		stopChan:       make(chan struct{}),
+		logger:         logger,
+	}
+}
+
+// Start starts the replay background worker.
+func (r *Replayer) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go r.run(ctx)
+	r.logger.Info("spool replayer started", "interval", r.interval)
+}
+
+// Stop stops the replay background worker.
+func (r *Replayer) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+	r.logger.Info("spool replayer stopped")
+}
+
+func (r *Replayer) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.replayAll(ctx)
+		case <-r.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// replayAll attempts delivery of every currently spooled chunk.
+func (r *Replayer) replayAll(ctx context.Context) {
+	headers, err := r.spool.List()
+	if err != nil {
+		r.logger.Error(err, "failed to list spooled chunks")
+		return
+	}
+	if len(headers) == 0 {
+		return
+	}
+
+	r.logger.Info("replaying spooled chunks", "count", len(headers))
+	for _, h := range headers {
+		if err := r.replayOne(ctx, h); err != nil {
+			r.logger.Error(err, "failed to replay spooled chunk", "chunk_id", h.ChunkID, "target_server_id", h.TargetServerID)
+		}
+	}
+}
+
+func (r *Replayer) replayOne(ctx context.Context, h Header) error {
+	serverID := h.TargetServerID
+	if !r.ringHasServer(serverID) {
+		rehashed, err := r.ring.GetServer(h.ChunkID.String())
+		if err != nil {
+			return fmt.Errorf("no storage servers available to rehash chunk: %w", err)
+		}
+		serverID, err = uuid.Parse(rehashed)
+		if err != nil {
+			return fmt.Errorf("invalid rehashed server ID %q: %w", rehashed, err)
+		}
+	}
+
+	client, err := r.getClient(serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage client for server %s: %w", serverID, err)
+	}
+
+	data, err := r.spool.Load(h.ChunkID)
+	if err != nil {
+		return err
+	}
+
+	uploadCtx, cancel := context.WithTimeout(ctx, UploadTimeout)
+	defer cancel()
+
+	if err := uploadChunk(uploadCtx, client, h.ChunkID.String(), data, h.SHA256); err != nil {
+		return fmt.Errorf("failed to deliver chunk to server %s: %w", serverID, err)
+	}
+
+	if serverID != h.TargetServerID {
+		if err := r.storage.UpdateChunkStorageServerForReplay(ctx, h.FileID, h.ChunkID, serverID); err != nil {
+			return fmt.Errorf("delivered chunk but failed to record its new server: %w", err)
+		}
+	}
+
+	if err := r.spool.Remove(h.ChunkID); err != nil {
+		return fmt.Errorf("delivered chunk but failed to remove spool entry: %w", err)
+	}
+
+	r.logger.Info("replayed spooled chunk", "chunk_id", h.ChunkID, "target_server_id", serverID)
+	return nil
+}
+
+func (r *Replayer) ringHasServer(serverID uuid.UUID) bool {
+	_, err := r.ring.GetServerInfo(serverID.String())
+	return err == nil
+}
+
+func (r *Replayer) getClient(serverID uuid.UUID) (pb.StorageServiceClient, error) {
+	r.clientsMu.RLock()
+	conn, exists := r.storageClients[serverID]
+	r.clientsMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no connection to storage server %s", serverID)
+	}
+	return pb.NewStorageServiceClient(conn), nil
+}
+
+// uploadChunk streams data to a storage server's PutChunk, the same
+// framing internal/api's UploadChunkToServer uses.
+func uploadChunk(ctx context.Context, client pb.StorageServiceClient, chunkID string, data []byte, checksum string) error {
+	stream, err := client.PutChunk(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create upload stream: %w", err)
+	}
+
+	if checksum == "" {
+		sum := sha256.Sum256(data)
+		checksum = hex.EncodeToString(sum[:])
+	}
+
+	offset := 0
+	for offset < len(data) {
+		end := offset + uploadBufferSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		req := &pb.PutChunkRequest{
+			ChunkId:  chunkID,
+			Checksum: checksum,
+			Data:     data[offset:end],
+		}
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("failed to send chunk data: %w", err)
+		}
+
+		offset = end
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("failed to close upload stream: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("storage server rejected chunk")
+	}
+	return nil
+}
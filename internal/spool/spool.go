@@ -0,0 +1,245 @@
+// Package spool implements a disk-backed write-ahead queue for chunk
+// uploads that couldn't be delivered to their hash-ring-assigned storage
+// server synchronously. uploadOneChunk falls back to Spool.Enqueue instead
+// of failing the whole upload when every attempt at a server is exhausted;
+// SpoolReplayer then retries delivery in the background, re-hashing to a
+// new owner if the original target has since left the ring.
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSpoolFull is returned by Enqueue when adding data would push the
+// spool's on-disk size over its configured cap.
+var ErrSpoolFull = errors.New("spool: capacity exceeded")
+
+// Header is the small JSON record written alongside a spooled chunk's
+// bytes, carrying everything SpoolReplayer needs to retry delivery without
+// the original upload request still being in memory.
+type Header struct {
+	ChunkID        uuid.UUID `json:"chunk_id"`
+	FileID         uuid.UUID `json:"file_id"`
+	ChunkNumber    int       `json:"chunk_number"`
+	TargetServerID uuid.UUID `json:"target_server_id"`
+	SHA256         string    `json:"sha256"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Spool is a disk-backed write-ahead queue of chunks awaiting delivery. It
+// is safe for concurrent use.
+type Spool struct {
+	dir      string
+	maxBytes int64
+
+	mu        sync.Mutex
+	usedBytes int64
+}
+
+// New creates a Spool rooted at dir, creating it if necessary, and scans
+// any entries already there (e.g. left over from a previous process) to
+// seed its used-bytes accounting. maxBytes caps the queue's total data
+// size; 0 means unbounded.
+func New(dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, maxBytes: maxBytes}
+
+	used, err := s.scanUsedBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan existing spool entries: %w", err)
+	}
+	s.usedBytes = used
+
+	return s, nil
+}
+
+func (s *Spool) scanUsedBytes() (int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".data") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+func (s *Spool) headerPath(chunkID uuid.UUID) string {
+	return filepath.Join(s.dir, chunkID.String()+".header.json")
+}
+
+func (s *Spool) dataPath(chunkID uuid.UUID) string {
+	return filepath.Join(s.dir, chunkID.String()+".data")
+}
+
+// Enqueue durably writes header and data to the spool directory, fsyncing
+// and renaming the header into place before doing the same for the data so
+// a crash mid-write leaves at worst a header with no data (skipped by
+// List), never data with no header to make sense of it. It returns
+// ErrSpoolFull without writing anything if data would push the spool over
+// its configured cap.
+func (s *Spool) Enqueue(header Header, data []byte) error {
+	n := int64(len(data))
+
+	s.mu.Lock()
+	if s.maxBytes > 0 && s.usedBytes+n > s.maxBytes {
+		s.mu.Unlock()
+		return ErrSpoolFull
+	}
+	s.usedBytes += n
+	s.mu.Unlock()
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		s.release(n)
+		return fmt.Errorf("failed to marshal spool header: %w", err)
+	}
+
+	if err := writeFileSynced(s.headerPath(header.ChunkID), headerBytes); err != nil {
+		s.release(n)
+		return fmt.Errorf("failed to write spool header: %w", err)
+	}
+
+	if err := writeFileSynced(s.dataPath(header.ChunkID), data); err != nil {
+		s.release(n)
+		os.Remove(s.headerPath(header.ChunkID))
+		return fmt.Errorf("failed to write spool data: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the header of every entry currently in the spool. Entries
+// with a header but no (or unparsable) data file are skipped - most likely
+// a crash mid-Enqueue - rather than erroring, since a future upload attempt
+// will simply spool that chunk again.
+func (s *Spool) List() ([]Header, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool directory: %w", err)
+	}
+
+	var headers []Header
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".header.json") {
+			continue
+		}
+
+		chunkID, err := uuid.Parse(strings.TrimSuffix(e.Name(), ".header.json"))
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(s.dataPath(chunkID)); err != nil {
+			continue
+		}
+
+		raw, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var h Header
+		if err := json.Unmarshal(raw, &h); err != nil {
+			continue
+		}
+		headers = append(headers, h)
+	}
+
+	return headers, nil
+}
+
+// Load returns the spooled bytes for chunkID.
+func (s *Spool) Load(chunkID uuid.UUID) ([]byte, error) {
+	data, err := os.ReadFile(s.dataPath(chunkID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spooled chunk %s: %w", chunkID, err)
+	}
+	return data, nil
+}
+
+// Remove deletes a spooled entry and credits its size back to the spool's
+// capacity, called once a chunk has been successfully replayed.
+func (s *Spool) Remove(chunkID uuid.UUID) error {
+	var freed int64
+	if info, err := os.Stat(s.dataPath(chunkID)); err == nil {
+		freed = info.Size()
+	}
+
+	if err := os.Remove(s.dataPath(chunkID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spooled chunk data: %w", err)
+	}
+	if err := os.Remove(s.headerPath(chunkID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spooled chunk header: %w", err)
+	}
+
+	s.release(freed)
+	return nil
+}
+
+// UsedBytes returns the spool's current on-disk data size.
+func (s *Spool) UsedBytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usedBytes
+}
+
+func (s *Spool) release(n int64) {
+	s.mu.Lock()
+	s.usedBytes -= n
+	if s.usedBytes < 0 {
+		s.usedBytes = 0
+	}
+	s.mu.Unlock()
+}
+
+// writeFileSynced writes data to a temp file in path's directory, fsyncs
+// it, then renames it into place, so a concurrent List never observes a
+// partially written file under path's final name.
+func writeFileSynced(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
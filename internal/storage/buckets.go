@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Bucket represents an S3-style bucket.
+type Bucket struct {
+	Name      string
+	Owner     string
+	Policy    string
+	CreatedAt time.Time
+}
+
+// ObjectKey maps an S3 (bucket, key) pair onto the internal file_id that
+// backs the object's chunks.
+type ObjectKey struct {
+	Bucket    string
+	Key       string
+	FileID    uuid.UUID
+	ETag      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AccessKey represents a SigV4 access key/secret key pair.
+type AccessKey struct {
+	AccessKeyID string
+	SecretKey   string
+	Owner       string
+	Status      string
+}
+
+// CreateBucket creates a new bucket.
+func (s *PostgresStorage) CreateBucket(ctx context.Context, bucket *Bucket) error {
+	query := `
+		INSERT INTO buckets (bucket_name, owner, policy)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`
+
+	err := s.pool.QueryRow(ctx, query, bucket.Name, bucket.Owner, bucket.Policy).Scan(&bucket.CreatedAt)
+	if err != nil {
+		if err.Error() == `ERROR: duplicate key value violates unique constraint "buckets_pkey" (SQLSTATE 23505)` {
+			return fmt.Errorf("%w: bucket already exists", ErrDuplicate)
+		}
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	return nil
+}
+
+// GetBucket retrieves a bucket by name.
+func (s *PostgresStorage) GetBucket(ctx context.Context, name string) (*Bucket, error) {
+	query := `SELECT bucket_name, owner, policy, created_at FROM buckets WHERE bucket_name = $1`
+
+	bucket := &Bucket{}
+	err := s.pool.QueryRow(ctx, query, name).Scan(&bucket.Name, &bucket.Owner, &bucket.Policy, &bucket.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get bucket: %w", err)
+	}
+
+	return bucket, nil
+}
+
+// PutObjectKey creates or overwrites the (bucket, key) -> file_id mapping.
+func (s *PostgresStorage) PutObjectKey(ctx context.Context, obj *ObjectKey) error {
+	query := `
+		INSERT INTO object_keys (bucket_name, object_key, file_id, etag)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (bucket_name, object_key)
+		DO UPDATE SET file_id = EXCLUDED.file_id, etag = EXCLUDED.etag, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := s.pool.QueryRow(ctx, query, obj.Bucket, obj.Key, obj.FileID, obj.ETag).
+		Scan(&obj.CreatedAt, &obj.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to put object key: %w", err)
+	}
+
+	return nil
+}
+
+// GetObjectKey resolves a (bucket, key) pair to its object metadata.
+func (s *PostgresStorage) GetObjectKey(ctx context.Context, bucket, key string) (*ObjectKey, error) {
+	query := `
+		SELECT bucket_name, object_key, file_id, etag, created_at, updated_at
+		FROM object_keys
+		WHERE bucket_name = $1 AND object_key = $2
+	`
+
+	obj := &ObjectKey{}
+	err := s.pool.QueryRow(ctx, query, bucket, key).Scan(
+		&obj.Bucket, &obj.Key, &obj.FileID, &obj.ETag, &obj.CreatedAt, &obj.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get object key: %w", err)
+	}
+
+	return obj, nil
+}
+
+// ListObjectKeys lists object keys in a bucket with an optional prefix,
+// ordered lexicographically, starting after startAfter (for pagination).
+func (s *PostgresStorage) ListObjectKeys(ctx context.Context, bucket, prefix, startAfter string, limit int) ([]*ObjectKey, error) {
+	query := `
+		SELECT bucket_name, object_key, file_id, etag, created_at, updated_at
+		FROM object_keys
+		WHERE bucket_name = $1 AND object_key LIKE $2 AND object_key > $3
+		ORDER BY object_key ASC
+		LIMIT $4
+	`
+
+	rows, err := s.pool.Query(ctx, query, bucket, prefix+"%", startAfter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object keys: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []*ObjectKey
+	for rows.Next() {
+		obj := &ObjectKey{}
+		if err := rows.Scan(&obj.Bucket, &obj.Key, &obj.FileID, &obj.ETag, &obj.CreatedAt, &obj.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan object key: %w", err)
+		}
+		objects = append(objects, obj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating object keys: %w", err)
+	}
+
+	return objects, nil
+}
+
+// DeleteObjectKey removes a (bucket, key) mapping.
+func (s *PostgresStorage) DeleteObjectKey(ctx context.Context, bucket, key string) error {
+	query := `DELETE FROM object_keys WHERE bucket_name = $1 AND object_key = $2`
+
+	result, err := s.pool.Exec(ctx, query, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete object key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAccessKey retrieves an access key by ID for SigV4 signature verification.
+func (s *PostgresStorage) GetAccessKey(ctx context.Context, accessKeyID string) (*AccessKey, error) {
+	query := `SELECT access_key_id, secret_key, owner, status FROM access_keys WHERE access_key_id = $1`
+
+	key := &AccessKey{}
+	err := s.pool.QueryRow(ctx, query, accessKeyID).Scan(&key.AccessKeyID, &key.SecretKey, &key.Owner, &key.Status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get access key: %w", err)
+	}
+
+	return key, nil
+}
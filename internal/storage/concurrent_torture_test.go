@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	defaultConcurrentDuration = 30 * time.Second
+	torturePoolWorkers        = 20
+	tortureRollbackRatio      = 0.3
+	tortureNoopKey            = "torture-noop-key"
+)
+
+// weightedOp is one operation the torture test's worker pool can pick, with
+// a relative selection weight.
+type weightedOp struct {
+	name   string
+	weight int
+	run    func(ctx context.Context, t *testing.T, store *PostgresStorage, h *latencyHistograms, state *tortureState) error
+}
+
+// latencyHistograms tracks per-operation latency samples collected by
+// concurrent workers, guarded by a single mutex since sample volume is low
+// enough that contention doesn't matter for a test harness.
+type latencyHistograms struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+func newLatencyHistograms() *latencyHistograms {
+	return &latencyHistograms{samples: make(map[string][]time.Duration)}
+}
+
+func (h *latencyHistograms) record(op string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples[op] = append(h.samples[op], d)
+}
+
+func (h *latencyHistograms) summary() map[string]time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	avg := make(map[string]time.Duration, len(h.samples))
+	for op, durations := range h.samples {
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		if len(durations) > 0 {
+			avg[op] = total / time.Duration(len(durations))
+		}
+	}
+	return avg
+}
+
+// tortureState tracks IDs created during the run so read/update operations
+// have something real to act on, and counts serialization failures so the
+// test can assert none leaked out as a final error after pgx's own retry
+// logic gave up.
+type tortureState struct {
+	mu                    sync.Mutex
+	fileIDs               []uuid.UUID
+	serverIDs             []uuid.UUID
+	serializationFailures int64
+}
+
+func (s *tortureState) addFile(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileIDs = append(s.fileIDs, id)
+}
+
+func (s *tortureState) addServer(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverIDs = append(s.serverIDs, id)
+}
+
+func (s *tortureState) randomFile(r *rand.Rand) (uuid.UUID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.fileIDs) == 0 {
+		return uuid.UUID{}, false
+	}
+	return s.fileIDs[r.Intn(len(s.fileIDs))], true
+}
+
+func (s *tortureState) randomServer(r *rand.Rand) (uuid.UUID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.serverIDs) == 0 {
+		return uuid.UUID{}, false
+	}
+	return s.serverIDs[r.Intn(len(s.serverIDs))], true
+}
+
+func (s *tortureState) noteSerializationFailure() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serializationFailures++
+}
+
+// TestConcurrentWorkload runs a configurable-duration mixed read/write/
+// heartbeat workload against the shared testPool, the way production
+// traffic actually looks, rather than TestConcurrentWrites' single
+// all-CreateFile burst. Duration is controlled by STORAGE_CONCURRENT_DURATION
+// (default 30s) so it can be shortened in quick CI runs and lengthened for
+// real soak testing.
+func TestConcurrentWorkload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping concurrent torture test in -short mode")
+	}
+
+	duration := defaultConcurrentDuration
+	if v := os.Getenv("STORAGE_CONCURRENT_DURATION"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		require.NoError(t, err, "invalid STORAGE_CONCURRENT_DURATION")
+		duration = parsed
+	}
+
+	ctx := context.Background()
+	store := NewPostgresStorage(testPool)
+	hist := newLatencyHistograms()
+	state := &tortureState{}
+
+	// Seed a few storage servers so server-scoped operations have targets
+	// from the very first tick.
+	for i := 0; i < 3; i++ {
+		server := &StorageServer{GRPCAddress: fmt.Sprintf("localhost:%d", 60000+i)}
+		require.NoError(t, store.CreateStorageServer(ctx, server))
+		state.addServer(server.ServerID)
+	}
+	t.Cleanup(func() {
+		for _, id := range state.serverIDs {
+			testPool.Exec(ctx, "DELETE FROM storage_servers WHERE server_id = $1", id)
+		}
+	})
+
+	ops := []weightedOp{
+		{name: "CreateFile", weight: 5, run: tortureCreateFile},
+		{name: "CreateChunksBatch", weight: 4, run: tortureCreateChunksBatch},
+		{name: "GetFileByID", weight: 8, run: tortureGetFileByID},
+		{name: "UpdateFileStatus", weight: 3, run: tortureUpdateFileStatus},
+		{name: "UpdateHeartbeat", weight: 6, run: tortureUpdateHeartbeat},
+		{name: "GetActiveStorageServers", weight: 4, run: tortureGetActiveServers},
+		{name: "CleanupExpiredSessions", weight: 1, run: tortureCleanupExpiredSessions},
+		{name: "TxCreateFileAndChunk", weight: 3, run: tortureTxCreateFileAndChunk},
+		{name: "NoopWrite", weight: 2, run: tortureNoopWrite},
+	}
+	totalWeight := 0
+	for _, op := range ops {
+		totalWeight += op.weight
+	}
+
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	errCh := make(chan error, torturePoolWorkers*1024)
+
+	for w := 0; w < torturePoolWorkers; w++ {
+		wg.Add(1)
+		go func(workerSeed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(workerSeed))
+
+			for time.Now().Before(deadline) {
+				pick := r.Intn(totalWeight)
+				var chosen weightedOp
+				for _, op := range ops {
+					if pick < op.weight {
+						chosen = op
+						break
+					}
+					pick -= op.weight
+				}
+
+				start := time.Now()
+				err := chosen.run(ctx, t, store, hist, state)
+				hist.record(chosen.name, time.Since(start))
+
+				if err != nil {
+					if classifyError(err) == "serialization_failure" {
+						state.noteSerializationFailure()
+						continue
+					}
+					errCh <- fmt.Errorf("%s: %w", chosen.name, err)
+				}
+			}
+		}(int64(w) + 1)
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Errorf("concurrent workload error: %v", err)
+	}
+
+	t.Logf("average latency by operation: %v", hist.summary())
+	t.Logf("serialization failures observed (expected, not leaked as errors): %d", state.serializationFailures)
+
+	assertTortureInvariants(ctx, t, state)
+}
+
+func tortureCreateFile(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	file := &File{
+		Filename:    fmt.Sprintf("torture-%s.bin", uuid.New()),
+		ContentType: "application/octet-stream",
+		TotalSize:   0,
+	}
+	if err := store.CreateFile(ctx, file); err != nil {
+		return err
+	}
+	state.addFile(file.FileID)
+	return nil
+}
+
+func tortureCreateChunksBatch(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	fileID, ok := state.randomFile(r)
+	if !ok {
+		return nil
+	}
+	serverID, ok := state.randomServer(r)
+	if !ok {
+		return nil
+	}
+
+	const chunkSize = 1024
+	numChunks := 1 + r.Intn(3)
+	chunks := make([]*Chunk, numChunks)
+	for i := range chunks {
+		chunks[i] = &Chunk{
+			FileID:          fileID,
+			ChunkNumber:     i,
+			StorageServerID: serverID,
+			ChunkSize:       chunkSize,
+			ChunkHash:       fmt.Sprintf("torture-hash-%s", uuid.New()),
+		}
+	}
+	if err := store.CreateChunksBatch(ctx, chunks); err != nil {
+		return err
+	}
+	return store.UpdateFileSize(ctx, fileID, chunkSize*int64(numChunks))
+}
+
+func tortureGetFileByID(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	fileID, ok := state.randomFile(r)
+	if !ok {
+		return nil
+	}
+	_, err := store.GetFileByID(ctx, fileID)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func tortureUpdateFileStatus(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	fileID, ok := state.randomFile(r)
+	if !ok {
+		return nil
+	}
+	err := store.UpdateFileStatus(ctx, fileID, "completed")
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func tortureUpdateHeartbeat(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	serverID, ok := state.randomServer(r)
+	if !ok {
+		return nil
+	}
+	err := store.UpdateHeartbeat(ctx, serverID)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func tortureGetActiveServers(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	_, err := store.GetActiveStorageServers(ctx, time.Minute)
+	return err
+}
+
+func tortureCleanupExpiredSessions(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	_, err := store.CleanupExpiredSessions(ctx)
+	return err
+}
+
+// tortureTxCreateFileAndChunk exercises CreateFileInTx+CreateChunkInTx
+// together, rolling back tortureRollbackRatio of the time so the invariant
+// checks also cover "a transaction was abandoned mid-way and must have left
+// no trace".
+func tortureTxCreateFileAndChunk(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	serverID, ok := state.randomServer(r)
+	if !ok {
+		return nil
+	}
+
+	tx, err := testPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	file := &File{
+		Filename:    fmt.Sprintf("torture-tx-%s.bin", uuid.New()),
+		ContentType: "application/octet-stream",
+		TotalSize:   2048,
+	}
+	if err := store.CreateFileInTx(ctx, tx, file); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	chunk := &Chunk{
+		FileID:          file.FileID,
+		ChunkNumber:     0,
+		StorageServerID: serverID,
+		ChunkSize:       2048,
+		ChunkHash:       fmt.Sprintf("torture-tx-hash-%s", uuid.New()),
+	}
+	if err := store.CreateChunkInTx(ctx, tx, chunk); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	if r.Float64() < tortureRollbackRatio {
+		return tx.Rollback(ctx)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	state.addFile(file.FileID)
+	return nil
+}
+
+// tortureNoopWrite touches a magic row with no net state change, to
+// exercise WAL append and commit paths under concurrency without adding
+// anything the invariant checks need to account for.
+func tortureNoopWrite(ctx context.Context, t *testing.T, store *PostgresStorage, hist *latencyHistograms, state *tortureState) error {
+	_, err := testPool.Exec(ctx, `
+		INSERT INTO reclaimer_actions (action_type, details)
+		VALUES ('torture_noop', $1)
+	`, tortureNoopKey)
+	return err
+}
+
+// assertTortureInvariants checks the database is internally consistent
+// after the workload stops: every chunk points at an existing file, every
+// non-pending file's chunk sizes sum to its total_size, and no expired
+// upload session is left behind.
+func assertTortureInvariants(ctx context.Context, t *testing.T, state *tortureState) {
+	var orphanedChunks int
+	err := testPool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM chunks c
+		LEFT JOIN files f ON f.file_id = c.file_id
+		WHERE f.file_id IS NULL
+	`).Scan(&orphanedChunks)
+	require.NoError(t, err)
+	assert.Equal(t, 0, orphanedChunks, "every chunk should point at an existing file")
+
+	var mismatchedSizes int
+	err = testPool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT f.file_id, f.total_size, COALESCE(SUM(c.chunk_size), 0) AS chunk_total
+			FROM files f
+			LEFT JOIN chunks c ON c.file_id = f.file_id
+			WHERE f.upload_status != 'pending'
+			GROUP BY f.file_id, f.total_size
+			HAVING f.total_size != COALESCE(SUM(c.chunk_size), 0)
+		) mismatches
+	`).Scan(&mismatchedSizes)
+	require.NoError(t, err)
+	assert.Equal(t, 0, mismatchedSizes, "every non-pending file's chunk sizes should sum to total_size")
+
+	var orphanedSessions int
+	err = testPool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM upload_sessions
+		WHERE expires_at < NOW() AND status = 'active'
+	`).Scan(&orphanedSessions)
+	require.NoError(t, err)
+	assert.Equal(t, 0, orphanedSessions, "no expired upload session should be left behind")
+
+	// Clean up everything this run created so it doesn't bleed into later
+	// tests that share testPool.
+	for _, id := range state.fileIDs {
+		testPool.Exec(ctx, "DELETE FROM files WHERE file_id = $1", id)
+	}
+	testPool.Exec(ctx, "DELETE FROM reclaimer_actions WHERE action_type = 'torture_noop'")
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DecommissionJob tracks the progress of gracefully draining every chunk off
+// a storage server. CursorChunkNumber/CursorChunkID mark the last chunk
+// moved, in (chunk_number, chunk_id) order, so a restarted gateway can
+// resume a running job instead of rescanning chunks already moved.
+type DecommissionJob struct {
+	JobID             uuid.UUID
+	ServerID          uuid.UUID
+	Status            string
+	CursorChunkNumber int
+	CursorChunkID     *uuid.UUID
+	ChunksTotal       int
+	ChunksMoved       int
+	FailedChunks      int
+	BytesTotal        int64
+	BytesMoved        int64
+	StartedAt         time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// CreateDecommissionJob records a new decommission job for serverID with the
+// ChunksTotal/BytesTotal the caller already set on job (see
+// CountChunksByServerID); it doesn't count the server's chunks itself. It
+// fails with ErrDuplicate if serverID already has a running or paused job.
+func (s *PostgresStorage) CreateDecommissionJob(ctx context.Context, job *DecommissionJob) error {
+	if job.JobID == uuid.Nil {
+		job.JobID = uuid.New()
+	}
+	if job.Status == "" {
+		job.Status = "running"
+	}
+	job.CursorChunkNumber = -1
+
+	query := `
+		INSERT INTO decommission_jobs (job_id, server_id, status, cursor_chunk_number, chunks_total, bytes_total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING started_at, created_at, updated_at
+	`
+
+	err := s.pool.QueryRow(ctx, query,
+		job.JobID, job.ServerID, job.Status, job.CursorChunkNumber, job.ChunksTotal, job.BytesTotal,
+	).Scan(&job.StartedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		if classifyError(err) == "duplicate" {
+			return fmt.Errorf("%w: server %s already has an active decommission job", ErrDuplicate, job.ServerID)
+		}
+		return fmt.Errorf("failed to create decommission job: %w", err)
+	}
+
+	return nil
+}
+
+// GetDecommissionJob retrieves a decommission job by ID.
+func (s *PostgresStorage) GetDecommissionJob(ctx context.Context, jobID uuid.UUID) (*DecommissionJob, error) {
+	query := `
+		SELECT job_id, server_id, status, cursor_chunk_number, cursor_chunk_id,
+		       chunks_total, chunks_moved, failed_chunks, bytes_total, bytes_moved,
+		       started_at, completed_at, created_at, updated_at
+		FROM decommission_jobs
+		WHERE job_id = $1
+	`
+
+	job := &DecommissionJob{}
+	err := s.pool.QueryRow(ctx, query, jobID).Scan(
+		&job.JobID, &job.ServerID, &job.Status, &job.CursorChunkNumber, &job.CursorChunkID,
+		&job.ChunksTotal, &job.ChunksMoved, &job.FailedChunks, &job.BytesTotal, &job.BytesMoved,
+		&job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get decommission job %s: %w", jobID, err)
+	}
+
+	return job, nil
+}
+
+// GetActiveDecommissionJobForServer returns the running or paused
+// decommission job for serverID, if any, so a restarted gateway can resume
+// it instead of starting a duplicate.
+func (s *PostgresStorage) GetActiveDecommissionJobForServer(ctx context.Context, serverID uuid.UUID) (*DecommissionJob, error) {
+	query := `
+		SELECT job_id, server_id, status, cursor_chunk_number, cursor_chunk_id,
+		       chunks_total, chunks_moved, failed_chunks, bytes_total, bytes_moved,
+		       started_at, completed_at, created_at, updated_at
+		FROM decommission_jobs
+		WHERE server_id = $1 AND status IN ('running', 'paused')
+	`
+
+	job := &DecommissionJob{}
+	err := s.pool.QueryRow(ctx, query, serverID).Scan(
+		&job.JobID, &job.ServerID, &job.Status, &job.CursorChunkNumber, &job.CursorChunkID,
+		&job.ChunksTotal, &job.ChunksMoved, &job.FailedChunks, &job.BytesTotal, &job.BytesMoved,
+		&job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get active decommission job for server %s: %w", serverID, err)
+	}
+
+	return job, nil
+}
+
+// RecordDecommissionChunkResult records that one more chunk was moved (or
+// failed to move). It doesn't touch the resumable cursor: chunks within a
+// batch are moved concurrently, so the cursor is only safe to advance once
+// the whole batch has finished (see UpdateDecommissionJobCursor).
+func (s *PostgresStorage) RecordDecommissionChunkResult(ctx context.Context, jobID uuid.UUID, bytesMoved int64, failed bool) error {
+	query := `
+		UPDATE decommission_jobs
+		SET chunks_moved = chunks_moved + CASE WHEN $2 THEN 0 ELSE 1 END,
+		    failed_chunks = failed_chunks + CASE WHEN $2 THEN 1 ELSE 0 END,
+		    bytes_moved = bytes_moved + $3,
+		    updated_at = NOW()
+		WHERE job_id = $1
+	`
+
+	result, err := s.pool.Exec(ctx, query, jobID, failed, bytesMoved)
+	if err != nil {
+		return fmt.Errorf("failed to record chunk result for decommission job %s: %w", jobID, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateDecommissionJobCursor advances the resumable cursor past the last
+// chunk of a fully-processed batch.
+func (s *PostgresStorage) UpdateDecommissionJobCursor(ctx context.Context, jobID uuid.UUID, chunkNumber int, chunkID uuid.UUID) error {
+	query := `
+		UPDATE decommission_jobs
+		SET cursor_chunk_number = $2, cursor_chunk_id = $3, updated_at = NOW()
+		WHERE job_id = $1
+	`
+
+	result, err := s.pool.Exec(ctx, query, jobID, chunkNumber, chunkID)
+	if err != nil {
+		return fmt.Errorf("failed to update decommission job %s cursor: %w", jobID, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetDecommissionJobStatus transitions a decommission job to status
+// (running, paused, completed, cancelled, failed), stamping completed_at
+// when it reaches a terminal state.
+func (s *PostgresStorage) SetDecommissionJobStatus(ctx context.Context, jobID uuid.UUID, status string) error {
+	query := `
+		UPDATE decommission_jobs
+		SET status = $2,
+		    completed_at = CASE WHEN $2 IN ('completed', 'cancelled', 'failed') THEN NOW() ELSE completed_at END,
+		    updated_at = NOW()
+		WHERE job_id = $1
+	`
+
+	result, err := s.pool.Exec(ctx, query, jobID, status)
+	if err != nil {
+		return fmt.Errorf("failed to set decommission job %s status to %q: %w", jobID, status, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CountChunksByServerID returns the number of chunks and their total size
+// currently assigned to serverID, used to seed a new decommission job's
+// ChunksTotal/BytesTotal.
+func (s *PostgresStorage) CountChunksByServerID(ctx context.Context, serverID uuid.UUID) (count int, totalBytes int64, err error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(chunk_size), 0) FROM chunks WHERE storage_server_id = $1`
+
+	if err := s.pool.QueryRow(ctx, query, serverID).Scan(&count, &totalBytes); err != nil {
+		return 0, 0, fmt.Errorf("failed to count chunks for server %s: %w", serverID, err)
+	}
+
+	return count, totalBytes, nil
+}
+
+// GetChunksByServerIDAfter pages through serverID's chunks in
+// (chunk_number, chunk_id) order, starting after afterNumber/afterID, so the
+// decommission worker can resume a partially-drained server without
+// reprocessing chunks it already moved. Pass afterNumber -1 and a nil
+// afterID to start from the beginning.
+func (s *PostgresStorage) GetChunksByServerIDAfter(ctx context.Context, serverID uuid.UUID, afterNumber int, afterID *uuid.UUID, limit int) ([]*Chunk, error) {
+	query := `
+		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size,
+		       chunk_hash, status, erasure_coded, created_at, updated_at
+		FROM chunks
+		WHERE storage_server_id = $1
+		  AND (chunk_number, chunk_id) > ($2, COALESCE($3, '00000000-0000-0000-0000-000000000000'::uuid))
+		ORDER BY chunk_number ASC, chunk_id ASC
+		LIMIT $4
+	`
+
+	rows, err := s.pool.Query(ctx, query, serverID, afterNumber, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to page chunks for server %s: %w", serverID, err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		chunk := &Chunk{}
+		err := rows.Scan(
+			&chunk.ChunkID,
+			&chunk.FileID,
+			&chunk.ChunkNumber,
+			&chunk.StorageServerID,
+			&chunk.ChunkSize,
+			&chunk.ChunkHash,
+			&chunk.Status,
+			&chunk.ErasureCoded,
+			&chunk.CreatedAt,
+			&chunk.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// UpdateChunkStorageServer reassigns a chunk to a new storage server, as the
+// last step of moving its data during decommissioning.
+func (s *PostgresStorage) UpdateChunkStorageServer(ctx context.Context, chunkID, newServerID uuid.UUID) error {
+	query := `UPDATE chunks SET storage_server_id = $2, updated_at = NOW() WHERE chunk_id = $1`
+
+	result, err := s.pool.Exec(ctx, query, chunkID, newServerID)
+	if err != nil {
+		return fmt.Errorf("failed to reassign chunk %s to server %s: %w", chunkID, newServerID, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DeleteJob tracks the progress of an async bulk file deletion, created
+// either from an explicit list of file IDs or from a path prefix snapshotted
+// into delete_job_files at creation time.
+type DeleteJob struct {
+	JobID          uuid.UUID
+	Prefix         *string
+	Status         string
+	FilesTotal     int
+	FilesDeleted   int
+	FilesFailed    int
+	ChunksDeleted  int
+	ChunksFailed   int
+	BytesTotal     int64
+	BytesReclaimed int64
+	StartedAt      time.Time
+	CompletedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CreateDeleteJob snapshots fileIDs into delete_job_files and seeds
+// FilesTotal/BytesTotal from their current total_size, all in one
+// transaction so the job's worklist can never include a file that was never
+// actually counted into its totals.
+func (s *PostgresStorage) CreateDeleteJob(ctx context.Context, job *DeleteJob, fileIDs []uuid.UUID) error {
+	if job.JobID == uuid.Nil {
+		job.JobID = uuid.New()
+	}
+	if job.Status == "" {
+		job.Status = "running"
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin delete job transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var filesTotal int
+	var bytesTotal int64
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(total_size), 0) FROM files WHERE file_id = ANY($1)`,
+		fileIDs,
+	).Scan(&filesTotal, &bytesTotal); err != nil {
+		return fmt.Errorf("failed to sum file sizes for delete job: %w", err)
+	}
+	job.FilesTotal = filesTotal
+	job.BytesTotal = bytesTotal
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO delete_jobs (job_id, prefix, status, files_total, bytes_total)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING started_at, created_at, updated_at
+	`, job.JobID, job.Prefix, job.Status, job.FilesTotal, job.BytesTotal,
+	).Scan(&job.StartedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create delete job: %w", err)
+	}
+
+	if len(fileIDs) > 0 {
+		batch := &pgx.Batch{}
+		for _, fileID := range fileIDs {
+			batch.Queue(`INSERT INTO delete_job_files (job_id, file_id) VALUES ($1, $2)`, job.JobID, fileID)
+		}
+
+		br := tx.SendBatch(ctx, batch)
+		for i := range fileIDs {
+			if _, err := br.Exec(); err != nil {
+				br.Close()
+				return fmt.Errorf("failed to queue file %d for delete job %s: %w", i, job.JobID, err)
+			}
+		}
+		if err := br.Close(); err != nil {
+			return fmt.Errorf("failed to queue files for delete job %s: %w", job.JobID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit delete job %s: %w", job.JobID, err)
+	}
+
+	return nil
+}
+
+// GetDeleteJob retrieves a bulk delete job by ID.
+func (s *PostgresStorage) GetDeleteJob(ctx context.Context, jobID uuid.UUID) (*DeleteJob, error) {
+	query := `
+		SELECT job_id, prefix, status, files_total, files_deleted, files_failed,
+		       chunks_deleted, chunks_failed, bytes_total, bytes_reclaimed,
+		       started_at, completed_at, created_at, updated_at
+		FROM delete_jobs
+		WHERE job_id = $1
+	`
+
+	job := &DeleteJob{}
+	err := s.pool.QueryRow(ctx, query, jobID).Scan(
+		&job.JobID, &job.Prefix, &job.Status, &job.FilesTotal, &job.FilesDeleted, &job.FilesFailed,
+		&job.ChunksDeleted, &job.ChunksFailed, &job.BytesTotal, &job.BytesReclaimed,
+		&job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get delete job %s: %w", jobID, err)
+	}
+
+	return job, nil
+}
+
+// ListRunningDeleteJobs returns every job still in status 'running', so a
+// restarted gateway can re-launch a worker for each instead of leaving it
+// stuck forever.
+func (s *PostgresStorage) ListRunningDeleteJobs(ctx context.Context) ([]*DeleteJob, error) {
+	query := `
+		SELECT job_id, prefix, status, files_total, files_deleted, files_failed,
+		       chunks_deleted, chunks_failed, bytes_total, bytes_reclaimed,
+		       started_at, completed_at, created_at, updated_at
+		FROM delete_jobs
+		WHERE status = 'running'
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running delete jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*DeleteJob
+	for rows.Next() {
+		job := &DeleteJob{}
+		err := rows.Scan(
+			&job.JobID, &job.Prefix, &job.Status, &job.FilesTotal, &job.FilesDeleted, &job.FilesFailed,
+			&job.ChunksDeleted, &job.ChunksFailed, &job.BytesTotal, &job.BytesReclaimed,
+			&job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delete job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating running delete jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// GetPendingDeleteJobFiles pages up to limit still-pending file IDs for
+// jobID, letting the worker resume a crashed job without re-scanning files
+// it already finished.
+func (s *PostgresStorage) GetPendingDeleteJobFiles(ctx context.Context, jobID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT file_id FROM delete_job_files
+		WHERE job_id = $1 AND status = 'pending'
+		LIMIT $2
+	`
+
+	rows, err := s.pool.Query(ctx, query, jobID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to page pending files for delete job %s: %w", jobID, err)
+	}
+	defer rows.Close()
+
+	var fileIDs []uuid.UUID
+	for rows.Next() {
+		var fileID uuid.UUID
+		if err := rows.Scan(&fileID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending delete job file: %w", err)
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending delete job files: %w", err)
+	}
+
+	return fileIDs, nil
+}
+
+// RecordDeleteJobFileResult marks fileID done or failed within jobID and
+// folds its chunk/byte counters into the job's totals.
+func (s *PostgresStorage) RecordDeleteJobFileResult(ctx context.Context, jobID, fileID uuid.UUID, failed bool, chunksDeleted, chunksFailed int, bytesReclaimed int64) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin delete job file result transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	fileStatus := "done"
+	if failed {
+		fileStatus = "failed"
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE delete_job_files SET status = $3 WHERE job_id = $1 AND file_id = $2`,
+		jobID, fileID, fileStatus,
+	); err != nil {
+		return fmt.Errorf("failed to update delete job file %s/%s: %w", jobID, fileID, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE delete_jobs
+		SET files_deleted = files_deleted + CASE WHEN $2 THEN 0 ELSE 1 END,
+		    files_failed = files_failed + CASE WHEN $2 THEN 1 ELSE 0 END,
+		    chunks_deleted = chunks_deleted + $3,
+		    chunks_failed = chunks_failed + $4,
+		    bytes_reclaimed = bytes_reclaimed + $5,
+		    updated_at = NOW()
+		WHERE job_id = $1
+	`, jobID, failed, chunksDeleted, chunksFailed, bytesReclaimed)
+	if err != nil {
+		return fmt.Errorf("failed to update delete job %s totals: %w", jobID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit delete job file result for %s/%s: %w", jobID, fileID, err)
+	}
+
+	return nil
+}
+
+// SetDeleteJobStatus transitions a delete job to status (running, completed,
+// cancelled, failed), stamping completed_at when it reaches a terminal state.
+func (s *PostgresStorage) SetDeleteJobStatus(ctx context.Context, jobID uuid.UUID, status string) error {
+	query := `
+		UPDATE delete_jobs
+		SET status = $2,
+		    completed_at = CASE WHEN $2 IN ('completed', 'cancelled', 'failed') THEN NOW() ELSE completed_at END,
+		    updated_at = NOW()
+		WHERE job_id = $1
+	`
+
+	result, err := s.pool.Exec(ctx, query, jobID, status)
+	if err != nil {
+		return fmt.Errorf("failed to set delete job %s status to %q: %w", jobID, status, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordDanglingChunk records a chunk whose DeleteChunk call exhausted
+// retries during a bulk delete, for an operator or a future reaper to clean
+// up by hand.
+func (s *PostgresStorage) RecordDanglingChunk(ctx context.Context, chunkID, serverID, jobID uuid.UUID, reason string) error {
+	query := `
+		INSERT INTO dangling_chunks (chunk_id, storage_server_id, job_id, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chunk_id, storage_server_id) DO UPDATE SET reason = EXCLUDED.reason, created_at = NOW()
+	`
+
+	if _, err := s.pool.Exec(ctx, query, chunkID, serverID, jobID, reason); err != nil {
+		return fmt.Errorf("failed to record dangling chunk %s on server %s: %w", chunkID, serverID, err)
+	}
+
+	return nil
+}
+
+// DeleteFileByID permanently removes a file's row (chunk rows cascade).
+// Callers are responsible for deleting the file's chunks from their storage
+// servers first; this only drops the metadata.
+func (s *PostgresStorage) DeleteFileByID(ctx context.Context, fileID uuid.UUID) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM files WHERE file_id = $1`, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to delete file %s: %w", fileID, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
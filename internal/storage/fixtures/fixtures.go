@@ -0,0 +1,79 @@
+// Package fixtures seeds a small, deterministic set of rows used by tests
+// that need pre-existing data (a registered storage server, a completed
+// file with chunks) rather than building it up by hand in every test.
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/storage"
+)
+
+const virtualNodesCount = 150
+
+// Fixtures holds the IDs of the rows Load seeded, so tests can reference
+// them directly instead of re-querying for what was just created.
+type Fixtures struct {
+	StorageServerID uuid.UUID
+	FileID          uuid.UUID
+	ChunkIDs        []uuid.UUID
+}
+
+// Load seeds one storage server with its hash ring nodes, and one completed
+// file with 3 chunks on that server, returning their IDs.
+func Load(ctx context.Context, db storage.DBConn) (*Fixtures, error) {
+	store := storage.NewPostgresStorage(db)
+
+	server := &storage.StorageServer{
+		GRPCAddress:    fmt.Sprintf("localhost:%d", 50051),
+		AvailableSpace: 1024 * 1024 * 1024 * 1024,
+		UsedSpace:      0,
+	}
+	if err := store.CreateStorageServer(ctx, server); err != nil {
+		return nil, fmt.Errorf("seeding storage server: %w", err)
+	}
+
+	if err := store.CreateHashRingNodes(ctx, server.ServerID, virtualNodesCount); err != nil {
+		return nil, fmt.Errorf("seeding hash ring nodes: %w", err)
+	}
+
+	file := &storage.File{
+		Filename:    "fixture.txt",
+		ContentType: "text/plain",
+		TotalSize:   3072,
+	}
+	if err := store.CreateFile(ctx, file); err != nil {
+		return nil, fmt.Errorf("seeding file: %w", err)
+	}
+
+	chunks := make([]*storage.Chunk, 0, 3)
+	for i := 0; i < 3; i++ {
+		chunks = append(chunks, &storage.Chunk{
+			FileID:          file.FileID,
+			ChunkNumber:     i,
+			StorageServerID: server.ServerID,
+			ChunkSize:       1024,
+			ChunkHash:       fmt.Sprintf("fixture-hash-%d", i),
+		})
+	}
+	if err := store.CreateChunksBatch(ctx, chunks); err != nil {
+		return nil, fmt.Errorf("seeding chunks: %w", err)
+	}
+
+	if err := store.UpdateFileStatus(ctx, file.FileID, "completed"); err != nil {
+		return nil, fmt.Errorf("marking fixture file completed: %w", err)
+	}
+
+	chunkIDs := make([]uuid.UUID, len(chunks))
+	for i, c := range chunks {
+		chunkIDs[i] = c.ChunkID
+	}
+
+	return &Fixtures{
+		StorageServerID: server.ServerID,
+		FileID:          file.FileID,
+		ChunkIDs:        chunkIDs,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// FileCursor marks a position in (created_at, file_id) order - keyset paging
+// like GetChunksByServerIDAfter's (chunk_number, chunk_id) afterNumber/afterID,
+// but exposed as an opaque token (EncodeFileCursor/DecodeFileCursor) to
+// HTTP callers so paging doesn't regress to OFFSET, which gets slower as the
+// files table grows.
+type FileCursor struct {
+	CreatedAt time.Time
+	FileID    uuid.UUID
+}
+
+// EncodeFileCursor returns an opaque, URL-safe token for cursor.
+func EncodeFileCursor(cursor FileCursor) string {
+	raw := fmt.Sprintf("%d:%s", cursor.CreatedAt.UnixNano(), cursor.FileID)
+	return base64.URLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeFileCursor parses a token produced by EncodeFileCursor.
+func DecodeFileCursor(token string) (FileCursor, error) {
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return FileCursor{}, fmt.Errorf("invalid cursor token: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return FileCursor{}, fmt.Errorf("invalid cursor token")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return FileCursor{}, fmt.Errorf("invalid cursor token")
+	}
+	fileID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return FileCursor{}, fmt.Errorf("invalid cursor token")
+	}
+
+	return FileCursor{CreatedAt: time.Unix(0, nanos), FileID: fileID}, nil
+}
+
+// ListEntries iterates files whose path starts with prefix, in
+// (created_at, file_id) order, optionally resuming after a previous page's
+// cursor. Delete markers are excluded. Iteration stops early if the caller's
+// yield returns false, or after limit files if limit > 0. Query errors are
+// swallowed rather than returned because iter.Seq has no error channel; a
+// caller that needs to distinguish "no more rows" from "query failed" should
+// use GetFileByPath/GetFileByID instead.
+func (s *PostgresStorage) ListEntries(ctx context.Context, prefix string, after *FileCursor, limit int) iter.Seq[*File] {
+	return func(yield func(*File) bool) {
+		query := `
+			SELECT file_id, filename, content_type, total_size, upload_status,
+			       COALESCE(checksum, ''), version_id, is_delete_marker, COALESCE(path, ''), scheme,
+			       created_at, updated_at, completed_at
+			FROM files
+			WHERE is_delete_marker = false
+		`
+		args := make([]interface{}, 0, 4)
+
+		if prefix != "" {
+			args = append(args, prefix+"%")
+			query += fmt.Sprintf(" AND path LIKE $%d", len(args))
+		}
+		if after != nil {
+			args = append(args, after.CreatedAt, after.FileID)
+			query += fmt.Sprintf(" AND (created_at, file_id) > ($%d, $%d)", len(args)-1, len(args))
+		}
+
+		query += " ORDER BY created_at ASC, file_id ASC"
+
+		if limit > 0 {
+			args = append(args, limit)
+			query += fmt.Sprintf(" LIMIT $%d", len(args))
+		}
+
+		rows, err := s.pool.Query(ctx, query, args...)
+		if err != nil {
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			file := &File{}
+			if err := rows.Scan(
+				&file.FileID, &file.Filename, &file.ContentType, &file.TotalSize, &file.UploadStatus,
+				&file.Checksum, &file.VersionID, &file.IsDeleteMarker, &file.Path, &file.Scheme,
+				&file.CreatedAt, &file.UpdatedAt, &file.CompletedAt,
+			); err != nil {
+				return
+			}
+			if !yield(file) {
+				return
+			}
+		}
+	}
+}
+
+// collectFileIDsBatchSize bounds how many files ListEntries fetches per page
+// while CollectFileIDsByPrefix walks a prefix, so a huge prefix doesn't try
+// to pull every matching row in one query.
+const collectFileIDsBatchSize = 500
+
+// CollectFileIDsByPrefix walks every non-deleted file under prefix via
+// ListEntries and returns their IDs, for callers (like a bulk-delete job)
+// that need a full, stable snapshot of a prefix rather than one paginated
+// page of it.
+func (s *PostgresStorage) CollectFileIDsByPrefix(ctx context.Context, prefix string) ([]uuid.UUID, error) {
+	var fileIDs []uuid.UUID
+	var after *FileCursor
+
+	for {
+		count := 0
+		var last FileCursor
+		for file := range s.ListEntries(ctx, prefix, after, collectFileIDsBatchSize) {
+			fileIDs = append(fileIDs, file.FileID)
+			last = FileCursor{CreatedAt: file.CreatedAt, FileID: file.FileID}
+			count++
+		}
+		if count < collectFileIDsBatchSize {
+			break
+		}
+		after = &last
+	}
+
+	return fileIDs, nil
+}
+
+// GetFileByPath retrieves the latest non-deleted file at the given path,
+// mirroring GetFileByID for callers that only have a path (e.g. GET
+// /files/:file_id/metadata?path=).
+func (s *PostgresStorage) GetFileByPath(ctx context.Context, path string) (*File, error) {
+	query := `
+		SELECT file_id, filename, content_type, total_size, upload_status,
+		       COALESCE(checksum, ''), version_id, is_delete_marker, COALESCE(path, ''), scheme,
+		       created_at, updated_at, completed_at
+		FROM files
+		WHERE path = $1 AND is_delete_marker = false
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	file := &File{}
+	err := s.pool.QueryRow(ctx, query, path).Scan(
+		&file.FileID, &file.Filename, &file.ContentType, &file.TotalSize, &file.UploadStatus,
+		&file.Checksum, &file.VersionID, &file.IsDeleteMarker, &file.Path, &file.Scheme,
+		&file.CreatedAt, &file.UpdatedAt, &file.CompletedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get file by path %q: %w", path, err)
+	}
+
+	chunks, err := s.GetChunksByFileID(ctx, file.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunks: %w", err)
+	}
+	file.Chunks = chunks
+
+	return file, nil
+}
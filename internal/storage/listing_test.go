@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListEntries_PrefixAndKeysetPagination verifies ListEntries only
+// returns files under prefix, orders them by (created_at, file_id), and
+// that resuming from a FileCursor picks up where the previous page left off
+// instead of repeating or skipping entries.
+func TestListEntries_PrefixAndKeysetPagination(t *testing.T) {
+	tx, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewPostgresStorage(tx)
+
+	paths := []string{"/photos/a.jpg", "/photos/b.jpg", "/photos/c.jpg", "/docs/readme.txt"}
+	for _, p := range paths {
+		file := &File{Filename: p, ContentType: "application/octet-stream", TotalSize: 1, Path: p}
+		require.NoError(t, store.CreateFile(ctx, file))
+	}
+
+	var all []*File
+	for file := range store.ListEntries(ctx, "/photos/", nil, 0) {
+		all = append(all, file)
+	}
+	require.Len(t, all, 3, "should only return files under /photos/")
+	for _, f := range all {
+		assert.Contains(t, f.Path, "/photos/")
+	}
+
+	var firstPage []*File
+	for file := range store.ListEntries(ctx, "/photos/", nil, 2) {
+		firstPage = append(firstPage, file)
+	}
+	require.Len(t, firstPage, 2, "limit should cap the first page")
+
+	cursor := FileCursor{CreatedAt: firstPage[len(firstPage)-1].CreatedAt, FileID: firstPage[len(firstPage)-1].FileID}
+
+	var secondPage []*File
+	for file := range store.ListEntries(ctx, "/photos/", &cursor, 2) {
+		secondPage = append(secondPage, file)
+	}
+	require.Len(t, secondPage, 1, "second page should return the remaining file")
+	assert.NotEqual(t, firstPage[0].FileID, secondPage[0].FileID)
+	assert.NotEqual(t, firstPage[1].FileID, secondPage[0].FileID)
+}
+
+// TestFileCursor_EncodeDecodeRoundTrip verifies the opaque cursor token used
+// by the HTTP listing endpoints survives a round trip.
+func TestFileCursor_EncodeDecodeRoundTrip(t *testing.T) {
+	tx, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewPostgresStorage(tx)
+
+	file := &File{Filename: "cursor.txt", ContentType: "text/plain", TotalSize: 1, Path: "/cursor.txt"}
+	require.NoError(t, store.CreateFile(ctx, file))
+
+	token := EncodeFileCursor(FileCursor{CreatedAt: file.CreatedAt, FileID: file.FileID})
+	decoded, err := DecodeFileCursor(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, file.FileID, decoded.FileID)
+	assert.True(t, file.CreatedAt.Equal(decoded.CreatedAt))
+
+	_, err = DecodeFileCursor("not-a-valid-token!!!")
+	assert.Error(t, err)
+}
+
+// TestGetFileByPath verifies lookup by path, including the not-found case.
+func TestGetFileByPath(t *testing.T) {
+	tx, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewPostgresStorage(tx)
+
+	file := &File{Filename: "report.pdf", ContentType: "application/pdf", TotalSize: 10, Path: "/reports/report.pdf"}
+	require.NoError(t, store.CreateFile(ctx, file))
+
+	found, err := store.GetFileByPath(ctx, "/reports/report.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, file.FileID, found.FileID)
+
+	_, err = store.GetFileByPath(ctx, "/does/not/exist")
+	assert.Equal(t, ErrNotFound, err, "Should return ErrNotFound")
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// logFieldsKey is the context key ContextWithLogFields stores request-scoped
+// log fields under.
+type logFieldsKey struct{}
+
+type logFields struct {
+	sessionID string
+	uploadID  string
+}
+
+// ContextWithLogFields attaches request-scoped identifiers to ctx so that
+// PostgresStorage's logging picks them up automatically without every call
+// site having to pass them explicitly. Either ID may be left empty.
+func ContextWithLogFields(ctx context.Context, sessionID, uploadID string) context.Context {
+	return context.WithValue(ctx, logFieldsKey{}, logFields{sessionID: sessionID, uploadID: uploadID})
+}
+
+// loggerFromContext returns s.logger enriched via WithValues with any
+// session_id/upload_id stashed in ctx by ContextWithLogFields.
+func (s *PostgresStorage) loggerFromContext(ctx context.Context) logr.Logger {
+	log := s.logger
+	fields, ok := ctx.Value(logFieldsKey{}).(logFields)
+	if !ok {
+		return log
+	}
+	if fields.sessionID != "" {
+		log = log.WithValues("session_id", fields.sessionID)
+	}
+	if fields.uploadID != "" {
+		log = log.WithValues("upload_id", fields.uploadID)
+	}
+	return log
+}
+
+// classifyError buckets a pgx/Postgres error into a small, stable set of
+// categories for logging and alerting, so dashboards don't need to parse
+// SQLSTATE codes or this package's own sentinel errors.
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		return "duplicate"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			return "duplicate"
+		case len(pgErr.Code) >= 2 && pgErr.Code[:2] == "40":
+			return "serialization_failure"
+		}
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return "connection_lost"
+	}
+
+	return "other"
+}
+
+// tracelogAdapter bridges pgx's tracelog.Logger interface to logr, so SQL
+// tracing works with whatever logr backend the binary wires up instead of
+// pinning one implementation.
+type tracelogAdapter struct {
+	logger logr.Logger
+}
+
+// NewTraceLog returns a pgx query tracer (suitable for
+// pgxpool.Config.ConnConfig.Tracer) that logs every SQL statement - text,
+// args, duration, row count - through logger, classifying any error via
+// classifyError.
+func NewTraceLog(logger logr.Logger) *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   &tracelogAdapter{logger: logger},
+		LogLevel: tracelog.LogLevelInfo,
+	}
+}
+
+func (a *tracelogAdapter) Log(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]interface{}) {
+	log := a.logger
+
+	kvs := make([]interface{}, 0, len(data)*2+2)
+	for k, v := range data {
+		kvs = append(kvs, k, v)
+	}
+
+	err, _ := data["err"].(error)
+	if err != nil {
+		kvs = append(kvs, "error_class", classifyError(err))
+	}
+
+	switch level {
+	case tracelog.LogLevelError:
+		log.Error(err, msg, kvs...)
+	case tracelog.LogLevelWarn:
+		log.V(0).Info(msg, kvs...)
+	default:
+		log.V(1).Info(msg, kvs...)
+	}
+}
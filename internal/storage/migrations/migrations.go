@@ -0,0 +1,219 @@
+// Package migrations applies the SQL files in the top-level migrations/
+// directory against a database, tracking which versions have already run in
+// a schema_migrations table. It is used both by operators standing up a new
+// cluster and by the test suite, which otherwise tends to drift out of sync
+// with the schema as new migrations are added.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var filenamePattern = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// Migration is a single versioned schema change loaded from disk.
+type Migration struct {
+	Version int
+	Name    string
+	UpSQL   string
+	// DownSQL is empty if no matching NNN_name.down.sql file exists.
+	DownSQL string
+}
+
+// Load reads every NNN_name.sql file in dir (skipping *.down.sql files,
+// which are loaded as the DownSQL of their matching up-migration) and
+// returns them sorted by version.
+func Load(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations dir: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".down.sql") {
+			continue
+		}
+
+		matches := filenamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing migration version from %q: %w", entry.Name(), err)
+		}
+
+		upSQL, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %q: %w", entry.Name(), err)
+		}
+
+		downSQL := ""
+		downPath := filepath.Join(dir, strings.TrimSuffix(entry.Name(), ".sql")+".down.sql")
+		if data, err := os.ReadFile(downPath); err == nil {
+			downSQL = string(data)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading down migration for %q: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    matches[2],
+			UpSQL:   string(upSQL),
+			DownSQL: downSQL,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up creates the schema_migrations tracking table if needed and applies
+// every migration in dir that has not already been recorded as applied,
+// each in its own transaction.
+func Up(ctx context.Context, pool *pgxpool.Pool, dir string) error {
+	if err := ensureMigrationsTable(ctx, pool); err != nil {
+		return err
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("beginning transaction for migration %d: %w", m.Version, err)
+		}
+
+		if _, err := tx.Exec(ctx, m.UpSQL); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("applying migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+			m.Version, m.Name,
+		); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("recording migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("committing migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Down reverts the most recently applied migration using its DownSQL,
+// returning an error if no migrations have been applied or the most recent
+// one has no down migration.
+func Down(ctx context.Context, pool *pgxpool.Pool, dir string) error {
+	if err := ensureMigrationsTable(ctx, pool); err != nil {
+		return err
+	}
+
+	var version int
+	var name string
+	err := pool.QueryRow(ctx,
+		"SELECT version, name FROM schema_migrations ORDER BY version DESC LIMIT 1",
+	).Scan(&version, &name)
+	if err != nil {
+		return fmt.Errorf("no applied migrations to revert: %w", err)
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return err
+	}
+
+	var target *Migration
+	for i := range migrations {
+		if migrations[i].Version == version {
+			target = &migrations[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("migration %d_%s not found on disk", version, name)
+	}
+	if target.DownSQL == "" {
+		return fmt.Errorf("migration %d_%s has no down migration", version, name)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction to revert migration %d: %w", version, err)
+	}
+
+	if _, err := tx.Exec(ctx, target.DownSQL); err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("reverting migration %d_%s: %w", version, name, err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("unrecording migration %d_%s: %w", version, name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing revert of migration %d_%s: %w", version, name, err)
+	}
+
+	return nil
+}
+
+func ensureMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, pool *pgxpool.Pool) (map[int]bool, error) {
+	rows, err := pool.Query(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("querying applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("scanning applied migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MultipartUpload represents an in-progress S3 multipart upload. FileID
+// points at a placeholder file record that parts attach their chunks to as
+// they arrive, so CompleteMultipartUpload has no need to re-insert chunks.
+type MultipartUpload struct {
+	UploadID    uuid.UUID
+	Bucket      string
+	Key         string
+	FileID      uuid.UUID
+	ContentType string
+	Status      string
+	CreatedAt   time.Time
+}
+
+// MultipartPart represents a single part of a multipart upload. Each part
+// is backed by one chunk uploaded via the existing PutChunk gRPC.
+type MultipartPart struct {
+	UploadID   uuid.UUID
+	PartNumber int
+	ChunkID    uuid.UUID
+	Size       int64
+	MD5        string
+}
+
+// CreateMultipartUpload starts a new multipart upload.
+func (s *PostgresStorage) CreateMultipartUpload(ctx context.Context, upload *MultipartUpload) error {
+	if upload.UploadID == uuid.Nil {
+		upload.UploadID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO multipart_uploads (upload_id, bucket_name, object_key, file_id, content_type)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := s.pool.QueryRow(ctx, query, upload.UploadID, upload.Bucket, upload.Key, upload.FileID, upload.ContentType).
+		Scan(&upload.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	upload.Status = "in-progress"
+	return nil
+}
+
+// GetMultipartUpload retrieves a multipart upload by ID.
+func (s *PostgresStorage) GetMultipartUpload(ctx context.Context, uploadID uuid.UUID) (*MultipartUpload, error) {
+	query := `
+		SELECT upload_id, bucket_name, object_key, file_id, content_type, status, created_at
+		FROM multipart_uploads
+		WHERE upload_id = $1
+	`
+
+	upload := &MultipartUpload{}
+	err := s.pool.QueryRow(ctx, query, uploadID).Scan(
+		&upload.UploadID, &upload.Bucket, &upload.Key, &upload.FileID, &upload.ContentType, &upload.Status, &upload.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get multipart upload: %w", err)
+	}
+
+	return upload, nil
+}
+
+// PutMultipartPart records (or overwrites) a part of a multipart upload.
+func (s *PostgresStorage) PutMultipartPart(ctx context.Context, part *MultipartPart) error {
+	query := `
+		INSERT INTO multipart_parts (upload_id, part_number, chunk_id, size, md5)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (upload_id, part_number)
+		DO UPDATE SET chunk_id = EXCLUDED.chunk_id, size = EXCLUDED.size, md5 = EXCLUDED.md5
+	`
+
+	_, err := s.pool.Exec(ctx, query, part.UploadID, part.PartNumber, part.ChunkID, part.Size, part.MD5)
+	if err != nil {
+		return fmt.Errorf("failed to put multipart part: %w", err)
+	}
+
+	return nil
+}
+
+// GetMultipartParts retrieves all parts of a multipart upload, ordered by
+// part number.
+func (s *PostgresStorage) GetMultipartParts(ctx context.Context, uploadID uuid.UUID) ([]*MultipartPart, error) {
+	query := `
+		SELECT upload_id, part_number, chunk_id, size, md5
+		FROM multipart_parts
+		WHERE upload_id = $1
+		ORDER BY part_number ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query multipart parts: %w", err)
+	}
+	defer rows.Close()
+
+	var parts []*MultipartPart
+	for rows.Next() {
+		part := &MultipartPart{}
+		if err := rows.Scan(&part.UploadID, &part.PartNumber, &part.ChunkID, &part.Size, &part.MD5); err != nil {
+			return nil, fmt.Errorf("failed to scan multipart part: %w", err)
+		}
+		parts = append(parts, part)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating multipart parts: %w", err)
+	}
+
+	return parts, nil
+}
+
+// CompleteMultipartUpload marks a multipart upload as completed.
+func (s *PostgresStorage) CompleteMultipartUpload(ctx context.Context, uploadID uuid.UUID) error {
+	query := `UPDATE multipart_uploads SET status = 'completed' WHERE upload_id = $1`
+
+	result, err := s.pool.Exec(ctx, query, uploadID)
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
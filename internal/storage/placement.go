@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/google/uuid"
+	"github.com/s3storage/internal/telemetry"
+)
+
+type ringToken struct {
+	hash     uint64
+	serverID uuid.UUID
+}
+
+// HashRing is an in-memory consistent-hash placement engine backed by the
+// hash_ring_nodes table. Unlike hasher.HashRing (which the API gateway uses
+// to pick a single server to send a chunk to), PlaceChunk returns an
+// ordered primary-plus-replicas server list for a given file/chunk, and
+// AddServer/RemoveServer recompute only the affected server's tokens and
+// enqueue replication_queue jobs for any chunk whose placement no longer
+// matches the ring, rather than moving chunk data synchronously.
+type HashRing struct {
+	mu     sync.RWMutex
+	tokens []ringToken // sorted ascending by hash
+
+	// reservations tracks, per server, how many chunk slots are currently
+	// claimed by in-progress upload sessions. PlaceChunk stays a pure
+	// function of the ring; reservations exist only so a caller that
+	// claimed capacity ahead of receiving data (e.g. when starting a
+	// resumable session) can give it back if the session is abandoned -
+	// see SessionReaper.
+	reservations map[uuid.UUID]int
+}
+
+// NewHashRing creates an empty placement ring; call Reload to populate it
+// from the database before using it.
+func NewHashRing() *HashRing {
+	return &HashRing{reservations: make(map[uuid.UUID]int)}
+}
+
+// ReserveChunkSlot records that serverID has one more chunk slot claimed by
+// an in-progress upload session.
+func (r *HashRing) ReserveChunkSlot(serverID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reservations == nil {
+		r.reservations = make(map[uuid.UUID]int)
+	}
+	r.reservations[serverID]++
+}
+
+// ReleaseChunkSlot gives back a chunk slot previously claimed on serverID
+// via ReserveChunkSlot. It is a no-op (never goes negative) if nothing is
+// reserved, so it's safe to call when cleaning up a session whose slots may
+// already have been released.
+func (r *HashRing) ReleaseChunkSlot(serverID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reservations[serverID] > 0 {
+		r.reservations[serverID]--
+	}
+}
+
+// ReservedSlots returns how many chunk slots are currently claimed on
+// serverID by in-progress upload sessions.
+func (r *HashRing) ReservedSlots(serverID uuid.UUID) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reservations[serverID]
+}
+
+// Reload discards the current ring and rebuilds it from every row in
+// hash_ring_nodes.
+func (r *HashRing) Reload(ctx context.Context, store *PostgresStorage) error {
+	nodes, err := store.GetAllHashRingNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("loading hash ring nodes: %w", err)
+	}
+
+	tokens := make([]ringToken, 0, len(nodes))
+	for _, n := range nodes {
+		tokens = append(tokens, ringToken{hash: uint64(n.HashValue), serverID: n.ServerID})
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].hash < tokens[j].hash })
+
+	r.mu.Lock()
+	r.tokens = tokens
+	r.mu.Unlock()
+
+	telemetry.HashRingReloads.Inc()
+	return nil
+}
+
+// PlaceChunk returns the primary storage server followed by up to
+// replicas-1 distinct replica servers for the given chunk, walking the ring
+// clockwise from the chunk's key and skipping servers already chosen. It
+// returns fewer than replicas servers if fewer are registered.
+func (r *HashRing) PlaceChunk(fileID uuid.UUID, chunkNumber int, replicas int) []uuid.UUID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tokens) == 0 || replicas <= 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s-%d", fileID, chunkNumber)
+	keyHash := xxhash.Sum64String(key)
+
+	start := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i].hash >= keyHash })
+	if start >= len(r.tokens) {
+		start = 0
+	}
+
+	seen := make(map[uuid.UUID]bool, replicas)
+	result := make([]uuid.UUID, 0, replicas)
+	for i := 0; i < len(r.tokens) && len(result) < replicas; i++ {
+		tok := r.tokens[(start+i)%len(r.tokens)]
+		if seen[tok.serverID] {
+			continue
+		}
+		seen[tok.serverID] = true
+		result = append(result, tok.serverID)
+	}
+
+	for i := range result {
+		role := "replica"
+		if i == 0 {
+			role = "primary"
+		}
+		telemetry.PlacementDecisions.WithLabelValues(role).Inc()
+	}
+
+	return result
+}
+
+// PlaceChunkExcluding returns the first server PlaceChunk would choose for
+// the given chunk that isn't in exclude, walking further around the ring if
+// necessary. It's used by the decommissioner to pick a new home for a chunk
+// without ever picking the server being drained. It returns uuid.Nil if no
+// server outside exclude is registered.
+func (r *HashRing) PlaceChunkExcluding(fileID uuid.UUID, chunkNumber int, exclude map[uuid.UUID]bool) uuid.UUID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tokens) == 0 {
+		return uuid.Nil
+	}
+
+	key := fmt.Sprintf("%s-%d", fileID, chunkNumber)
+	keyHash := xxhash.Sum64String(key)
+
+	start := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i].hash >= keyHash })
+	if start >= len(r.tokens) {
+		start = 0
+	}
+
+	for i := 0; i < len(r.tokens); i++ {
+		tok := r.tokens[(start+i)%len(r.tokens)]
+		if !exclude[tok.serverID] {
+			return tok.serverID
+		}
+	}
+
+	return uuid.Nil
+}
+
+// AddServer loads serverID's virtual nodes from hash_ring_nodes, merges
+// them into the ring, and enqueues a replication_queue job for any chunk
+// whose primary placement now resolves elsewhere than it's currently
+// stored.
+func (r *HashRing) AddServer(ctx context.Context, store *PostgresStorage, serverID uuid.UUID) error {
+	nodes, err := store.GetHashRingNodesForServer(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("loading hash ring nodes for server %s: %w", serverID, err)
+	}
+
+	newTokens := make([]ringToken, 0, len(nodes))
+	for _, n := range nodes {
+		newTokens = append(newTokens, ringToken{hash: uint64(n.HashValue), serverID: n.ServerID})
+	}
+
+	r.mu.Lock()
+	r.tokens = append(r.tokens, newTokens...)
+	sort.Slice(r.tokens, func(i, j int) bool { return r.tokens[i].hash < r.tokens[j].hash })
+	r.mu.Unlock()
+
+	return r.rebalance(ctx, store, "server_added")
+}
+
+// RemoveServer drops serverID's virtual nodes from the ring and enqueues a
+// replication_queue job for every chunk that no longer hashes to its
+// current server as a result, so a separate replicator can rehydrate them
+// onto their new placement.
+func (r *HashRing) RemoveServer(ctx context.Context, store *PostgresStorage, serverID uuid.UUID) error {
+	r.mu.Lock()
+	remaining := make([]ringToken, 0, len(r.tokens))
+	for _, t := range r.tokens {
+		if t.serverID != serverID {
+			remaining = append(remaining, t)
+		}
+	}
+	r.tokens = remaining
+	r.mu.Unlock()
+
+	return r.rebalance(ctx, store, "server_removed")
+}
+
+// rebalance compares every chunk's current placement against the ring's
+// current primary for it, enqueueing a replication_queue job for each
+// mismatch. Chunk moves are never performed synchronously; a separate
+// replicator process drains the queue.
+func (r *HashRing) rebalance(ctx context.Context, store *PostgresStorage, reason string) error {
+	chunks, err := store.GetAllChunks(ctx)
+	if err != nil {
+		return fmt.Errorf("loading chunks to rebalance: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		placement := r.PlaceChunk(chunk.FileID, chunk.ChunkNumber, 1)
+		if len(placement) == 0 || placement[0] == chunk.StorageServerID {
+			continue
+		}
+
+		source := chunk.StorageServerID
+		target := placement[0]
+		job := &ReplicationJob{
+			ChunkID:  chunk.ChunkID,
+			SourceID: &source,
+			TargetID: &target,
+		}
+		if err := store.EnqueueReplicationJob(ctx, job); err != nil {
+			return fmt.Errorf("enqueueing rebalance job for chunk %s: %w", chunk.ChunkID, err)
+		}
+		telemetry.RebalanceJobsGenerated.WithLabelValues(reason).Inc()
+	}
+
+	return nil
+}
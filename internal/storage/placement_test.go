@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func sortRingTokens(tokens []ringToken) {
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].hash < tokens[j].hash })
+}
+
+// buildTestRing constructs a HashRing directly from virtualNodeHash, the same
+// function CreateHashRingNodes uses, without touching Postgres.
+func buildTestRing(servers []uuid.UUID, virtualNodesPerServer int) *HashRing {
+	r := NewHashRing()
+	for _, serverID := range servers {
+		for i := 0; i < virtualNodesPerServer; i++ {
+			r.tokens = append(r.tokens, ringToken{hash: virtualNodeHash(serverID, i), serverID: serverID})
+		}
+	}
+	sortRingTokens(r.tokens)
+	return r
+}
+
+func TestHashRing_AddingServerMovesOnlyASmallFraction(t *testing.T) {
+	const (
+		numServers   = 10
+		virtualNodes = 150
+		numKeys      = 5000
+	)
+
+	servers := make([]uuid.UUID, numServers)
+	for i := range servers {
+		servers[i] = uuid.New()
+	}
+
+	ring := buildTestRing(servers, virtualNodes)
+
+	before := make(map[int]uuid.UUID, numKeys)
+	fileID := uuid.New()
+	for chunkNumber := 0; chunkNumber < numKeys; chunkNumber++ {
+		placement := ring.PlaceChunk(fileID, chunkNumber, 1)
+		if len(placement) != 1 {
+			t.Fatalf("chunk %d: expected 1 placement, got %d", chunkNumber, len(placement))
+		}
+		before[chunkNumber] = placement[0]
+	}
+
+	newServer := uuid.New()
+	for i := 0; i < virtualNodes; i++ {
+		ring.tokens = append(ring.tokens, ringToken{hash: virtualNodeHash(newServer, i), serverID: newServer})
+	}
+	sortRingTokens(ring.tokens)
+
+	moved := 0
+	for chunkNumber := 0; chunkNumber < numKeys; chunkNumber++ {
+		placement := ring.PlaceChunk(fileID, chunkNumber, 1)
+		if placement[0] != before[chunkNumber] {
+			moved++
+		}
+	}
+
+	// Adding the (numServers+1)th server should move roughly 1/(numServers+1)
+	// of keys, not a large fraction of them. Allow generous slack since
+	// virtual-node placement isn't perfectly uniform.
+	maxExpectedMoved := numKeys / (numServers + 1) * 2
+	if moved > maxExpectedMoved {
+		t.Errorf("adding one server moved %d/%d keys, expected at most %d (~2x the ideal 1/%d share)",
+			moved, numKeys, maxExpectedMoved, numServers+1)
+	}
+	if moved == 0 {
+		t.Errorf("adding a server moved 0 keys, expected it to take over roughly 1/%d of the ring", numServers+1)
+	}
+}
@@ -2,13 +2,16 @@ package storage
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var (
@@ -18,18 +21,33 @@ var (
 	ErrDuplicate = errors.New("duplicate resource")
 )
 
-// File represents a file in the storage system
+// File represents a file in the storage system. Versioning makes
+// (Filename, VersionID) the logical identity of an object: deleting the
+// current version inserts a new zero-size row with IsDeleteMarker=true
+// rather than removing anything, so FileID alone only identifies one
+// specific version, current or historical.
 type File struct {
-	FileID       uuid.UUID
-	Filename     string
-	ContentType  string
-	TotalSize    int64
-	UploadStatus string
-	Checksum     string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	CompletedAt  *time.Time
-	Chunks       []*Chunk
+	FileID         uuid.UUID
+	Filename       string
+	ContentType    string
+	TotalSize      int64
+	UploadStatus   string
+	Checksum       string
+	VersionID      uuid.UUID
+	IsDeleteMarker bool
+	// Path is the file's hierarchical location (e.g. "/photos/2024/img.jpg"),
+	// used for directory-style listing (see ListEntries, GetFileByPath). It
+	// defaults to "/"+Filename when a caller doesn't set it explicitly.
+	Path string
+	// Scheme records how this file's chunks were stored: "replicated" (the
+	// default, one whole copy per chunk) or "ec(k,m)" for erasure-coded
+	// files, so the two can coexist and be told apart without scanning
+	// chunks.erasure_coded for every chunk of the file.
+	Scheme      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+	Chunks      []*Chunk
 }
 
 // Chunk represents a file chunk
@@ -41,8 +59,23 @@ type Chunk struct {
 	ChunkSize       int64
 	ChunkHash       string
 	Status          string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// ErasureCoded indicates the chunk was split into data/parity shards
+	// (see ChunkShard) instead of being replicated whole. StorageServerID
+	// still points at the shard with ShardIndex 0 so single-server lookups
+	// keep working even for erasure-coded chunks.
+	ErasureCoded bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ChunkShard maps one data or parity shard of an erasure-coded chunk to the
+// storage server holding it.
+type ChunkShard struct {
+	ChunkID    uuid.UUID
+	ShardIndex int
+	ServerID   uuid.UUID
+	IsParity   bool
+	CreatedAt  time.Time
 }
 
 // StorageServer represents a storage server in the cluster
@@ -63,18 +96,54 @@ type UploadSession struct {
 	FileID    uuid.UUID
 	Status    string
 	ExpiresAt time.Time
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// ExpectedChunks is the total number of chunks the client declared it
+	// will upload. FinalizeSession refuses to complete the session until
+	// this many chunks have been recorded via RecordChunkReceived.
+	ExpectedChunks int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ChunkProgress describes one chunk's reception state within a resumable
+// upload session, as tracked in session_chunks.
+type ChunkProgress struct {
+	ChunkNumber   int
+	State         string
+	ReceivedBytes int64
+	ETag          string
+	UpdatedAt     time.Time
+}
+
+// DBConn is the subset of *pgxpool.Pool that PostgresStorage needs. It is
+// also satisfied by pgx.Tx, so tests can construct a PostgresStorage around
+// a single per-test transaction (rolled back on cleanup) instead of the real
+// pool, without any production call site having to change.
+type DBConn interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 // PostgresStorage implements storage operations using PostgreSQL
 type PostgresStorage struct {
-	pool *pgxpool.Pool
+	pool   DBConn
+	logger logr.Logger
+}
+
+// NewPostgresStorage creates a new PostgresStorage instance with logging
+// disabled. Use NewPostgresStorageWithLogger to enrich query errors and key
+// operations with structured log lines.
+func NewPostgresStorage(pool DBConn) *PostgresStorage {
+	return NewPostgresStorageWithLogger(pool, logr.Discard())
 }
 
-// NewPostgresStorage creates a new PostgresStorage instance
-func NewPostgresStorage(pool *pgxpool.Pool) *PostgresStorage {
-	return &PostgresStorage{pool: pool}
+// NewPostgresStorageWithLogger creates a PostgresStorage that logs through
+// logger. Any logr backend works (zap, zerolog, stdr, ...); PostgresStorage
+// only depends on the logr.Logger interface.
+func NewPostgresStorageWithLogger(pool DBConn, logger logr.Logger) *PostgresStorage {
+	return &PostgresStorage{pool: pool, logger: logger}
 }
 
 // CreateFile creates a new file record
@@ -82,29 +151,47 @@ func (s *PostgresStorage) CreateFile(ctx context.Context, file *File) error {
 	if file.FileID == uuid.Nil {
 		file.FileID = uuid.New()
 	}
+	if file.VersionID == uuid.Nil {
+		file.VersionID = uuid.New()
+	}
+	if file.Path == "" {
+		file.Path = "/" + file.Filename
+	}
+	if file.Scheme == "" {
+		file.Scheme = "replicated"
+	}
 
 	query := `
-		INSERT INTO files (file_id, filename, content_type, total_size, upload_status)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO files (file_id, filename, content_type, total_size, upload_status, version_id, is_delete_marker, path, scheme)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING created_at, updated_at
 	`
 
+	log := s.loggerFromContext(ctx).WithValues("file_id", file.FileID)
+
 	err := s.pool.QueryRow(ctx, query,
 		file.FileID,
 		file.Filename,
 		file.ContentType,
 		file.TotalSize,
 		"pending",
+		file.VersionID,
+		file.IsDeleteMarker,
+		file.Path,
+		file.Scheme,
 	).Scan(&file.CreatedAt, &file.UpdatedAt)
 
 	if err != nil {
 		if err.Error() == "ERROR: duplicate key value violates unique constraint \"files_pkey\" (SQLSTATE 23505)" {
+			log.V(1).Info("CreateFile: duplicate file_id", "error_class", "duplicate")
 			return fmt.Errorf("%w: file_id already exists", ErrDuplicate)
 		}
+		log.Error(err, "CreateFile failed", "error_class", classifyError(err))
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 
 	file.UploadStatus = "pending"
+	log.V(1).Info("CreateFile succeeded")
 	return nil
 }
 
@@ -113,34 +200,51 @@ func (s *PostgresStorage) CreateFileInTx(ctx context.Context, tx pgx.Tx, file *F
 	if file.FileID == uuid.Nil {
 		file.FileID = uuid.New()
 	}
+	if file.VersionID == uuid.Nil {
+		file.VersionID = uuid.New()
+	}
+	if file.Path == "" {
+		file.Path = "/" + file.Filename
+	}
+	if file.Scheme == "" {
+		file.Scheme = "replicated"
+	}
 
 	query := `
-		INSERT INTO files (file_id, filename, content_type, total_size, upload_status)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO files (file_id, filename, content_type, total_size, upload_status, version_id, is_delete_marker, path, scheme)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING created_at, updated_at
 	`
 
+	log := s.loggerFromContext(ctx).WithValues("file_id", file.FileID)
+
 	err := tx.QueryRow(ctx, query,
 		file.FileID,
 		file.Filename,
 		file.ContentType,
 		file.TotalSize,
 		"pending",
+		file.VersionID,
+		file.IsDeleteMarker,
+		file.Path,
+		file.Scheme,
 	).Scan(&file.CreatedAt, &file.UpdatedAt)
 
 	if err != nil {
+		log.Error(err, "CreateFileInTx failed", "error_class", classifyError(err))
 		return fmt.Errorf("failed to create file in transaction: %w", err)
 	}
 
 	file.UploadStatus = "pending"
+	log.V(1).Info("CreateFileInTx succeeded")
 	return nil
 }
 
 // GetFileByID retrieves a file by its ID with associated chunks
 func (s *PostgresStorage) GetFileByID(ctx context.Context, fileID uuid.UUID) (*File, error) {
 	query := `
-		SELECT file_id, filename, content_type, total_size, upload_status, 
-		       COALESCE(checksum, ''), created_at, updated_at, completed_at
+		SELECT file_id, filename, content_type, total_size, upload_status,
+		       COALESCE(checksum, ''), version_id, is_delete_marker, COALESCE(path, ''), scheme, created_at, updated_at, completed_at
 		FROM files
 		WHERE file_id = $1
 	`
@@ -153,6 +257,10 @@ func (s *PostgresStorage) GetFileByID(ctx context.Context, fileID uuid.UUID) (*F
 		&file.TotalSize,
 		&file.UploadStatus,
 		&file.Checksum,
+		&file.VersionID,
+		&file.IsDeleteMarker,
+		&file.Path,
+		&file.Scheme,
 		&file.CreatedAt,
 		&file.UpdatedAt,
 		&file.CompletedAt,
@@ -195,6 +303,28 @@ func (s *PostgresStorage) UpdateFileStatus(ctx context.Context, fileID uuid.UUID
 	return nil
 }
 
+// UpdateFileSize updates a file's recorded total size, used when the size
+// wasn't known up front (e.g. while a multipart upload is still collecting
+// parts).
+func (s *PostgresStorage) UpdateFileSize(ctx context.Context, fileID uuid.UUID, totalSize int64) error {
+	query := `
+		UPDATE files
+		SET total_size = $1, updated_at = NOW()
+		WHERE file_id = $2
+	`
+
+	result, err := s.pool.Exec(ctx, query, totalSize, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to update file size: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 // CreateChunk creates a single chunk record
 func (s *PostgresStorage) CreateChunk(ctx context.Context, chunk *Chunk) error {
 	if chunk.ChunkID == uuid.Nil {
@@ -202,8 +332,8 @@ func (s *PostgresStorage) CreateChunk(ctx context.Context, chunk *Chunk) error {
 	}
 
 	query := `
-		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status, erasure_coded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING created_at, updated_at
 	`
 
@@ -215,6 +345,7 @@ func (s *PostgresStorage) CreateChunk(ctx context.Context, chunk *Chunk) error {
 		chunk.ChunkSize,
 		chunk.ChunkHash,
 		"pending",
+		chunk.ErasureCoded,
 	).Scan(&chunk.CreatedAt, &chunk.UpdatedAt)
 
 	if err != nil {
@@ -232,11 +363,13 @@ func (s *PostgresStorage) CreateChunkInTx(ctx context.Context, tx pgx.Tx, chunk
 	}
 
 	query := `
-		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status, erasure_coded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING created_at, updated_at
 	`
 
+	log := s.loggerFromContext(ctx).WithValues("chunk_id", chunk.ChunkID, "file_id", chunk.FileID, "server_id", chunk.StorageServerID)
+
 	err := tx.QueryRow(ctx, query,
 		chunk.ChunkID,
 		chunk.FileID,
@@ -245,13 +378,16 @@ func (s *PostgresStorage) CreateChunkInTx(ctx context.Context, tx pgx.Tx, chunk
 		chunk.ChunkSize,
 		chunk.ChunkHash,
 		"pending",
+		chunk.ErasureCoded,
 	).Scan(&chunk.CreatedAt, &chunk.UpdatedAt)
 
 	if err != nil {
+		log.Error(err, "CreateChunkInTx failed", "error_class", classifyError(err))
 		return fmt.Errorf("failed to create chunk in transaction: %w", err)
 	}
 
 	chunk.Status = "pending"
+	log.V(1).Info("CreateChunkInTx succeeded")
 	return nil
 }
 
@@ -263,8 +399,8 @@ func (s *PostgresStorage) CreateChunksBatch(ctx context.Context, chunks []*Chunk
 
 	batch := &pgx.Batch{}
 	query := `
-		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO chunks (chunk_id, file_id, chunk_number, storage_server_id, chunk_size, chunk_hash, status, erasure_coded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING chunk_id, created_at, updated_at
 	`
 
@@ -280,9 +416,12 @@ func (s *PostgresStorage) CreateChunksBatch(ctx context.Context, chunks []*Chunk
 			chunk.ChunkSize,
 			chunk.ChunkHash,
 			"pending",
+			chunk.ErasureCoded,
 		)
 	}
 
+	log := s.loggerFromContext(ctx).WithValues("file_id", chunks[0].FileID, "chunk_count", len(chunks))
+
 	results := s.pool.SendBatch(ctx, batch)
 	defer results.Close()
 
@@ -290,19 +429,21 @@ func (s *PostgresStorage) CreateChunksBatch(ctx context.Context, chunks []*Chunk
 		var chunkID uuid.UUID
 		err := results.QueryRow().Scan(&chunkID, &chunk.CreatedAt, &chunk.UpdatedAt)
 		if err != nil {
+			log.Error(err, "CreateChunksBatch failed", "chunk_id", chunk.ChunkID, "error_class", classifyError(err))
 			return fmt.Errorf("failed to create chunk %d: %w", i, err)
 		}
 		chunk.Status = "pending"
 	}
 
+	log.V(1).Info("CreateChunksBatch succeeded")
 	return nil
 }
 
 // GetChunksByFileID retrieves all chunks for a file, ordered by chunk_number
 func (s *PostgresStorage) GetChunksByFileID(ctx context.Context, fileID uuid.UUID) ([]*Chunk, error) {
 	query := `
-		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size, 
-		       chunk_hash, status, created_at, updated_at
+		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size,
+		       chunk_hash, status, erasure_coded, created_at, updated_at
 		FROM chunks
 		WHERE file_id = $1
 		ORDER BY chunk_number ASC
@@ -325,6 +466,7 @@ func (s *PostgresStorage) GetChunksByFileID(ctx context.Context, fileID uuid.UUI
 			&chunk.ChunkSize,
 			&chunk.ChunkHash,
 			&chunk.Status,
+			&chunk.ErasureCoded,
 			&chunk.CreatedAt,
 			&chunk.UpdatedAt,
 		)
@@ -341,6 +483,236 @@ func (s *PostgresStorage) GetChunksByFileID(ctx context.Context, fileID uuid.UUI
 	return chunks, nil
 }
 
+// GetErasureCodedChunks retrieves every erasure-coded chunk belonging to a
+// completed file, for the background shard-repair job to scan.
+func (s *PostgresStorage) GetErasureCodedChunks(ctx context.Context) ([]*Chunk, error) {
+	query := `
+		SELECT c.chunk_id, c.file_id, c.chunk_number, c.storage_server_id, c.chunk_size,
+		       c.chunk_hash, c.status, c.erasure_coded, c.created_at, c.updated_at
+		FROM chunks c
+		JOIN files f ON f.file_id = c.file_id
+		WHERE c.erasure_coded = TRUE AND f.upload_status = 'completed'
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query erasure-coded chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		chunk := &Chunk{}
+		err := rows.Scan(
+			&chunk.ChunkID,
+			&chunk.FileID,
+			&chunk.ChunkNumber,
+			&chunk.StorageServerID,
+			&chunk.ChunkSize,
+			&chunk.ChunkHash,
+			&chunk.Status,
+			&chunk.ErasureCoded,
+			&chunk.CreatedAt,
+			&chunk.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan erasure-coded chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating erasure-coded chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// GetChunkByHash returns a completed, non-erasure-coded chunk matching hash
+// and size, if any, for callers (e.g. content-defined-chunking uploads)
+// using the content hash as a dedup key. Note this only identifies a
+// candidate for dedup bookkeeping: chunks are addressed by chunk_id at the
+// storage-node layer, so reusing stored bytes across files would require
+// that layer to become content-addressed too - out of scope here.
+func (s *PostgresStorage) GetChunkByHash(ctx context.Context, hash string, size int64) (*Chunk, error) {
+	query := `
+		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size,
+		       chunk_hash, status, erasure_coded, created_at, updated_at
+		FROM chunks
+		WHERE chunk_hash = $1 AND chunk_size = $2 AND status = 'completed' AND erasure_coded = FALSE
+		LIMIT 1
+	`
+
+	chunk := &Chunk{}
+	err := s.pool.QueryRow(ctx, query, hash, size).Scan(
+		&chunk.ChunkID,
+		&chunk.FileID,
+		&chunk.ChunkNumber,
+		&chunk.StorageServerID,
+		&chunk.ChunkSize,
+		&chunk.ChunkHash,
+		&chunk.Status,
+		&chunk.ErasureCoded,
+		&chunk.CreatedAt,
+		&chunk.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get chunk by hash: %w", err)
+	}
+
+	return chunk, nil
+}
+
+// GetAllChunks retrieves every chunk in the system, for callers such as the
+// placement ring that need to compare the whole chunk set against the
+// current hash ring.
+func (s *PostgresStorage) GetAllChunks(ctx context.Context) ([]*Chunk, error) {
+	query := `
+		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size,
+		       chunk_hash, status, erasure_coded, created_at, updated_at
+		FROM chunks
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		chunk := &Chunk{}
+		err := rows.Scan(
+			&chunk.ChunkID,
+			&chunk.FileID,
+			&chunk.ChunkNumber,
+			&chunk.StorageServerID,
+			&chunk.ChunkSize,
+			&chunk.ChunkHash,
+			&chunk.Status,
+			&chunk.ErasureCoded,
+			&chunk.CreatedAt,
+			&chunk.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, rows.Err()
+}
+
+// UpdateChunkShardServer repoints a regenerated shard at its new server
+// after the shard-repair job re-uploads it elsewhere.
+func (s *PostgresStorage) UpdateChunkShardServer(ctx context.Context, chunkID uuid.UUID, shardIndex int, serverID uuid.UUID) error {
+	query := `
+		UPDATE chunk_shards
+		SET server_id = $1
+		WHERE chunk_id = $2 AND shard_index = $3
+	`
+
+	result, err := s.pool.Exec(ctx, query, serverID, chunkID, shardIndex)
+	if err != nil {
+		return fmt.Errorf("failed to update chunk shard server: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateChunkStorageServerForReplay repoints a single-replica chunk at its
+// new server after the spool replayer delivers it somewhere other than its
+// original hash-ring target (see internal/spool). Distinct from
+// UpdateChunkStorageServer (internal/storage/decommission.go), which does
+// the equivalent repoint for a chunk moved off a draining server.
+func (s *PostgresStorage) UpdateChunkStorageServerForReplay(ctx context.Context, chunkID uuid.UUID, serverID uuid.UUID) error {
+	query := `
+		UPDATE chunks
+		SET storage_server_id = $1
+		WHERE chunk_id = $2
+	`
+
+	result, err := s.pool.Exec(ctx, query, serverID, chunkID)
+	if err != nil {
+		return fmt.Errorf("failed to update chunk storage server: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateChunkShardsBatch records the server placement of every shard of an
+// erasure-coded chunk in a single batch operation.
+func (s *PostgresStorage) CreateChunkShardsBatch(ctx context.Context, shards []*ChunkShard) error {
+	if len(shards) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	query := `
+		INSERT INTO chunk_shards (chunk_id, shard_index, server_id, is_parity)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	for _, shard := range shards {
+		batch.Queue(query, shard.ChunkID, shard.ShardIndex, shard.ServerID, shard.IsParity)
+	}
+
+	results := s.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	for i, shard := range shards {
+		if err := results.QueryRow().Scan(&shard.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create chunk shard %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// GetChunkShardsByChunkID retrieves the server placement of every shard of
+// an erasure-coded chunk, ordered by shard_index.
+func (s *PostgresStorage) GetChunkShardsByChunkID(ctx context.Context, chunkID uuid.UUID) ([]*ChunkShard, error) {
+	query := `
+		SELECT chunk_id, shard_index, server_id, is_parity, created_at
+		FROM chunk_shards
+		WHERE chunk_id = $1
+		ORDER BY shard_index ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, chunkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunk shards: %w", err)
+	}
+	defer rows.Close()
+
+	var shards []*ChunkShard
+	for rows.Next() {
+		shard := &ChunkShard{}
+		if err := rows.Scan(&shard.ChunkID, &shard.ShardIndex, &shard.ServerID, &shard.IsParity, &shard.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk shard: %w", err)
+		}
+		shards = append(shards, shard)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunk shards: %w", err)
+	}
+
+	return shards, nil
+}
+
 // CreateStorageServer creates a new storage server record or updates if address exists
 func (s *PostgresStorage) CreateStorageServer(ctx context.Context, server *StorageServer) error {
 	if server.ServerID == uuid.Nil {
@@ -395,6 +767,8 @@ func (s *PostgresStorage) CreateStorageServerInTx(ctx context.Context, tx pgx.Tx
 		RETURNING server_id, created_at, updated_at, last_heartbeat
 	`
 
+	log := s.loggerFromContext(ctx).WithValues("server_id", server.ServerID)
+
 	err := tx.QueryRow(ctx, query,
 		server.ServerID,
 		server.GRPCAddress,
@@ -404,13 +778,24 @@ func (s *PostgresStorage) CreateStorageServerInTx(ctx context.Context, tx pgx.Tx
 	).Scan(&server.ServerID, &server.CreatedAt, &server.UpdatedAt, &server.LastHeartbeat)
 
 	if err != nil {
+		log.Error(err, "CreateStorageServerInTx failed", "error_class", classifyError(err))
 		return fmt.Errorf("failed to create storage server in transaction: %w", err)
 	}
+	log.V(1).Info("CreateStorageServerInTx succeeded")
 
 	server.Status = "active"
 	return nil
 }
 
+// virtualNodeHash computes the ring position of virtual node i for serverID
+// as the first 8 bytes of sha1("<serverID>:<i>"), interpreted as a big-endian
+// uint64. The column stores it as a BIGINT, so callers round-trip it through
+// the same int64/uint64 bit-cast the in-memory ring (HashRing) already uses.
+func virtualNodeHash(serverID uuid.UUID, i int) uint64 {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d", serverID, i)))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
 // CreateHashRingNodes creates virtual nodes for consistent hashing
 func (s *PostgresStorage) CreateHashRingNodes(ctx context.Context, serverID uuid.UUID, count int) error {
 	// First, delete any existing hash ring nodes for this server
@@ -427,10 +812,12 @@ func (s *PostgresStorage) CreateHashRingNodes(ctx context.Context, serverID uuid
 		VALUES ($1, $2, $3)
 	`
 
-	// Generate hash values for virtual nodes
+	// Generate hash values for virtual nodes by hashing "<serverID>:<i>" with
+	// SHA-1 and taking the first 8 bytes as an unsigned 64-bit integer, so
+	// the virtual nodes for a single server scatter across the ring instead
+	// of clustering in one adjacent range.
 	for i := 0; i < count; i++ {
-		// Simple hash generation (in production, use proper consistent hashing)
-		hashValue := int64(serverID.ID()) + int64(i)*1000000
+		hashValue := int64(virtualNodeHash(serverID, i))
 		batch.Queue(query, serverID, i, hashValue)
 	}
 
@@ -447,6 +834,56 @@ func (s *PostgresStorage) CreateHashRingNodes(ctx context.Context, serverID uuid
 	return nil
 }
 
+// HashRingNode is a single virtual node row from hash_ring_nodes.
+type HashRingNode struct {
+	ServerID   uuid.UUID
+	VirtualIdx int
+	HashValue  int64
+}
+
+// GetAllHashRingNodes retrieves every virtual node for every storage server,
+// used to build an in-memory placement ring.
+func (s *PostgresStorage) GetAllHashRingNodes(ctx context.Context) ([]HashRingNode, error) {
+	query := `SELECT server_id, virtual_node_index, hash_value FROM hash_ring_nodes`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hash ring nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []HashRingNode
+	for rows.Next() {
+		var node HashRingNode
+		if err := rows.Scan(&node.ServerID, &node.VirtualIdx, &node.HashValue); err != nil {
+			return nil, fmt.Errorf("failed to scan hash ring node: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// GetHashRingNodesForServer retrieves a single server's virtual nodes.
+func (s *PostgresStorage) GetHashRingNodesForServer(ctx context.Context, serverID uuid.UUID) ([]HashRingNode, error) {
+	query := `SELECT server_id, virtual_node_index, hash_value FROM hash_ring_nodes WHERE server_id = $1`
+
+	rows, err := s.pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hash ring nodes for server: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []HashRingNode
+	for rows.Next() {
+		var node HashRingNode
+		if err := rows.Scan(&node.ServerID, &node.VirtualIdx, &node.HashValue); err != nil {
+			return nil, fmt.Errorf("failed to scan hash ring node: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
 // UpdateHeartbeat updates the last_heartbeat timestamp for a storage server
 func (s *PostgresStorage) UpdateHeartbeat(ctx context.Context, serverID uuid.UUID) error {
 	query := `
@@ -455,15 +892,71 @@ func (s *PostgresStorage) UpdateHeartbeat(ctx context.Context, serverID uuid.UUI
 		WHERE server_id = $1
 	`
 
+	log := s.loggerFromContext(ctx).WithValues("server_id", serverID)
+
 	result, err := s.pool.Exec(ctx, query, serverID)
 	if err != nil {
+		log.Error(err, "UpdateHeartbeat failed", "error_class", classifyError(err))
 		return fmt.Errorf("failed to update heartbeat: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
+		log.V(1).Info("UpdateHeartbeat: server not found")
 		return ErrNotFound
 	}
 
+	log.V(1).Info("UpdateHeartbeat succeeded")
+	return nil
+}
+
+// UpdateStorageServerStatus sets serverID's status column, e.g. to "draining"
+// while it finishes in-flight streams before shutting down. The row's
+// presence in GetActiveStorageServers (which filters on status = 'active')
+// changes as soon as this commits, ahead of the server's gossiped Draining
+// flag reaching every gateway.
+func (s *PostgresStorage) UpdateStorageServerStatus(ctx context.Context, serverID uuid.UUID, status string) error {
+	query := `
+		UPDATE storage_servers
+		SET status = $2, updated_at = NOW()
+		WHERE server_id = $1
+	`
+
+	log := s.loggerFromContext(ctx).WithValues("server_id", serverID, "status", status)
+
+	result, err := s.pool.Exec(ctx, query, serverID, status)
+	if err != nil {
+		log.Error(err, "UpdateStorageServerStatus failed", "error_class", classifyError(err))
+		return fmt.Errorf("failed to update storage server status: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	log.V(1).Info("UpdateStorageServerStatus succeeded")
+	return nil
+}
+
+// DeleteStorageServer removes serverID's row entirely, so a restart under
+// the same hostname doesn't collide with the old row on the grpc_address
+// unique constraint. hash_ring_nodes cascades on server_id, so its virtual
+// nodes are removed along with it.
+func (s *PostgresStorage) DeleteStorageServer(ctx context.Context, serverID uuid.UUID) error {
+	query := `DELETE FROM storage_servers WHERE server_id = $1`
+
+	log := s.loggerFromContext(ctx).WithValues("server_id", serverID)
+
+	result, err := s.pool.Exec(ctx, query, serverID)
+	if err != nil {
+		log.Error(err, "DeleteStorageServer failed", "error_class", classifyError(err))
+		return fmt.Errorf("failed to delete storage server: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	log.V(1).Info("DeleteStorageServer succeeded")
 	return nil
 }
 
@@ -519,8 +1012,8 @@ func (s *PostgresStorage) CreateUploadSession(ctx context.Context, session *Uplo
 	expiresAt := time.Now().Add(ttl)
 
 	query := `
-		INSERT INTO upload_sessions (session_id, file_id, status, expires_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO upload_sessions (session_id, file_id, status, expires_at, expected_chunks)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING created_at, updated_at
 	`
 
@@ -529,6 +1022,7 @@ func (s *PostgresStorage) CreateUploadSession(ctx context.Context, session *Uplo
 		session.FileID,
 		"active",
 		expiresAt,
+		session.ExpectedChunks,
 	).Scan(&session.CreatedAt, &session.UpdatedAt)
 
 	if err != nil {
@@ -617,6 +1111,177 @@ func (s *PostgresStorage) DeleteFile(ctx context.Context, fileID uuid.UUID) erro
 	return nil
 }
 
+// CreateDeleteMarker inserts a new zero-size "current version" of filename
+// with IsDeleteMarker=true, the versioned equivalent of deleting an object:
+// it never removes source's row or chunks, so every prior version (and its
+// chunks) remains reachable via ListFileVersions/GetFileByVersion.
+func (s *PostgresStorage) CreateDeleteMarker(ctx context.Context, source *File) (*File, error) {
+	marker := &File{
+		Filename:    source.Filename,
+		ContentType: source.ContentType,
+		TotalSize:   0,
+	}
+	marker.VersionID = uuid.New()
+	marker.IsDeleteMarker = true
+
+	if err := s.CreateFile(ctx, marker); err != nil {
+		return nil, fmt.Errorf("failed to create delete marker for %q: %w", source.Filename, err)
+	}
+	if err := s.UpdateFileStatus(ctx, marker.FileID, "completed"); err != nil {
+		return nil, fmt.Errorf("failed to mark delete marker %q completed: %w", source.Filename, err)
+	}
+	marker.UploadStatus = "completed"
+
+	return marker, nil
+}
+
+// ListFileVersions returns every version of filename (including delete
+// markers), newest first.
+func (s *PostgresStorage) ListFileVersions(ctx context.Context, filename string) ([]*File, error) {
+	query := `
+		SELECT file_id, filename, content_type, total_size, upload_status,
+		       COALESCE(checksum, ''), version_id, is_delete_marker, scheme, created_at, updated_at, completed_at
+		FROM files
+		WHERE filename = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.pool.Query(ctx, query, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions for %q: %w", filename, err)
+	}
+	defer rows.Close()
+
+	var versions []*File
+	for rows.Next() {
+		file := &File{}
+		if err := rows.Scan(
+			&file.FileID, &file.Filename, &file.ContentType, &file.TotalSize, &file.UploadStatus,
+			&file.Checksum, &file.VersionID, &file.IsDeleteMarker, &file.Scheme, &file.CreatedAt, &file.UpdatedAt, &file.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan file version: %w", err)
+		}
+		versions = append(versions, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating file versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetFileByVersion retrieves one specific version of filename, with its chunks.
+func (s *PostgresStorage) GetFileByVersion(ctx context.Context, filename string, versionID uuid.UUID) (*File, error) {
+	query := `
+		SELECT file_id, filename, content_type, total_size, upload_status,
+		       COALESCE(checksum, ''), version_id, is_delete_marker, scheme, created_at, updated_at, completed_at
+		FROM files
+		WHERE filename = $1 AND version_id = $2
+	`
+
+	file := &File{}
+	err := s.pool.QueryRow(ctx, query, filename, versionID).Scan(
+		&file.FileID, &file.Filename, &file.ContentType, &file.TotalSize, &file.UploadStatus,
+		&file.Checksum, &file.VersionID, &file.IsDeleteMarker, &file.Scheme, &file.CreatedAt, &file.UpdatedAt, &file.CompletedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get version %s of %q: %w", versionID, filename, err)
+	}
+
+	chunks, err := s.GetChunksByFileID(ctx, file.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunks: %w", err)
+	}
+	file.Chunks = chunks
+
+	return file, nil
+}
+
+// PendingChunkDelete is a chunk whose DeleteChunk RPC failed when a file
+// version was permanently deleted, queued for the version compactor to retry.
+type PendingChunkDelete struct {
+	ID        uuid.UUID
+	ChunkID   uuid.UUID
+	ServerID  uuid.UUID
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// EnqueuePendingChunkDelete records a chunk delete that failed (e.g. the
+// storage server was unreachable) so the version compactor retries it later
+// instead of leaking disk space on the storage server.
+func (s *PostgresStorage) EnqueuePendingChunkDelete(ctx context.Context, chunkID, serverID uuid.UUID) error {
+	query := `
+		INSERT INTO pending_chunk_deletes (chunk_id, server_id)
+		VALUES ($1, $2)
+	`
+	if _, err := s.pool.Exec(ctx, query, chunkID, serverID); err != nil {
+		return fmt.Errorf("failed to enqueue pending chunk delete for chunk %s: %w", chunkID, err)
+	}
+	return nil
+}
+
+// GetPendingChunkDeletes returns up to limit queued chunk deletes, oldest first.
+func (s *PostgresStorage) GetPendingChunkDeletes(ctx context.Context, limit int) ([]*PendingChunkDelete, error) {
+	query := `
+		SELECT id, chunk_id, server_id, attempts, created_at
+		FROM pending_chunk_deletes
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	rows, err := s.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending chunk deletes: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingChunkDelete
+	for rows.Next() {
+		p := &PendingChunkDelete{}
+		if err := rows.Scan(&p.ID, &p.ChunkID, &p.ServerID, &p.Attempts, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pending chunk delete: %w", err)
+		}
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending chunk deletes: %w", err)
+	}
+
+	return pending, nil
+}
+
+// DeletePendingChunkDelete removes a pending_chunk_deletes row once the
+// retry succeeds.
+func (s *PostgresStorage) DeletePendingChunkDelete(ctx context.Context, id uuid.UUID) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM pending_chunk_deletes WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending chunk delete %s: %w", id, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IncrementPendingChunkDeleteAttempts records another failed retry of a
+// pending chunk delete.
+func (s *PostgresStorage) IncrementPendingChunkDeleteAttempts(ctx context.Context, id uuid.UUID) error {
+	result, err := s.pool.Exec(ctx, `UPDATE pending_chunk_deletes SET attempts = attempts + 1 WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to increment pending chunk delete attempts for %s: %w", id, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // CleanupExpiredSessions deletes expired upload sessions
 func (s *PostgresStorage) CleanupExpiredSessions(ctx context.Context) (int, error) {
 	query := `
@@ -631,3 +1296,116 @@ func (s *PostgresStorage) CleanupExpiredSessions(ctx context.Context) (int, erro
 
 	return int(result.RowsAffected()), nil
 }
+
+// RecordChunkReceived records that chunkNumber of session sessionID arrived
+// with the given etag and size. It is idempotent: a client retrying the
+// same chunk after a network blip re-runs this with the same arguments, and
+// the ON CONFLICT clause only overwrites a row that hasn't already reached
+// "completed", so a late duplicate can't regress (or double-count) a chunk
+// FinalizeSession has already counted.
+func (s *PostgresStorage) RecordChunkReceived(ctx context.Context, sessionID uuid.UUID, chunkNumber int, etag string, size int64) error {
+	query := `
+		INSERT INTO session_chunks (session_id, chunk_number, state, received_bytes, etag, updated_at)
+		VALUES ($1, $2, 'completed', $3, $4, NOW())
+		ON CONFLICT (session_id, chunk_number) DO UPDATE
+		SET state = 'completed', received_bytes = $3, etag = $4, updated_at = NOW()
+		WHERE session_chunks.state <> 'completed'
+	`
+
+	if _, err := s.pool.Exec(ctx, query, sessionID, chunkNumber, size, etag); err != nil {
+		return fmt.Errorf("failed to record chunk %d for session %s: %w", chunkNumber, sessionID, err)
+	}
+
+	return nil
+}
+
+// GetSessionProgress returns the reception state of every chunk recorded so
+// far for sessionID, ordered by chunk number.
+func (s *PostgresStorage) GetSessionProgress(ctx context.Context, sessionID uuid.UUID) ([]ChunkProgress, error) {
+	query := `
+		SELECT chunk_number, state, received_bytes, COALESCE(etag, ''), updated_at
+		FROM session_chunks
+		WHERE session_id = $1
+		ORDER BY chunk_number ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session progress for %s: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var progress []ChunkProgress
+	for rows.Next() {
+		var p ChunkProgress
+		if err := rows.Scan(&p.ChunkNumber, &p.State, &p.ReceivedBytes, &p.ETag, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk progress: %w", err)
+		}
+		progress = append(progress, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session progress: %w", err)
+	}
+
+	return progress, nil
+}
+
+// FinalizeSession atomically flips a session's file to "completed", but
+// only if every one of its ExpectedChunks has a session_chunks row in state
+// "completed" whose etag matches the corresponding chunk's hash in the
+// chunks table. The session row is locked for the duration of the check so
+// two concurrent finalize attempts for the same session can't both succeed.
+func (s *PostgresStorage) FinalizeSession(ctx context.Context, sessionID uuid.UUID) (*File, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin finalize transaction for session %s: %w", sessionID, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var fileID uuid.UUID
+	var expectedChunks int
+	err = tx.QueryRow(ctx, `
+		SELECT file_id, expected_chunks FROM upload_sessions WHERE session_id = $1 FOR UPDATE
+	`, sessionID).Scan(&fileID, &expectedChunks)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+
+	var verifiedChunks int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM session_chunks sc
+		JOIN chunks c ON c.file_id = $1 AND c.chunk_number = sc.chunk_number
+		WHERE sc.session_id = $2 AND sc.state = 'completed' AND sc.etag = c.chunk_hash
+	`, fileID, sessionID).Scan(&verifiedChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify chunks for session %s: %w", sessionID, err)
+	}
+	if verifiedChunks != expectedChunks {
+		return nil, fmt.Errorf("session %s is not ready to finalize: %d/%d chunks verified", sessionID, verifiedChunks, expectedChunks)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE files SET upload_status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE file_id = $1
+	`, fileID); err != nil {
+		return nil, fmt.Errorf("failed to mark file %s completed: %w", fileID, err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE upload_sessions SET status = 'completed', updated_at = NOW()
+		WHERE session_id = $1
+	`, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to mark session %s completed: %w", sessionID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit finalize transaction for session %s: %w", sessionID, err)
+	}
+
+	return s.GetFileByID(ctx, fileID)
+}
@@ -1,8 +1,13 @@
-package storage
+// Package storage_test lives outside package storage so it can import
+// internal/storage/fixtures, which itself imports storage - an external
+// test package breaks that import cycle the way an internal (package
+// storage) test file can't.
+package storage_test
 
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -10,7 +15,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/s3storage/internal/storage"
+	"github.com/s3storage/internal/storage/fixtures"
+	"github.com/s3storage/internal/storage/migrations"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -18,11 +27,17 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-// setupTestDB creates a PostgreSQL container and returns a connection pool
-func setupTestDB(t *testing.T) (*pgxpool.Pool, func()) {
+// testPool is shared across the whole package so that a single container
+// pays for the schema migrations once instead of once per test.
+var testPool *pgxpool.Pool
+
+// TestMain starts one PostgreSQL container for the package, runs every
+// migration in migrations/ against it (not just 001_initial_schema.sql, so
+// the test suite can't silently drift out of sync with the schema the way
+// setupTestDB used to), and tears it down once all tests have run.
+func TestMain(m *testing.M) {
 	ctx := context.Background()
 
-	// Create PostgreSQL container
 	postgresContainer, err := postgres.RunContainer(ctx,
 		testcontainers.WithImage("postgres:15-alpine"),
 		postgres.WithDatabase("s3storage_test"),
@@ -33,33 +48,68 @@ func setupTestDB(t *testing.T) (*pgxpool.Pool, func()) {
 				WithOccurrence(2).
 				WithStartupTimeout(60*time.Second)),
 	)
-	require.NoError(t, err, "Failed to start PostgreSQL container")
+	if err != nil {
+		log.Fatalf("Failed to start PostgreSQL container: %v", err)
+	}
 
-	// Get connection string
 	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
-	require.NoError(t, err, "Failed to get connection string")
+	if err != nil {
+		log.Fatalf("Failed to get connection string: %v", err)
+	}
 
-	// Create connection pool
-	pool, err := pgxpool.New(ctx, connStr)
-	require.NoError(t, err, "Failed to create connection pool")
+	testPool, err = pgxpool.New(ctx, connStr)
+	if err != nil {
+		log.Fatalf("Failed to create connection pool: %v", err)
+	}
 
-	// Load and execute schema
-	schemaPath := filepath.Join("..", "..", "migrations", "001_initial_schema.sql")
-	schemaSQL, err := os.ReadFile(schemaPath)
-	require.NoError(t, err, "Failed to read schema file")
+	migrationsDir := filepath.Join("..", "..", "migrations")
+	if err := migrations.Up(ctx, testPool, migrationsDir); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
 
-	_, err = pool.Exec(ctx, string(schemaSQL))
-	require.NoError(t, err, "Failed to execute schema")
+	code := m.Run()
+
+	testPool.Close()
+	if err := postgresContainer.Terminate(ctx); err != nil {
+		log.Printf("Failed to terminate container: %v", err)
+	}
+
+	os.Exit(code)
+}
+
+// setupTestDB begins a transaction on the shared testPool and returns it in
+// place of the pool itself; storage.PostgresStorage runs the same queries
+// against either, since pgx.Tx implements the same Exec/Query/QueryRow/SendBatch/
+// Begin methods. One difference: NOW() returns the transaction's start time,
+// so every timestamp a test writes through the transaction is the same. The
+// transaction is rolled back on cleanup so tests never see each other's data
+// despite sharing one container.
+func setupTestDB(t *testing.T) (pgx.Tx, func()) {
+	ctx := context.Background()
+
+	tx, err := testPool.Begin(ctx)
+	require.NoError(t, err, "Failed to begin test transaction")
 
-	// Cleanup function
 	cleanup := func() {
-		pool.Close()
-		if err := postgresContainer.Terminate(ctx); err != nil {
-			t.Logf("Failed to terminate container: %v", err)
+		if err := tx.Rollback(ctx); err != nil && err != pgx.ErrTxClosed {
+			t.Logf("Failed to roll back test transaction: %v", err)
 		}
 	}
 
-	return pool, cleanup
+	return tx, cleanup
+}
+
+// setupTestDBWithFixtures is setupTestDB plus a realistic set of
+// pre-existing rows (a storage server with hash ring nodes, and a completed
+// file with chunks), for tests that want to exercise reads against existing
+// data rather than building it up from scratch.
+func setupTestDBWithFixtures(t *testing.T) (pgx.Tx, *fixtures.Fixtures, func()) {
+	tx, cleanup := setupTestDB(t)
+
+	fx, err := fixtures.Load(context.Background(), tx)
+	require.NoError(t, err, "Failed to load fixtures")
+
+	return tx, fx, cleanup
 }
 
 // TestCreateFile_Success tests successful file creation
@@ -68,15 +118,15 @@ func TestCreateFile_Success(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
 
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err, "CreateFile should succeed")
 
 	// Verify file_id was generated
@@ -86,7 +136,7 @@ func TestCreateFile_Success(t *testing.T) {
 	assert.Equal(t, "pending", file.UploadStatus, "UploadStatus should be 'pending'")
 
 	// Verify all fields are saved correctly
-	var savedFile File
+	var savedFile storage.File
 	err = pool.QueryRow(ctx, `
 		SELECT file_id, filename, content_type, total_size, upload_status
 		FROM files WHERE file_id = $1
@@ -110,26 +160,26 @@ func TestCreateFile_DuplicateID(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create first file
-	file1 := &File{
+	file1 := &storage.File{
 		FileID:      uuid.New(),
 		Filename:    "test1.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err := storage.CreateFile(ctx, file1)
+	err := store.CreateFile(ctx, file1)
 	require.NoError(t, err)
 
 	// Try to create second file with same ID
-	file2 := &File{
+	file2 := &storage.File{
 		FileID:      file1.FileID, // Same ID
 		Filename:    "test2.txt",
 		ContentType: "text/plain",
 		TotalSize:   2048,
 	}
-	err = storage.CreateFile(ctx, file2)
+	err = store.CreateFile(ctx, file2)
 	assert.Error(t, err, "Should return error for duplicate file_id")
 	assert.Contains(t, err.Error(), "duplicate", "Error should mention duplicate")
 }
@@ -140,28 +190,28 @@ func TestCreateChunks_Batch(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file first
-	file := &File{
+	file := &storage.File{
 		Filename:    "large.bin",
 		ContentType: "application/octet-stream",
 		TotalSize:   6 * 1024 * 1024 * 1024, // 6 GB
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Create storage server
-	server := &StorageServer{
+	server := &storage.StorageServer{
 		GRPCAddress: "localhost:50051",
 	}
-	err = storage.CreateStorageServer(ctx, server)
+	err = store.CreateStorageServer(ctx, server)
 	require.NoError(t, err)
 
 	// Create 6 chunks
-	chunks := make([]*Chunk, 6)
+	chunks := make([]*storage.Chunk, 6)
 	for i := 0; i < 6; i++ {
-		chunks[i] = &Chunk{
+		chunks[i] = &storage.Chunk{
 			FileID:          file.FileID,
 			ChunkNumber:     i,
 			StorageServerID: server.ServerID,
@@ -170,7 +220,7 @@ func TestCreateChunks_Batch(t *testing.T) {
 		}
 	}
 
-	err = storage.CreateChunksBatch(ctx, chunks)
+	err = store.CreateChunksBatch(ctx, chunks)
 	require.NoError(t, err, "Batch insert should succeed")
 
 	// Verify all 6 chunks were created
@@ -194,26 +244,26 @@ func TestGetFile_ByID(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file with chunks
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.dat",
 		ContentType: "application/octet-stream",
 		TotalSize:   6000,
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Create storage server
-	server := &StorageServer{GRPCAddress: "localhost:50051"}
-	err = storage.CreateStorageServer(ctx, server)
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err = store.CreateStorageServer(ctx, server)
 	require.NoError(t, err)
 
 	// Create chunks
-	chunks := make([]*Chunk, 6)
+	chunks := make([]*storage.Chunk, 6)
 	for i := 0; i < 6; i++ {
-		chunks[i] = &Chunk{
+		chunks[i] = &storage.Chunk{
 			FileID:          file.FileID,
 			ChunkNumber:     i,
 			StorageServerID: server.ServerID,
@@ -221,11 +271,11 @@ func TestGetFile_ByID(t *testing.T) {
 			ChunkHash:       fmt.Sprintf("hash%d", i),
 		}
 	}
-	err = storage.CreateChunksBatch(ctx, chunks)
+	err = store.CreateChunksBatch(ctx, chunks)
 	require.NoError(t, err)
 
 	// Get file with chunks
-	retrievedFile, err := storage.GetFileByID(ctx, file.FileID)
+	retrievedFile, err := store.GetFileByID(ctx, file.FileID)
 	require.NoError(t, err, "Should retrieve file")
 	assert.Equal(t, file.FileID, retrievedFile.FileID)
 	assert.Equal(t, file.Filename, retrievedFile.Filename)
@@ -245,12 +295,12 @@ func TestGetFile_NotFound(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	nonExistentID := uuid.New()
-	_, err := storage.GetFileByID(ctx, nonExistentID)
+	_, err := store.GetFileByID(ctx, nonExistentID)
 	assert.Error(t, err, "Should return error for non-existent file")
-	assert.Equal(t, ErrNotFound, err, "Should return ErrNotFound")
+	assert.Equal(t, storage.ErrNotFound, err, "Should return ErrNotFound")
 }
 
 // TestUpdateFileStatus tests file status updates
@@ -259,15 +309,15 @@ func TestUpdateFileStatus(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Get initial updated_at
@@ -279,7 +329,7 @@ func TestUpdateFileStatus(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Update status: pending → uploading
-	err = storage.UpdateFileStatus(ctx, file.FileID, "uploading")
+	err = store.UpdateFileStatus(ctx, file.FileID, "uploading")
 	require.NoError(t, err)
 
 	var status string
@@ -291,7 +341,7 @@ func TestUpdateFileStatus(t *testing.T) {
 
 	// Update status: uploading → completed
 	time.Sleep(10 * time.Millisecond)
-	err = storage.UpdateFileStatus(ctx, file.FileID, "completed")
+	err = store.UpdateFileStatus(ctx, file.FileID, "completed")
 	require.NoError(t, err)
 
 	err = pool.QueryRow(ctx, "SELECT upload_status, updated_at FROM files WHERE file_id = $1", file.FileID).Scan(&status, &updatedAt)
@@ -305,38 +355,38 @@ func TestGetChunksByFileID(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.bin",
 		ContentType: "application/octet-stream",
 		TotalSize:   6000,
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Create storage server
-	server := &StorageServer{GRPCAddress: "localhost:50051"}
-	err = storage.CreateStorageServer(ctx, server)
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err = store.CreateStorageServer(ctx, server)
 	require.NoError(t, err)
 
 	// Create 6 chunks in random order
 	chunkNumbers := []int{3, 1, 5, 0, 4, 2}
 	for _, num := range chunkNumbers {
-		chunk := &Chunk{
+		chunk := &storage.Chunk{
 			FileID:          file.FileID,
 			ChunkNumber:     num,
 			StorageServerID: server.ServerID,
 			ChunkSize:       1000,
 			ChunkHash:       fmt.Sprintf("hash%d", num),
 		}
-		err = storage.CreateChunk(ctx, chunk)
+		err = store.CreateChunk(ctx, chunk)
 		require.NoError(t, err)
 	}
 
 	// Get chunks
-	chunks, err := storage.GetChunksByFileID(ctx, file.FileID)
+	chunks, err := store.GetChunksByFileID(ctx, file.FileID)
 	require.NoError(t, err)
 	assert.Len(t, chunks, 6, "Should return 6 chunks")
 
@@ -352,7 +402,7 @@ func TestTransaction_Rollback(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Begin transaction
 	tx, err := pool.Begin(ctx)
@@ -360,28 +410,28 @@ func TestTransaction_Rollback(t *testing.T) {
 	defer tx.Rollback(ctx)
 
 	// Create file in transaction
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err = storage.CreateFileInTx(ctx, tx, file)
+	err = store.CreateFileInTx(ctx, tx, file)
 	require.NoError(t, err)
 
 	// Create storage server
-	server := &StorageServer{GRPCAddress: "localhost:50051"}
-	err = storage.CreateStorageServerInTx(ctx, tx, server)
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err = store.CreateStorageServerInTx(ctx, tx, server)
 	require.NoError(t, err)
 
 	// Create chunk
-	chunk := &Chunk{
+	chunk := &storage.Chunk{
 		FileID:          file.FileID,
 		ChunkNumber:     0,
 		StorageServerID: server.ServerID,
 		ChunkSize:       1024,
 		ChunkHash:       "hash0",
 	}
-	err = storage.CreateChunkInTx(ctx, tx, chunk)
+	err = store.CreateChunkInTx(ctx, tx, chunk)
 	require.NoError(t, err)
 
 	// Rollback transaction
@@ -405,7 +455,7 @@ func TestTransaction_Commit(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Begin transaction
 	tx, err := pool.Begin(ctx)
@@ -413,28 +463,28 @@ func TestTransaction_Commit(t *testing.T) {
 	defer tx.Rollback(ctx)
 
 	// Create file in transaction
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err = storage.CreateFileInTx(ctx, tx, file)
+	err = store.CreateFileInTx(ctx, tx, file)
 	require.NoError(t, err)
 
 	// Create storage server
-	server := &StorageServer{GRPCAddress: "localhost:50051"}
-	err = storage.CreateStorageServerInTx(ctx, tx, server)
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err = store.CreateStorageServerInTx(ctx, tx, server)
 	require.NoError(t, err)
 
 	// Create chunk
-	chunk := &Chunk{
+	chunk := &storage.Chunk{
 		FileID:          file.FileID,
 		ChunkNumber:     0,
 		StorageServerID: server.ServerID,
 		ChunkSize:       1024,
 		ChunkHash:       "hash0",
 	}
-	err = storage.CreateChunkInTx(ctx, tx, chunk)
+	err = store.CreateChunkInTx(ctx, tx, chunk)
 	require.NoError(t, err)
 
 	// Commit transaction
@@ -458,15 +508,15 @@ func TestStorageServerRegistration(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Register storage server
-	server := &StorageServer{
+	server := &storage.StorageServer{
 		GRPCAddress:    "localhost:50051",
 		AvailableSpace: 1024 * 1024 * 1024 * 1024, // 1 TB
 		UsedSpace:      0,
 	}
-	err := storage.CreateStorageServer(ctx, server)
+	err := store.CreateStorageServer(ctx, server)
 	require.NoError(t, err)
 	assert.NotEqual(t, uuid.Nil, server.ServerID, "ServerID should be generated")
 
@@ -477,7 +527,7 @@ func TestStorageServerRegistration(t *testing.T) {
 	assert.Equal(t, 1, count)
 
 	// Create 150 virtual nodes
-	err = storage.CreateHashRingNodes(ctx, server.ServerID, 150)
+	err = store.CreateHashRingNodes(ctx, server.ServerID, 150)
 	require.NoError(t, err)
 
 	// Verify 150 nodes were created
@@ -492,11 +542,11 @@ func TestStorageServerHeartbeat(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create server
-	server := &StorageServer{GRPCAddress: "localhost:50051"}
-	err := storage.CreateStorageServer(ctx, server)
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err := store.CreateStorageServer(ctx, server)
 	require.NoError(t, err)
 
 	// Get initial heartbeat
@@ -506,7 +556,7 @@ func TestStorageServerHeartbeat(t *testing.T) {
 
 	// Wait and update heartbeat
 	time.Sleep(100 * time.Millisecond)
-	err = storage.UpdateHeartbeat(ctx, server.ServerID)
+	err = store.UpdateHeartbeat(ctx, server.ServerID)
 	require.NoError(t, err)
 
 	// Verify heartbeat was updated
@@ -516,24 +566,87 @@ func TestStorageServerHeartbeat(t *testing.T) {
 	assert.True(t, updatedHeartbeat.After(initialHeartbeat), "Heartbeat should be updated")
 }
 
+// TestUpdateStorageServerStatus tests marking a server draining and back
+func TestUpdateStorageServerStatus(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(pool)
+
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err := store.CreateStorageServer(ctx, server)
+	require.NoError(t, err)
+
+	err = store.UpdateStorageServerStatus(ctx, server.ServerID, "draining")
+	require.NoError(t, err)
+
+	var status string
+	err = pool.QueryRow(ctx, "SELECT status FROM storage_servers WHERE server_id = $1", server.ServerID).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "draining", status)
+
+	// A draining server is excluded from GetActiveStorageServers' status =
+	// 'active' filter even though its heartbeat is still fresh.
+	activeServers, err := store.GetActiveStorageServers(ctx, 30*time.Second)
+	require.NoError(t, err)
+	for _, s := range activeServers {
+		assert.NotEqual(t, server.ServerID, s.ServerID, "draining server should not be returned as active")
+	}
+
+	err = store.UpdateStorageServerStatus(ctx, uuid.New(), "draining")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+// TestDeleteStorageServer tests that deleting a server also removes its
+// hash ring nodes via the FK cascade
+func TestDeleteStorageServer(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(pool)
+
+	server := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	err := store.CreateStorageServer(ctx, server)
+	require.NoError(t, err)
+	err = store.CreateHashRingNodes(ctx, server.ServerID, 10)
+	require.NoError(t, err)
+
+	err = store.DeleteStorageServer(ctx, server.ServerID)
+	require.NoError(t, err)
+
+	var count int
+	err = pool.QueryRow(ctx, "SELECT COUNT(*) FROM storage_servers WHERE server_id = $1", server.ServerID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	err = pool.QueryRow(ctx, "SELECT COUNT(*) FROM hash_ring_nodes WHERE server_id = $1", server.ServerID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "hash ring nodes should cascade-delete")
+
+	err = store.DeleteStorageServer(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
 // TestGetActiveStorageServers tests active server retrieval
 func TestGetActiveStorageServers(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create 3 servers
-	server1 := &StorageServer{GRPCAddress: "localhost:50051"}
-	server2 := &StorageServer{GRPCAddress: "localhost:50052"}
-	server3 := &StorageServer{GRPCAddress: "localhost:50053"}
+	server1 := &storage.StorageServer{GRPCAddress: "localhost:50051"}
+	server2 := &storage.StorageServer{GRPCAddress: "localhost:50052"}
+	server3 := &storage.StorageServer{GRPCAddress: "localhost:50053"}
 
-	err := storage.CreateStorageServer(ctx, server1)
+	err := store.CreateStorageServer(ctx, server1)
 	require.NoError(t, err)
-	err = storage.CreateStorageServer(ctx, server2)
+	err = store.CreateStorageServer(ctx, server2)
 	require.NoError(t, err)
-	err = storage.CreateStorageServer(ctx, server3)
+	err = store.CreateStorageServer(ctx, server3)
 	require.NoError(t, err)
 
 	// Make server3 inactive by setting old heartbeat
@@ -542,7 +655,7 @@ func TestGetActiveStorageServers(t *testing.T) {
 	require.NoError(t, err)
 
 	// Get active servers (heartbeat within last 30 seconds)
-	activeServers, err := storage.GetActiveStorageServers(ctx, 30*time.Second)
+	activeServers, err := store.GetActiveStorageServers(ctx, 30*time.Second)
 	require.NoError(t, err)
 	assert.Len(t, activeServers, 2, "Should return only 2 active servers")
 
@@ -558,22 +671,22 @@ func TestUploadSession_Create(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Create upload session
-	session := &UploadSession{
+	session := &storage.UploadSession{
 		FileID: file.FileID,
 	}
-	err = storage.CreateUploadSession(ctx, session, 1*time.Hour)
+	err = store.CreateUploadSession(ctx, session, 1*time.Hour)
 	require.NoError(t, err)
 
 	// Verify session was created
@@ -595,22 +708,22 @@ func TestUploadSession_Cleanup(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
 
 	// Create file
-	file := &File{
+	file := &storage.File{
 		Filename:    "test.txt",
 		ContentType: "text/plain",
 		TotalSize:   1024,
 	}
-	err := storage.CreateFile(ctx, file)
+	err := store.CreateFile(ctx, file)
 	require.NoError(t, err)
 
 	// Create expired session
-	session := &UploadSession{
+	session := &storage.UploadSession{
 		FileID: file.FileID,
 	}
-	err = storage.CreateUploadSession(ctx, session, -1*time.Hour) // Expired 1 hour ago
+	err = store.CreateUploadSession(ctx, session, -1*time.Hour) // Expired 1 hour ago
 	require.NoError(t, err)
 
 	// Verify session exists
@@ -620,7 +733,7 @@ func TestUploadSession_Cleanup(t *testing.T) {
 	assert.Equal(t, 1, count)
 
 	// Run cleanup
-	deletedCount, err := storage.CleanupExpiredSessions(ctx)
+	deletedCount, err := store.CleanupExpiredSessions(ctx)
 	require.NoError(t, err)
 	assert.Equal(t, 1, deletedCount, "Should delete 1 expired session")
 
@@ -630,13 +743,87 @@ func TestUploadSession_Cleanup(t *testing.T) {
 	assert.Equal(t, 0, count, "Expired session should be deleted")
 }
 
-// TestConcurrentWrites tests concurrent file creation
-func TestConcurrentWrites(t *testing.T) {
+// TestSessionChunks_PartialCompletion verifies FinalizeSession refuses to
+// complete a session until every expected chunk has been recorded.
+func TestSessionChunks_PartialCompletion(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(pool)
+
+	server := &storage.StorageServer{GRPCAddress: "localhost:61000"}
+	require.NoError(t, store.CreateStorageServer(ctx, server))
+
+	file := &storage.File{Filename: "partial.bin", ContentType: "application/octet-stream", TotalSize: 2048}
+	require.NoError(t, store.CreateFile(ctx, file))
+
+	session := &storage.UploadSession{FileID: file.FileID, ExpectedChunks: 2}
+	require.NoError(t, store.CreateUploadSession(ctx, session, 1*time.Hour))
+
+	chunk0 := &storage.Chunk{FileID: file.FileID, ChunkNumber: 0, StorageServerID: server.ServerID, ChunkSize: 1024, ChunkHash: "hash-0"}
+	require.NoError(t, store.CreateChunk(ctx, chunk0))
+	require.NoError(t, store.RecordChunkReceived(ctx, session.SessionID, 0, "hash-0", 1024))
+
+	// Only 1 of 2 expected chunks has arrived - finalize must fail.
+	_, err := store.FinalizeSession(ctx, session.SessionID)
+	assert.Error(t, err)
+
+	progress, err := store.GetSessionProgress(ctx, session.SessionID)
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, "completed", progress[0].State)
+
+	// Complete the second chunk - finalize should now succeed.
+	chunk1 := &storage.Chunk{FileID: file.FileID, ChunkNumber: 1, StorageServerID: server.ServerID, ChunkSize: 1024, ChunkHash: "hash-1"}
+	require.NoError(t, store.CreateChunk(ctx, chunk1))
+	require.NoError(t, store.RecordChunkReceived(ctx, session.SessionID, 1, "hash-1", 1024))
+
+	finalized, err := store.FinalizeSession(ctx, session.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", finalized.UploadStatus)
+}
+
+// TestSessionChunks_RetryIsIdempotent verifies that recording the same
+// chunk twice - as a client would after a network blip - doesn't regress or
+// double-count it.
+func TestSessionChunks_RetryIsIdempotent(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	ctx := context.Background()
-	storage := NewPostgresStorage(pool)
+	store := storage.NewPostgresStorage(pool)
+
+	file := &storage.File{Filename: "retry.bin", ContentType: "application/octet-stream", TotalSize: 1024}
+	require.NoError(t, store.CreateFile(ctx, file))
+
+	session := &storage.UploadSession{FileID: file.FileID, ExpectedChunks: 1}
+	require.NoError(t, store.CreateUploadSession(ctx, session, 1*time.Hour))
+
+	require.NoError(t, store.RecordChunkReceived(ctx, session.SessionID, 0, "hash-0", 1024))
+	// Client didn't see the ack and retries with the same etag/size.
+	require.NoError(t, store.RecordChunkReceived(ctx, session.SessionID, 0, "hash-0", 1024))
+
+	progress, err := store.GetSessionProgress(ctx, session.SessionID)
+	require.NoError(t, err)
+	require.Len(t, progress, 1, "retrying the same chunk must not create a second row")
+	assert.Equal(t, "completed", progress[0].State)
+}
+
+// TestConcurrentWrites tests concurrent file creation. Unlike the other
+// tests it runs directly against the shared testPool rather than a per-test
+// transaction: a single pgx.Tx isn't safe for concurrent use by multiple
+// goroutines, and this test is specifically exercising real concurrent
+// access. It cleans up its own rows instead of relying on a rollback.
+func TestConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(testPool)
+	pool := testPool
+	t.Cleanup(func() {
+		if _, err := pool.Exec(ctx, "DELETE FROM files WHERE filename LIKE 'file%.txt'"); err != nil {
+			t.Logf("Failed to clean up concurrent test files: %v", err)
+		}
+	})
 
 	const numGoroutines = 10
 	var wg sync.WaitGroup
@@ -648,13 +835,13 @@ func TestConcurrentWrites(t *testing.T) {
 		go func(index int) {
 			defer wg.Done()
 
-			file := &File{
+			file := &storage.File{
 				Filename:    fmt.Sprintf("file%d.txt", index),
 				ContentType: "text/plain",
 				TotalSize:   1024,
 			}
 
-			if err := storage.CreateFile(ctx, file); err != nil {
+			if err := store.CreateFile(ctx, file); err != nil {
 				errors <- err
 			}
 		}(i)
@@ -674,3 +861,140 @@ func TestConcurrentWrites(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, numGoroutines, count, "Should have created 10 files")
 }
+
+// TestSessionChunks_ConcurrentRetries fires the same chunk's
+// RecordChunkReceived concurrently, as a client and its retry might race
+// against each other, and checks exactly one session_chunks row results
+// with no error from the upsert's WHERE clause racing itself.
+func TestSessionChunks_ConcurrentRetries(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(testPool)
+
+	file := &storage.File{Filename: fmt.Sprintf("concurrent-session-%s.bin", uuid.New()), ContentType: "application/octet-stream", TotalSize: 1024}
+	require.NoError(t, store.CreateFile(ctx, file))
+	t.Cleanup(func() {
+		testPool.Exec(ctx, "DELETE FROM files WHERE file_id = $1", file.FileID)
+	})
+
+	session := &storage.UploadSession{FileID: file.FileID, ExpectedChunks: 1}
+	require.NoError(t, store.CreateUploadSession(ctx, session, 1*time.Hour))
+
+	const numWriters = 10
+	var wg sync.WaitGroup
+	errCh := make(chan error, numWriters)
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.RecordChunkReceived(ctx, session.SessionID, 0, "hash-0", 1024); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Errorf("concurrent RecordChunkReceived error: %v", err)
+	}
+
+	progress, err := store.GetSessionProgress(ctx, session.SessionID)
+	require.NoError(t, err)
+	require.Len(t, progress, 1, "concurrent retries of the same chunk must not create duplicate rows")
+	assert.Equal(t, "completed", progress[0].State)
+	assert.Equal(t, int64(1024), progress[0].ReceivedBytes)
+}
+
+// TestObjectVersioning_DeleteMarkerAndRestore verifies that deleting a file
+// without a version_id creates a new current version rather than removing
+// the original, and that the original version remains fetchable by its
+// version_id.
+func TestObjectVersioning_DeleteMarkerAndRestore(t *testing.T) {
+	// Runs against testPool rather than setupTestDB's transaction: in one
+	// transaction the original version and the delete marker would share a
+	// created_at, and ListFileVersions orders newest first by created_at.
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(testPool)
+	t.Cleanup(func() {
+		testPool.Exec(ctx, "DELETE FROM files WHERE filename = $1", "versioned.txt")
+	})
+
+	file := &storage.File{Filename: "versioned.txt", ContentType: "text/plain", TotalSize: 1024}
+	require.NoError(t, store.CreateFile(ctx, file))
+	require.NotEqual(t, uuid.Nil, file.VersionID, "VersionID should be generated")
+	assert.False(t, file.IsDeleteMarker)
+
+	marker, err := store.CreateDeleteMarker(ctx, file)
+	require.NoError(t, err)
+	assert.True(t, marker.IsDeleteMarker)
+	assert.Equal(t, int64(0), marker.TotalSize)
+	assert.NotEqual(t, file.FileID, marker.FileID, "delete marker should be its own row")
+
+	versions, err := store.ListFileVersions(ctx, "versioned.txt")
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "original version and delete marker should both be listed")
+	assert.Equal(t, marker.VersionID, versions[0].VersionID, "newest version should be first")
+
+	original, err := store.GetFileByVersion(ctx, "versioned.txt", file.VersionID)
+	require.NoError(t, err)
+	assert.Equal(t, file.FileID, original.FileID)
+	assert.False(t, original.IsDeleteMarker)
+}
+
+// TestPendingChunkDelete_RetryLifecycle verifies the enqueue/list/increment/
+// delete cycle the version compactor drives when a DeleteChunk RPC fails.
+func TestPendingChunkDelete_RetryLifecycle(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(pool)
+
+	chunkID := uuid.New()
+	serverID := uuid.New()
+	require.NoError(t, store.EnqueuePendingChunkDelete(ctx, chunkID, serverID))
+
+	pending, err := store.GetPendingChunkDeletes(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, chunkID, pending[0].ChunkID)
+	assert.Equal(t, serverID, pending[0].ServerID)
+	assert.Equal(t, 0, pending[0].Attempts)
+
+	require.NoError(t, store.IncrementPendingChunkDeleteAttempts(ctx, pending[0].ID))
+
+	pending, err = store.GetPendingChunkDeletes(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+
+	require.NoError(t, store.DeletePendingChunkDelete(ctx, pending[0].ID))
+
+	pending, err = store.GetPendingChunkDeletes(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// TestFixtures_Load tests that the fixture-loading layer seeds a usable,
+// consistent set of rows on top of a freshly-migrated schema.
+func TestFixtures_Load(t *testing.T) {
+	tx, fx, cleanup := setupTestDBWithFixtures(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(tx)
+
+	file, err := store.GetFileByID(ctx, fx.FileID)
+	require.NoError(t, err, "Fixture file should be retrievable")
+	assert.Equal(t, "completed", file.UploadStatus)
+	assert.Len(t, file.Chunks, len(fx.ChunkIDs), "Fixture file should have its seeded chunks")
+
+	for _, chunk := range file.Chunks {
+		assert.Equal(t, fx.StorageServerID, chunk.StorageServerID)
+	}
+
+	var nodeCount int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM hash_ring_nodes WHERE server_id = $1", fx.StorageServerID).Scan(&nodeCount)
+	require.NoError(t, err)
+	assert.Equal(t, 150, nodeCount, "Fixture storage server should have its virtual nodes")
+}
@@ -0,0 +1,526 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// DefaultReclaimerInterval is the default interval between reconciliation scans.
+	DefaultReclaimerInterval = 1 * time.Minute
+	// DefaultDeadServerThreshold is how stale a storage server's last_heartbeat
+	// must be before its chunks are flagged for re-replication.
+	DefaultDeadServerThreshold = 2 * time.Minute
+	// DefaultStuckUploadDeadline is how long a file may sit in "uploading"
+	// without a matching live upload session before it's considered stuck.
+	DefaultStuckUploadDeadline = 30 * time.Minute
+)
+
+// ReplicationJob is a unit of work for a separate replicator process to
+// rehydrate a chunk missing a replica, e.g. because its source server died.
+type ReplicationJob struct {
+	JobID     uuid.UUID
+	ChunkID   uuid.UUID
+	SourceID  *uuid.UUID
+	TargetID  *uuid.UUID
+	State     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReclaimerConfig controls the reclaimer's dead-server and stuck-upload thresholds.
+type ReclaimerConfig struct {
+	DeadServerThreshold time.Duration
+	StuckUploadDeadline time.Duration
+}
+
+// DefaultReclaimerConfig returns the reclaimer's default thresholds.
+func DefaultReclaimerConfig() ReclaimerConfig {
+	return ReclaimerConfig{
+		DeadServerThreshold: DefaultDeadServerThreshold,
+		StuckUploadDeadline: DefaultStuckUploadDeadline,
+	}
+}
+
+// Reclaimer is a background reconciliation loop that detects chunks on dead
+// storage servers, files stuck mid-upload, and orphaned chunks, recording
+// each finding in the reclaimer_actions audit log and (for dead-server
+// chunks) queuing a replication_queue job for a separate replicator to act
+// on. Multiple Reclaimer instances can run concurrently across coordinator
+// replicas: storage_cleanups + `SELECT ... FOR UPDATE SKIP LOCKED` ensures
+// only one of them scans a given storage server at a time.
+type Reclaimer struct {
+	storage  *PostgresStorage
+	workerID string
+	config   ReclaimerConfig
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewReclaimer creates a Reclaimer with the default thresholds. workerID
+// identifies this process in storage_cleanups.acquired_by and should be
+// stable for the lifetime of the process (e.g. hostname or instance ID).
+func NewReclaimer(storage *PostgresStorage, workerID string) *Reclaimer {
+	return NewReclaimerWithConfig(storage, workerID, DefaultReclaimerConfig())
+}
+
+// NewReclaimerWithConfig creates a Reclaimer with custom thresholds.
+func NewReclaimerWithConfig(storage *PostgresStorage, workerID string, config ReclaimerConfig) *Reclaimer {
+	return &Reclaimer{
+		storage:  storage,
+		workerID: workerID,
+		config:   config,
+	}
+}
+
+// Run starts the reclaimer's background loop, scanning every interval.
+func (r *Reclaimer) Run(ctx context.Context, interval time.Duration) {
+	r.stopChan = make(chan struct{})
+	r.wg.Add(1)
+	go r.run(ctx, interval)
+	log.Printf("Reclaimer started (worker: %s, interval: %v)", r.workerID, interval)
+}
+
+// Stop stops the reclaimer's background loop.
+func (r *Reclaimer) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+	log.Println("Reclaimer stopped")
+}
+
+func (r *Reclaimer) run(ctx context.Context, interval time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	if err := r.reconcile(ctx); err != nil {
+		log.Printf("Reclaimer: error during initial reconciliation: %v", err)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.reconcile(ctx); err != nil {
+				log.Printf("Reclaimer: error during reconciliation: %v", err)
+			}
+		case <-r.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile runs one pass of every reconciliation check.
+func (r *Reclaimer) reconcile(ctx context.Context) error {
+	if err := r.reconcileDeadServer(ctx); err != nil {
+		log.Printf("Reclaimer: error reconciling dead servers: %v", err)
+	}
+	if err := r.reconcileStuckUploads(ctx); err != nil {
+		log.Printf("Reclaimer: error reconciling stuck uploads: %v", err)
+	}
+	if err := r.reconcileOrphanedChunks(ctx); err != nil {
+		log.Printf("Reclaimer: error reconciling orphaned chunks: %v", err)
+	}
+	return nil
+}
+
+// reconcileDeadServer claims the storage server with the oldest scan time,
+// and if its heartbeat is stale, flags every chunk it holds for
+// re-replication elsewhere.
+func (r *Reclaimer) reconcileDeadServer(ctx context.Context) error {
+	server, ok, err := r.storage.ClaimStorageCleanup(ctx, r.workerID)
+	if err != nil {
+		return fmt.Errorf("failed to claim storage server for scan: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if err := r.storage.ReleaseStorageCleanup(ctx, server.ServerID); err != nil {
+			log.Printf("Reclaimer: failed to release storage_cleanups row for %s: %v", server.ServerID, err)
+		}
+	}()
+
+	if time.Since(server.LastHeartbeat) < r.config.DeadServerThreshold {
+		return nil
+	}
+
+	chunks, err := r.storage.GetChunksByServerID(ctx, server.ServerID)
+	if err != nil {
+		return fmt.Errorf("failed to list chunks on server %s: %w", server.ServerID, err)
+	}
+
+	for _, chunk := range chunks {
+		job := &ReplicationJob{
+			ChunkID:  chunk.ChunkID,
+			SourceID: &server.ServerID,
+			State:    "pending",
+		}
+		if err := r.storage.EnqueueReplicationJob(ctx, job); err != nil {
+			log.Printf("Reclaimer: failed to enqueue replication job for chunk %s: %v", chunk.ChunkID, err)
+			continue
+		}
+		if err := r.storage.LogReclaimerAction(ctx, "dead_server_chunk_flagged", &chunk.ChunkID, &chunk.FileID, &server.ServerID,
+			fmt.Sprintf("server %s heartbeat stale since %s", server.ServerID, server.LastHeartbeat)); err != nil {
+			log.Printf("Reclaimer: failed to log action for chunk %s: %v", chunk.ChunkID, err)
+		}
+	}
+
+	if len(chunks) > 0 {
+		log.Printf("Reclaimer: flagged %d chunks on dead server %s for re-replication", len(chunks), server.ServerID)
+	}
+
+	return nil
+}
+
+// reconcileStuckUploads marks files that have been uploading past the
+// configured deadline, with no live upload session, as failed.
+func (r *Reclaimer) reconcileStuckUploads(ctx context.Context) error {
+	files, err := r.storage.GetStuckUploadingFiles(ctx, r.config.StuckUploadDeadline)
+	if err != nil {
+		return fmt.Errorf("failed to list stuck uploads: %w", err)
+	}
+
+	for _, file := range files {
+		if err := r.storage.UpdateFileStatus(ctx, file.FileID, "failed"); err != nil {
+			log.Printf("Reclaimer: failed to mark stuck upload %s as failed: %v", file.FileID, err)
+			continue
+		}
+		if err := r.storage.LogReclaimerAction(ctx, "stuck_upload_detected", nil, &file.FileID, nil,
+			fmt.Sprintf("file stuck in upload_status=%q since %s", file.UploadStatus, file.UpdatedAt)); err != nil {
+			log.Printf("Reclaimer: failed to log action for file %s: %v", file.FileID, err)
+		}
+	}
+
+	if len(files) > 0 {
+		log.Printf("Reclaimer: marked %d stuck uploads as failed", len(files))
+	}
+
+	return nil
+}
+
+// reconcileOrphanedChunks removes chunks whose parent file no longer exists.
+// Foreign-key cascade should make this a no-op in normal operation; it's a
+// safety net for any path that bypasses DeleteFile.
+func (r *Reclaimer) reconcileOrphanedChunks(ctx context.Context) error {
+	chunks, err := r.storage.GetOrphanedChunks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list orphaned chunks: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		if err := r.storage.DeleteChunk(ctx, chunk.ChunkID); err != nil {
+			log.Printf("Reclaimer: failed to delete orphaned chunk %s: %v", chunk.ChunkID, err)
+			continue
+		}
+		if err := r.storage.LogReclaimerAction(ctx, "orphaned_chunk_deleted", &chunk.ChunkID, &chunk.FileID, nil,
+			"parent file no longer exists"); err != nil {
+			log.Printf("Reclaimer: failed to log action for chunk %s: %v", chunk.ChunkID, err)
+		}
+	}
+
+	if len(chunks) > 0 {
+		log.Printf("Reclaimer: deleted %d orphaned chunks", len(chunks))
+	}
+
+	return nil
+}
+
+// EnsureStorageCleanupRow registers serverID in storage_cleanups so the
+// reclaimer can pick it up for scanning. Safe to call repeatedly (e.g. on
+// every storage server registration).
+func (s *PostgresStorage) EnsureStorageCleanupRow(ctx context.Context, serverID uuid.UUID) error {
+	query := `
+		INSERT INTO storage_cleanups (server_id)
+		VALUES ($1)
+		ON CONFLICT (server_id) DO NOTHING
+	`
+
+	if _, err := s.pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to register storage server for cleanup scanning: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimStorageCleanup claims the storage_cleanups row with the oldest
+// last_scan_at using SELECT ... FOR UPDATE SKIP LOCKED, so concurrent
+// reclaimer replicas never scan the same server at once. It returns
+// ok=false if no row is available to claim (e.g. all are currently held).
+func (s *PostgresStorage) ClaimStorageCleanup(ctx context.Context, workerID string) (*StorageServer, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var serverID uuid.UUID
+	selectQuery := `
+		SELECT server_id
+		FROM storage_cleanups
+		WHERE acquired_at IS NULL
+		ORDER BY last_scan_at ASC
+		FOR UPDATE SKIP LOCKED
+		LIMIT 1
+	`
+	err = tx.QueryRow(ctx, selectQuery).Scan(&serverID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to claim storage_cleanups row: %w", err)
+	}
+
+	updateQuery := `
+		UPDATE storage_cleanups
+		SET acquired_at = NOW(), acquired_by = $1, last_scan_at = NOW()
+		WHERE server_id = $2
+	`
+	if _, err := tx.Exec(ctx, updateQuery, workerID, serverID); err != nil {
+		return nil, false, fmt.Errorf("failed to acquire storage_cleanups row: %w", err)
+	}
+
+	var server StorageServer
+	serverQuery := `
+		SELECT server_id, grpc_address, status, available_space, used_space,
+		       last_heartbeat, created_at, updated_at
+		FROM storage_servers
+		WHERE server_id = $1
+	`
+	err = tx.QueryRow(ctx, serverQuery, serverID).Scan(
+		&server.ServerID,
+		&server.GRPCAddress,
+		&server.Status,
+		&server.AvailableSpace,
+		&server.UsedSpace,
+		&server.LastHeartbeat,
+		&server.CreatedAt,
+		&server.UpdatedAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load claimed storage server: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit claim: %w", err)
+	}
+
+	return &server, true, nil
+}
+
+// ReleaseStorageCleanup releases a storage_cleanups row claimed via
+// ClaimStorageCleanup so another replica (or the next scan) can pick it up.
+func (s *PostgresStorage) ReleaseStorageCleanup(ctx context.Context, serverID uuid.UUID) error {
+	query := `
+		UPDATE storage_cleanups
+		SET acquired_at = NULL, acquired_by = NULL
+		WHERE server_id = $1
+	`
+
+	if _, err := s.pool.Exec(ctx, query, serverID); err != nil {
+		return fmt.Errorf("failed to release storage_cleanups row: %w", err)
+	}
+
+	return nil
+}
+
+// GetChunksByServerID retrieves every chunk currently assigned to serverID.
+func (s *PostgresStorage) GetChunksByServerID(ctx context.Context, serverID uuid.UUID) ([]*Chunk, error) {
+	query := `
+		SELECT chunk_id, file_id, chunk_number, storage_server_id, chunk_size,
+		       chunk_hash, status, erasure_coded, created_at, updated_at
+		FROM chunks
+		WHERE storage_server_id = $1
+	`
+
+	rows, err := s.pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks by server: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		chunk := &Chunk{}
+		err := rows.Scan(
+			&chunk.ChunkID,
+			&chunk.FileID,
+			&chunk.ChunkNumber,
+			&chunk.StorageServerID,
+			&chunk.ChunkSize,
+			&chunk.ChunkHash,
+			&chunk.Status,
+			&chunk.ErasureCoded,
+			&chunk.CreatedAt,
+			&chunk.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// GetStuckUploadingFiles retrieves files that have been in the "uploading"
+// status for longer than deadline with no matching live upload session.
+func (s *PostgresStorage) GetStuckUploadingFiles(ctx context.Context, deadline time.Duration) ([]*File, error) {
+	query := `
+		SELECT f.file_id, f.filename, f.content_type, f.total_size, f.upload_status,
+		       COALESCE(f.checksum, ''), f.created_at, f.updated_at, f.completed_at
+		FROM files f
+		WHERE f.upload_status = 'uploading'
+		  AND f.updated_at < $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM upload_sessions us
+		      WHERE us.file_id = f.file_id AND us.status = 'active'
+		  )
+	`
+
+	cutoff := time.Now().Add(-deadline)
+	rows, err := s.pool.Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stuck uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var files []*File
+	for rows.Next() {
+		file := &File{}
+		err := rows.Scan(
+			&file.FileID,
+			&file.Filename,
+			&file.ContentType,
+			&file.TotalSize,
+			&file.UploadStatus,
+			&file.Checksum,
+			&file.CreatedAt,
+			&file.UpdatedAt,
+			&file.CompletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating files: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetOrphanedChunks retrieves chunks whose parent file no longer exists.
+func (s *PostgresStorage) GetOrphanedChunks(ctx context.Context) ([]*Chunk, error) {
+	query := `
+		SELECT c.chunk_id, c.file_id, c.chunk_number, c.storage_server_id, c.chunk_size,
+		       c.chunk_hash, c.status, c.erasure_coded, c.created_at, c.updated_at
+		FROM chunks c
+		LEFT JOIN files f ON f.file_id = c.file_id
+		WHERE f.file_id IS NULL
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		chunk := &Chunk{}
+		err := rows.Scan(
+			&chunk.ChunkID,
+			&chunk.FileID,
+			&chunk.ChunkNumber,
+			&chunk.StorageServerID,
+			&chunk.ChunkSize,
+			&chunk.ChunkHash,
+			&chunk.Status,
+			&chunk.ErasureCoded,
+			&chunk.CreatedAt,
+			&chunk.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// DeleteChunk deletes a single chunk record.
+func (s *PostgresStorage) DeleteChunk(ctx context.Context, chunkID uuid.UUID) error {
+	query := `DELETE FROM chunks WHERE chunk_id = $1`
+
+	result, err := s.pool.Exec(ctx, query, chunkID)
+	if err != nil {
+		return fmt.Errorf("failed to delete chunk: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// EnqueueReplicationJob records a replication job for a separate replicator
+// process to pick up and rehydrate a chunk onto a new storage server.
+func (s *PostgresStorage) EnqueueReplicationJob(ctx context.Context, job *ReplicationJob) error {
+	if job.JobID == uuid.Nil {
+		job.JobID = uuid.New()
+	}
+	if job.State == "" {
+		job.State = "pending"
+	}
+
+	query := `
+		INSERT INTO replication_queue (job_id, chunk_id, source_id, target_id, state)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at
+	`
+
+	err := s.pool.QueryRow(ctx, query, job.JobID, job.ChunkID, job.SourceID, job.TargetID, job.State).
+		Scan(&job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue replication job: %w", err)
+	}
+
+	return nil
+}
+
+// LogReclaimerAction appends an entry to the reclaimer_actions audit log.
+// chunkID, fileID, and serverID may each be nil when not applicable to
+// actionType.
+func (s *PostgresStorage) LogReclaimerAction(ctx context.Context, actionType string, chunkID, fileID, serverID *uuid.UUID, details string) error {
+	query := `
+		INSERT INTO reclaimer_actions (action_type, chunk_id, file_id, server_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := s.pool.Exec(ctx, query, actionType, chunkID, fileID, serverID, details); err != nil {
+		return fmt.Errorf("failed to log reclaimer action: %w", err)
+	}
+
+	return nil
+}
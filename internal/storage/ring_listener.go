@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorageServersChangedChannel is the Postgres NOTIFY channel storage
+// servers' inserts/updates/deletes are published on (see
+// migrations/005_storage_servers_notify.sql).
+const StorageServersChangedChannel = "storage_servers_changed"
+
+// notificationPollInterval bounds how long WaitForNotification blocks
+// between checks of stopChan, so Stop doesn't have to wait for an actual
+// notification to return.
+const notificationPollInterval = 30 * time.Second
+
+// HashRingChangeListener keeps a HashRing in sync across coordinator
+// processes by listening on StorageServersChangedChannel and reloading the
+// ring on every notification, instead of relying solely on polling.
+type HashRingChangeListener struct {
+	pool     *pgxpool.Pool
+	store    *PostgresStorage
+	ring     *HashRing
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewHashRingChangeListener creates a listener that reloads ring whenever a
+// storage server row changes.
+func NewHashRingChangeListener(pool *pgxpool.Pool, store *PostgresStorage, ring *HashRing) *HashRingChangeListener {
+	return &HashRingChangeListener{pool: pool, store: store, ring: ring}
+}
+
+// Run acquires a dedicated connection, issues LISTEN, and starts the
+// background goroutine that reloads the ring on each notification.
+func (l *HashRingChangeListener) Run(ctx context.Context) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring listener connection: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, fmt.Sprintf("LISTEN %s", StorageServersChangedChannel)); err != nil {
+		conn.Release()
+		return fmt.Errorf("listening on %s: %w", StorageServersChangedChannel, err)
+	}
+
+	l.stopChan = make(chan struct{})
+	l.wg.Add(1)
+	go l.run(ctx, conn)
+	return nil
+}
+
+func (l *HashRingChangeListener) run(ctx context.Context, conn *pgxpool.Conn) {
+	defer l.wg.Done()
+	defer conn.Release()
+
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		default:
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, notificationPollInterval)
+		_, err := conn.Conn().WaitForNotification(waitCtx)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Timeout waiting for a notification is expected; loop back
+			// around to re-check stopChan.
+			continue
+		}
+
+		if err := l.ring.Reload(ctx, l.store); err != nil {
+			log.Printf("HashRingChangeListener: failed to reload ring: %v", err)
+		}
+	}
+}
+
+// Stop signals the listener goroutine to exit and waits for it to do so.
+func (l *HashRingChangeListener) Stop() {
+	if l.stopChan == nil {
+		return
+	}
+	close(l.stopChan)
+	l.wg.Wait()
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultSessionReaperInterval is the default interval between expired
+// upload session sweeps.
+const DefaultSessionReaperInterval = 1 * time.Minute
+
+// SessionReaper is a background loop that deletes expired upload sessions
+// and releases the chunk-slot reservations they held on the placement
+// ring, so abandoned resumable uploads don't leak capacity accounting.
+// Deleting a session cascades to its session_chunks rows via foreign key.
+type SessionReaper struct {
+	storage  *PostgresStorage
+	ring     *HashRing
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewSessionReaper creates a SessionReaper that sweeps storage for expired
+// sessions and releases their reservations on ring.
+func NewSessionReaper(storage *PostgresStorage, ring *HashRing) *SessionReaper {
+	return &SessionReaper{storage: storage, ring: ring}
+}
+
+// Run starts the reaper's background loop, sweeping every interval.
+func (r *SessionReaper) Run(ctx context.Context, interval time.Duration) {
+	r.stopChan = make(chan struct{})
+	r.wg.Add(1)
+	go r.run(ctx, interval)
+	log.Printf("SessionReaper started (interval: %v)", interval)
+}
+
+// Stop stops the reaper's background loop.
+func (r *SessionReaper) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+	log.Println("SessionReaper stopped")
+}
+
+func (r *SessionReaper) run(ctx context.Context, interval time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	if err := r.sweep(ctx); err != nil {
+		log.Printf("SessionReaper: error during initial sweep: %v", err)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.sweep(ctx); err != nil {
+				log.Printf("SessionReaper: error during sweep: %v", err)
+			}
+		case <-r.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweep finds expired sessions, releases any chunk-slot reservations their
+// unfinished chunks were holding, and deletes the session.
+func (r *SessionReaper) sweep(ctx context.Context) error {
+	sessions, err := r.storage.GetExpiredSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := r.reapSession(ctx, session); err != nil {
+			log.Printf("SessionReaper: error reaping session %s: %v", session.SessionID, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *SessionReaper) reapSession(ctx context.Context, session *UploadSession) error {
+	progress, err := r.storage.GetSessionProgress(ctx, session.SessionID)
+	if err != nil {
+		return err
+	}
+
+	completed := make(map[int]bool, len(progress))
+	for _, p := range progress {
+		if p.State == "completed" {
+			completed[p.ChunkNumber] = true
+		}
+	}
+
+	for chunkNumber := 0; chunkNumber < session.ExpectedChunks; chunkNumber++ {
+		if completed[chunkNumber] {
+			continue
+		}
+		for _, serverID := range r.ring.PlaceChunk(session.FileID, chunkNumber, 1) {
+			r.ring.ReleaseChunkSlot(serverID)
+		}
+	}
+
+	if err := r.storage.DeleteUploadSession(ctx, session.SessionID); err != nil {
+		return err
+	}
+
+	log.Printf("SessionReaper: reaped expired session %s (file %s)", session.SessionID, session.FileID)
+	return nil
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ServerUsage is one storage server's reported capacity, as of its last
+// heartbeat.
+type ServerUsage struct {
+	ServerID       uuid.UUID
+	GRPCAddress    string
+	UsedBytes      int64
+	AvailableBytes int64
+}
+
+// FileSummary is a minimal file description used for the usage endpoint's
+// top-N largest files list.
+type FileSummary struct {
+	FileID   uuid.UUID
+	Filename string
+	Size     int64
+}
+
+// UsageStats is the full set of aggregates behind GET /admin/usage.
+type UsageStats struct {
+	TotalBytes   int64
+	StatusCounts map[string]int64
+	Servers      []ServerUsage
+	TopFiles     []FileSummary
+}
+
+// GetUsageStats computes the aggregates behind GET /admin/usage: total bytes
+// stored, per-status file counts, per-server used/available space, and the
+// topN largest files. It's a handful of separate queries rather than one big
+// join, since each aggregate scans a different table/grouping; callers that
+// hit this often (a dashboard, a scrape loop) should go through
+// usagecache.Cache instead of calling it directly, since each of these is an
+// unindexed full scan of files that gets slower as the table grows.
+func (s *PostgresStorage) GetUsageStats(ctx context.Context, topN int) (*UsageStats, error) {
+	stats := &UsageStats{StatusCounts: make(map[string]int64)}
+
+	if err := s.pool.QueryRow(ctx,
+		`SELECT COALESCE(SUM(total_size), 0) FROM files WHERE is_delete_marker = false`,
+	).Scan(&stats.TotalBytes); err != nil {
+		return nil, fmt.Errorf("failed to sum total file size: %w", err)
+	}
+
+	statusRows, err := s.pool.Query(ctx,
+		`SELECT upload_status, COUNT(*) FROM files WHERE is_delete_marker = false GROUP BY upload_status`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count files by status: %w", err)
+	}
+	for statusRows.Next() {
+		var status string
+		var count int64
+		if err := statusRows.Scan(&status, &count); err != nil {
+			statusRows.Close()
+			return nil, fmt.Errorf("failed to scan status count: %w", err)
+		}
+		stats.StatusCounts[status] = count
+	}
+	if err := statusRows.Err(); err != nil {
+		statusRows.Close()
+		return nil, fmt.Errorf("error iterating status counts: %w", err)
+	}
+	statusRows.Close()
+
+	serverRows, err := s.pool.Query(ctx,
+		`SELECT server_id, grpc_address, used_space, available_space FROM storage_servers ORDER BY server_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query storage server usage: %w", err)
+	}
+	for serverRows.Next() {
+		var su ServerUsage
+		if err := serverRows.Scan(&su.ServerID, &su.GRPCAddress, &su.UsedBytes, &su.AvailableBytes); err != nil {
+			serverRows.Close()
+			return nil, fmt.Errorf("failed to scan server usage: %w", err)
+		}
+		stats.Servers = append(stats.Servers, su)
+	}
+	if err := serverRows.Err(); err != nil {
+		serverRows.Close()
+		return nil, fmt.Errorf("error iterating server usage: %w", err)
+	}
+	serverRows.Close()
+
+	fileRows, err := s.pool.Query(ctx,
+		`SELECT file_id, filename, total_size FROM files
+		 WHERE is_delete_marker = false
+		 ORDER BY total_size DESC
+		 LIMIT $1`,
+		topN,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top files: %w", err)
+	}
+	for fileRows.Next() {
+		var fs FileSummary
+		if err := fileRows.Scan(&fs.FileID, &fs.Filename, &fs.Size); err != nil {
+			fileRows.Close()
+			return nil, fmt.Errorf("failed to scan top file: %w", err)
+		}
+		stats.TopFiles = append(stats.TopFiles, fs)
+	}
+	if err := fileRows.Err(); err != nil {
+		fileRows.Close()
+		return nil, fmt.Errorf("error iterating top files: %w", err)
+	}
+	fileRows.Close()
+
+	return stats, nil
+}
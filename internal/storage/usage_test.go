@@ -0,0 +1,45 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/s3storage/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUsageStats_Aggregates verifies GetUsageStats reports total bytes,
+// per-status counts, per-server capacity, and the top-N largest files
+// correctly against a small, hand-built dataset.
+func TestGetUsageStats_Aggregates(t *testing.T) {
+	tx, fx, cleanup := setupTestDBWithFixtures(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := storage.NewPostgresStorage(tx)
+
+	small := &storage.File{Filename: "small.txt", ContentType: "text/plain", TotalSize: 10, UploadStatus: "pending"}
+	require.NoError(t, store.CreateFile(ctx, small))
+
+	big := &storage.File{Filename: "big.bin", ContentType: "application/octet-stream", TotalSize: 1_000_000, UploadStatus: "pending"}
+	require.NoError(t, store.CreateFile(ctx, big))
+
+	stats, err := store.GetUsageStats(ctx, 2)
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, stats.TotalBytes, int64(1_000_010), "total bytes should include both new files plus any fixtures")
+	assert.GreaterOrEqual(t, stats.StatusCounts["pending"], int64(2))
+
+	require.NotEmpty(t, stats.Servers, "fixtures should have created at least one storage server")
+	var sawFixtureServer bool
+	for _, s := range stats.Servers {
+		if s.ServerID == fx.StorageServerID {
+			sawFixtureServer = true
+		}
+	}
+	assert.True(t, sawFixtureServer, "fixture server should appear in per-server usage")
+
+	require.Len(t, stats.TopFiles, 2, "topN=2 should cap the result")
+	assert.Equal(t, big.FileID, stats.TopFiles[0].FileID, "largest file should be first")
+}
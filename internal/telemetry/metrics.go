@@ -0,0 +1,194 @@
+package telemetry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RED-style metrics shared across the gateway and storage nodes.
+var (
+	// RequestDuration records request latency by logical operation and
+	// outcome status, e.g. op="DownloadFile" status="success".
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "request_duration_seconds",
+		Help:    "Request duration in seconds, labeled by operation and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op", "status"})
+
+	// HashRingSize is the number of storage servers currently in the
+	// consistent hash ring.
+	HashRingSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hash_ring_size",
+		Help: "Number of storage servers currently registered in the consistent hash ring.",
+	})
+
+	// CircuitBreakerState reports each storage server's circuit breaker
+	// state as a gauge, labeled by server ID. The value matches
+	// circuitbreaker.State's own numbering (0=closed, 1=open, 2=half-open).
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "Circuit breaker state per storage server (0=closed, 1=open, 2=half-open).",
+	}, []string{"server_id"})
+
+	// RetryAttempts counts retry attempts made by the retry package, labeled
+	// by the operation that requested the retry.
+	RetryAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retry_attempts_total",
+		Help: "Number of retry attempts made, labeled by operation.",
+	}, []string{"op"})
+
+	// HeartbeatFailures counts failed storage_servers heartbeat/liveness
+	// writes, labeled by server ID.
+	HeartbeatFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "heartbeat_failures_total",
+		Help: "Number of failed storage server heartbeat writes, labeled by server ID.",
+	}, []string{"server_id"})
+
+	// HashRingLookupDuration records hasher.HashRing.GetServer latency.
+	HashRingLookupDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hash_ring_lookup_duration_seconds",
+		Help:    "Latency of in-memory hash ring key lookups.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// HashRingKeysPerServer counts hasher.HashRing.GetServer lookups
+	// resolved to each server, labeled by server ID, so operators can catch
+	// placement imbalance in real time.
+	HashRingKeysPerServer = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hash_ring_keys_per_server_total",
+		Help: "Number of hash ring lookups resolved to each storage server.",
+	}, []string{"server_id"})
+
+	// PlacementDecisions counts chunk placement lookups made by
+	// storage.HashRing, labeled by whether the server returned was the
+	// primary or a replica.
+	PlacementDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "placement_decisions_total",
+		Help: "Number of chunk placement decisions made by the placement hash ring, labeled by role.",
+	}, []string{"role"})
+
+	// HashRingReloads counts full reloads of the placement ring from
+	// hash_ring_nodes, e.g. on startup or a storage_servers_changed
+	// notification.
+	HashRingReloads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "placement_ring_reloads_total",
+		Help: "Number of times the placement hash ring was reloaded from the database.",
+	})
+
+	// RebalanceJobsGenerated counts replication_queue jobs enqueued because
+	// a chunk's placement no longer matched the ring after a server was
+	// added or removed.
+	RebalanceJobsGenerated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "placement_rebalance_jobs_total",
+		Help: "Number of rebalance jobs enqueued after a hash ring change, labeled by reason.",
+	}, []string{"reason"})
+
+	// UsageTotalBytes is the total size of all non-deleted files, as of the
+	// last usagecache.Cache refresh.
+	UsageTotalBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "usage_total_bytes",
+		Help: "Total size in bytes of all non-deleted files, refreshed on the usage cache's TTL.",
+	})
+
+	// UsageServerUsedBytes/UsageServerAvailableBytes report each storage
+	// server's last-reported capacity, as of the last usagecache.Cache
+	// refresh, so a scrape doesn't have to query Postgres directly.
+	UsageServerUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "usage_server_used_bytes",
+		Help: "Bytes used, per storage server, as of the last usage cache refresh.",
+	}, []string{"server_id"})
+	UsageServerAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "usage_server_available_bytes",
+		Help: "Bytes available, per storage server, as of the last usage cache refresh.",
+	}, []string{"server_id"})
+
+	// UsageFilesByStatus reports the file count per upload_status, as of the
+	// last usagecache.Cache refresh.
+	UsageFilesByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "usage_files_by_status",
+		Help: "Number of files per upload status, as of the last usage cache refresh.",
+	}, []string{"status"})
+
+	// BandwidthBytesIn/BandwidthBytesOut are cumulative gRPC payload bytes
+	// received from/sent to each storage server, as of the last
+	// bwmeter.Meter sample.
+	BandwidthBytesIn = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_bytes_in",
+		Help: "Cumulative gRPC payload bytes received from this storage server.",
+	}, []string{"server_id"})
+	BandwidthBytesOut = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_bytes_out",
+		Help: "Cumulative gRPC payload bytes sent to this storage server.",
+	}, []string{"server_id"})
+
+	// BandwidthThroughputIn/BandwidthThroughputOut are each storage
+	// server's EWMA-smoothed throughput, in bytes/sec, as of the last
+	// bwmeter.Meter sample.
+	BandwidthThroughputIn = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_throughput_in_bytes_per_second",
+		Help: "EWMA-smoothed inbound throughput per storage server, in bytes/sec.",
+	}, []string{"server_id"})
+	BandwidthThroughputOut = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_throughput_out_bytes_per_second",
+		Help: "EWMA-smoothed outbound throughput per storage server, in bytes/sec.",
+	}, []string{"server_id"})
+
+	// BandwidthInFlightStreams is the number of gRPC streams currently open
+	// to each storage server.
+	BandwidthInFlightStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_in_flight_streams",
+		Help: "Number of gRPC streams currently open to this storage server.",
+	}, []string{"server_id"})
+
+	// BandwidthConcurrencyLimit/BandwidthConcurrencyInFlight report each
+	// storage server's adaptive concurrency limiter state - see
+	// internal/bwmeter.ConcurrencyLimiter.
+	BandwidthConcurrencyLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_concurrency_limit",
+		Help: "Current AIMD concurrency cap for chunk transfers to this storage server.",
+	}, []string{"server_id"})
+	BandwidthConcurrencyInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bandwidth_concurrency_in_flight",
+		Help: "Chunk transfers currently in flight to this storage server.",
+	}, []string{"server_id"})
+
+	// StorageServerAvailableBytes/StorageServerUsedBytes are a storage
+	// server's own view of its capacity, sourced from its DB record and
+	// refreshed alongside its liveness heartbeat. Unlike
+	// UsageServer{Used,Available}Bytes (the gateway's cached view of every
+	// server), these are emitted by the storage-server binary itself about
+	// only its own instance.
+	StorageServerAvailableBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "storage_server_available_bytes",
+		Help: "Bytes available on this storage server, as of its DB record.",
+	})
+	StorageServerUsedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "storage_server_used_bytes",
+		Help: "Bytes used on this storage server, as of its DB record.",
+	})
+
+	// NotifyQueueDepth is the number of events currently buffered in an
+	// internal/notify.Queue, sampled on every Enqueue and delivery. A
+	// sustained rise indicates replication handlers are falling behind.
+	NotifyQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "notify_queue_depth",
+		Help: "Number of events currently buffered in the chunk-write notification queue.",
+	})
+)
+
+func init() {
+	for _, c := range []prometheus.Collector{
+		RequestDuration, HashRingSize, CircuitBreakerState, RetryAttempts, HeartbeatFailures,
+		HashRingLookupDuration, HashRingKeysPerServer,
+		PlacementDecisions, HashRingReloads, RebalanceJobsGenerated,
+		UsageTotalBytes, UsageServerUsedBytes, UsageServerAvailableBytes, UsageFilesByStatus,
+		BandwidthBytesIn, BandwidthBytesOut, BandwidthThroughputIn, BandwidthThroughputOut,
+		BandwidthInFlightStreams, BandwidthConcurrencyLimit, BandwidthConcurrencyInFlight,
+		StorageServerAvailableBytes, StorageServerUsedBytes, NotifyQueueDepth,
+	} {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package telemetry centralizes OpenTelemetry tracing setup and the
+// Prometheus RED metrics shared by the API gateway and storage servers, so
+// both binaries wire up observability the same way.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// InitTracer configures the global OpenTelemetry tracer provider for
+// serviceName, exporting spans over OTLP/gRPC to otlpEndpoint. If
+// otlpEndpoint is empty, a tracer provider is still installed (so spans can
+// be created without nil checks everywhere) but it never exports; this lets
+// tracing stay a no-op in environments where OTEL_EXPORTER_OTLP_ENDPOINT
+// isn't set. The returned shutdown func should be called during graceful
+// shutdown to flush any buffered spans.
+func InitTracer(ctx context.Context, serviceName, otlpEndpoint string) (func(context.Context) error, error) {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otel resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	if otlpEndpoint != "" {
+		exporter, err := otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(otlpEndpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
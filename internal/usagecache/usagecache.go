@@ -0,0 +1,140 @@
+// Package usagecache caches the expensive aggregates behind GET
+// /admin/usage (total bytes stored, per-server capacity, per-status file
+// counts, top-N largest files) so a dashboard or a Prometheus scrape doesn't
+// turn into a full table scan on every request. It's the same "cache
+// account info for up to 10s" pattern used elsewhere in this module, applied
+// to storage.PostgresStorage's usage aggregates: a single background
+// refresh runs per TTL expiry (coalesced across concurrent callers with a
+// singleflight-style call), and callers are served the last known-good
+// value while that refresh is in flight (stale-while-revalidate) rather than
+// blocking on Postgres.
+package usagecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/s3storage/internal/storage"
+	"github.com/s3storage/internal/telemetry"
+)
+
+// DefaultTTL is how long a cached Stats value is served before a refresh is
+// triggered.
+const DefaultTTL = 10 * time.Second
+
+// DefaultTopN is how many of the largest files Stats.TopFiles reports by
+// default.
+const DefaultTopN = 10
+
+// Cache serves storage.PostgresStorage's usage aggregates, refreshing them
+// at most once per TTL no matter how many callers ask. The zero value is not
+// usable; construct one with New.
+type Cache struct {
+	store *storage.PostgresStorage
+	ttl   time.Duration
+	topN  int
+
+	mu          sync.RWMutex
+	stats       *storage.UsageStats
+	lastRefresh time.Time
+
+	inflightMu sync.Mutex
+	inflight   *refreshCall
+}
+
+// refreshCall tracks a single in-flight refresh so concurrent Get calls that
+// observe the same staleness coalesce onto one Postgres round trip instead
+// of each issuing their own.
+type refreshCall struct {
+	wg    sync.WaitGroup
+	stats *storage.UsageStats
+	err   error
+}
+
+// New creates a Cache backed by store. ttl <= 0 uses DefaultTTL; topN <= 0
+// uses DefaultTopN.
+func New(store *storage.PostgresStorage, ttl time.Duration, topN int) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if topN <= 0 {
+		topN = DefaultTopN
+	}
+	return &Cache{store: store, ttl: ttl, topN: topN}
+}
+
+// Get returns the cached Stats, refreshing synchronously if nothing has
+// been loaded yet. Once a value exists, a caller that finds it stale
+// triggers a background refresh (coalesced with any other concurrent
+// refresh) and still gets the stale value back immediately rather than
+// waiting on Postgres.
+func (c *Cache) Get(ctx context.Context) (*storage.UsageStats, error) {
+	c.mu.RLock()
+	stats := c.stats
+	stale := stats == nil || time.Since(c.lastRefresh) > c.ttl
+	c.mu.RUnlock()
+
+	if stats == nil {
+		// Nothing cached yet: block on the first load.
+		return c.refresh(ctx)
+	}
+
+	if stale {
+		go func() {
+			// Background refresh: a fresh context, since the triggering
+			// request's ctx may be cancelled long before this completes.
+			c.refresh(context.Background())
+		}()
+	}
+
+	return stats, nil
+}
+
+// refresh coalesces concurrent callers onto a single Postgres round trip and
+// updates the cache (and the usage_* Prometheus gauges) with its result.
+func (c *Cache) refresh(ctx context.Context) (*storage.UsageStats, error) {
+	c.inflightMu.Lock()
+	if call := c.inflight; call != nil {
+		c.inflightMu.Unlock()
+		call.wg.Wait()
+		return call.stats, call.err
+	}
+
+	call := &refreshCall{}
+	call.wg.Add(1)
+	c.inflight = call
+	c.inflightMu.Unlock()
+
+	call.stats, call.err = c.store.GetUsageStats(ctx, c.topN)
+	call.wg.Done()
+
+	c.inflightMu.Lock()
+	c.inflight = nil
+	c.inflightMu.Unlock()
+
+	if call.err == nil {
+		c.mu.Lock()
+		c.stats = call.stats
+		c.lastRefresh = time.Now()
+		c.mu.Unlock()
+		updateGauges(call.stats)
+	}
+
+	return call.stats, call.err
+}
+
+// updateGauges mirrors stats onto the usage_* Prometheus gauges so a scrape
+// reads already-computed values instead of hitting Postgres itself.
+func updateGauges(stats *storage.UsageStats) {
+	telemetry.UsageTotalBytes.Set(float64(stats.TotalBytes))
+
+	for status, count := range stats.StatusCounts {
+		telemetry.UsageFilesByStatus.WithLabelValues(status).Set(float64(count))
+	}
+
+	for _, server := range stats.Servers {
+		telemetry.UsageServerUsedBytes.WithLabelValues(server.ServerID.String()).Set(float64(server.UsedBytes))
+		telemetry.UsageServerAvailableBytes.WithLabelValues(server.ServerID.String()).Set(float64(server.AvailableBytes))
+	}
+}